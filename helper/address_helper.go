@@ -0,0 +1,220 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+)
+
+/********************************
+		Address spaces
+*********************************/
+
+// azureReservedAddresses is the number of addresses Azure reserves in every subnet prefix:
+// network address, default gateway, two DNS addresses and broadcast address
+const azureReservedAddresses = 5
+
+// SubnetCapacity is the use of the addresses of a subnet
+type SubnetCapacity struct {
+	Name            string
+	AddressPrefixes []string
+	// Size is the number of addresses of the prefixes, capped to math.MaxInt64 for large IPv6 prefixes
+	Size int64
+	// Reserved is the number of addresses reserved by Azure
+	Reserved int64
+	// Used is the number of IP configurations in the subnet (NICs, private endpoints, load balancer frontends...)
+	Used int64
+	// Available is the number of addresses still free
+	Available int64
+}
+
+// VirtualNetworkAddressReport is the outcome of checking the address space of a virtual network
+type VirtualNetworkAddressReport struct {
+	// Overlaps describes each pair of overlapping prefixes among the virtual network and its peered virtual networks
+	Overlaps []string
+	// SubnetsOutsideAddressSpace describes each subnet prefix outside the address space of the virtual network
+	SubnetsOutsideAddressSpace []string
+	Subnets                    []SubnetCapacity
+}
+
+// SubnetsBelowCapacity returns the subnets with less than minimumAvailable free addresses
+func (r VirtualNetworkAddressReport) SubnetsBelowCapacity(minimumAvailable int64) []SubnetCapacity {
+	subnets := make([]SubnetCapacity, 0)
+	for _, subnet := range r.Subnets {
+		if subnet.Available < minimumAvailable {
+			subnets = append(subnets, subnet)
+		}
+	}
+	return subnets
+}
+
+// GetVirtualNetworkAddressReportE checks the address space of a virtual network, see EvaluateVirtualNetworkAddressesE
+func GetVirtualNetworkAddressReportE(resourceGroupName, virtualNetworkName string, subscriptionID ...string) (*VirtualNetworkAddressReport, error) {
+	return GetVirtualNetworkAddressReportWithContextE(context.Background(), resourceGroupName, virtualNetworkName, subscriptionID...)
+}
+
+// GetVirtualNetworkAddressReportWithContextE checks the address space of a virtual network, see EvaluateVirtualNetworkAddressesE
+func GetVirtualNetworkAddressReportWithContextE(ctx context.Context, resourceGroupName, virtualNetworkName string, subscriptionID ...string) (*VirtualNetworkAddressReport, error) {
+	virtualNetwork, err := GetVirtualNetworkWithContextE(ctx, resourceGroupName, virtualNetworkName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+	peerings, err := ListVirtualNetworkPeeringWithContextE(ctx, resourceGroupName, virtualNetworkName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+	return EvaluateVirtualNetworkAddressesE(virtualNetwork, peerings)
+}
+
+// EvaluateVirtualNetworkAddressesE checks offline that the address space of a virtual network doesn't overlap the address space
+// of its peered virtual networks, nor the peered virtual networks each other (as for the spokes of a hub), and that every subnet
+// is inside the address space. It also counts the free addresses of each subnet, leaving out the 5 addresses reserved by Azure.
+func EvaluateVirtualNetworkAddressesE(virtualNetwork *network.VirtualNetwork, peerings []network.VirtualNetworkPeering) (*VirtualNetworkAddressReport, error) {
+	if virtualNetwork == nil || virtualNetwork.VirtualNetworkPropertiesFormat == nil {
+		return nil, fmt.Errorf("Virtual network has no properties")
+	}
+	report := &VirtualNetworkAddressReport{
+		Overlaps:                   make([]string, 0),
+		SubnetsOutsideAddressSpace: make([]string, 0),
+		Subnets:                    make([]SubnetCapacity, 0),
+	}
+
+	// address spaces of the virtual network and of its peered virtual networks, by name
+	names := []string{stringValue(virtualNetwork.Name)}
+	spaces := make([][]*net.IPNet, 1)
+	var err error
+	if virtualNetwork.AddressSpace != nil && virtualNetwork.AddressSpace.AddressPrefixes != nil {
+		if spaces[0], err = parsePrefixes(*virtualNetwork.AddressSpace.AddressPrefixes); err != nil {
+			return nil, fmt.Errorf("Virtual network %s: %s", names[0], err)
+		}
+	}
+	for _, peering := range peerings {
+		properties := peering.VirtualNetworkPeeringPropertiesFormat
+		if properties == nil || properties.RemoteAddressSpace == nil || properties.RemoteAddressSpace.AddressPrefixes == nil {
+			continue
+		}
+		name := stringValue(peering.Name)
+		if properties.RemoteVirtualNetwork != nil && properties.RemoteVirtualNetwork.ID != nil {
+			if id, err := ParseResourceIDE(*properties.RemoteVirtualNetwork.ID); err == nil {
+				name = id.Name()
+			}
+		}
+		prefixes, err := parsePrefixes(*properties.RemoteAddressSpace.AddressPrefixes)
+		if err != nil {
+			return nil, fmt.Errorf("Peering %s: %s", stringValue(peering.Name), err)
+		}
+		names = append(names, name)
+		spaces = append(spaces, prefixes)
+	}
+	for i := range spaces {
+		for j := i + 1; j < len(spaces); j++ {
+			for _, a := range spaces[i] {
+				for _, b := range spaces[j] {
+					if a.Contains(b.IP) || b.Contains(a.IP) {
+						report.Overlaps = append(report.Overlaps, fmt.Sprintf("%s (%s) overlaps %s (%s)", a, names[i], b, names[j]))
+					}
+				}
+			}
+		}
+	}
+
+	if virtualNetwork.Subnets == nil {
+		return report, nil
+	}
+	for _, subnet := range *virtualNetwork.Subnets {
+		if subnet.SubnetPropertiesFormat == nil {
+			continue
+		}
+		capacity := SubnetCapacity{
+			Name:            stringValue(subnet.Name),
+			AddressPrefixes: stringList(subnet.AddressPrefix, subnet.AddressPrefixes),
+		}
+		prefixes, err := parsePrefixes(capacity.AddressPrefixes)
+		if err != nil {
+			return nil, fmt.Errorf("Subnet %s: %s", capacity.Name, err)
+		}
+		for _, prefix := range prefixes {
+			if !prefixWithin(prefix, spaces[0]) {
+				report.SubnetsOutsideAddressSpace = append(report.SubnetsOutsideAddressSpace,
+					fmt.Sprintf("%s (%s) is outside the address space of %s", prefix, capacity.Name, names[0]))
+			}
+			capacity.Size = addCapped(capacity.Size, prefixSize(prefix))
+			capacity.Reserved += azureReservedAddresses
+		}
+		if subnet.IPConfigurations != nil {
+			capacity.Used = int64(len(*subnet.IPConfigurations))
+		}
+		capacity.Available = capacity.Size - capacity.Reserved - capacity.Used
+		if capacity.Available < 0 {
+			capacity.Available = 0
+		}
+		report.Subnets = append(report.Subnets, capacity)
+	}
+	sort.Slice(report.Subnets, func(i, j int) bool { return report.Subnets[i].Name < report.Subnets[j].Name })
+	return report, nil
+}
+
+// AssertVirtualNetworkAddresses fails the test when the address space of a virtual network overlaps a peered virtual network,
+// when a subnet is outside the address space or when a subnet has less than minimumAvailable free addresses
+func AssertVirtualNetworkAddresses(t *testing.T, resourceGroupName, virtualNetworkName string, minimumAvailable int64, subscriptionID ...string) {
+	t.Helper()
+	report, err := GetVirtualNetworkAddressReportWithContextE(NewTestContext(t), resourceGroupName, virtualNetworkName, subscriptionID...)
+	failOnAzureError(t, err, "check the address space of", "Microsoft.Network/virtualNetworks", virtualNetworkName, resourceGroupName)
+
+	issues := make([]string, 0)
+	issues = append(issues, report.Overlaps...)
+	issues = append(issues, report.SubnetsOutsideAddressSpace...)
+	for _, subnet := range report.SubnetsBelowCapacity(minimumAvailable) {
+		issues = append(issues, fmt.Sprintf("subnet %s (%s) has %d free addresses, expected at least %d",
+			subnet.Name, strings.Join(subnet.AddressPrefixes, ", "), subnet.Available, minimumAvailable))
+	}
+	if len(issues) > 0 {
+		t.Errorf("Virtual network %s in resource group %s has %d address issues:\n  %s", virtualNetworkName, resourceGroupName, len(issues), strings.Join(issues, "\n  "))
+	}
+}
+
+func parsePrefixes(prefixes []string) ([]*net.IPNet, error) {
+	parsed := make([]*net.IPNet, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(prefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid address prefix %s", prefix)
+		}
+		parsed = append(parsed, ipNet)
+	}
+	return parsed, nil
+}
+
+// prefixWithin tells whether prefix is inside one of the prefixes of an address space
+func prefixWithin(prefix *net.IPNet, space []*net.IPNet) bool {
+	length, _ := prefix.Mask.Size()
+	for _, spacePrefix := range space {
+		spaceLength, _ := spacePrefix.Mask.Size()
+		if spacePrefix.Contains(prefix.IP) && spaceLength <= length {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixSize returns the number of addresses of a prefix, capped to math.MaxInt64
+func prefixSize(prefix *net.IPNet) int64 {
+	length, bits := prefix.Mask.Size()
+	if bits-length >= 63 {
+		return math.MaxInt64
+	}
+	return int64(1) << uint(bits-length)
+}
+
+func addCapped(a int64, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
@@ -0,0 +1,121 @@
+package helper
+
+import (
+	"math"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateVirtualNetworkAddressesE(t *testing.T) {
+	var virtualNetwork network.VirtualNetwork
+	loadTestNetworkResource(t, filepath.Join("addresses", "vnet-hub.json"), &virtualNetwork)
+	var peerings []network.VirtualNetworkPeering
+	loadTestNetworkResource(t, filepath.Join("addresses", "peerings.json"), &peerings)
+
+	report, err := EvaluateVirtualNetworkAddressesE(&virtualNetwork, peerings)
+	require.NoError(t, err)
+	// peerings are named after their remote virtual network when it is known
+	assert.Equal(t, []string{
+		"10.0.0.0/16 (vnet-hub) overlaps 10.0.128.0/17 (hub-to-legacy)",
+		"10.1.0.0/16 (vnet-spoke1) overlaps 10.1.128.0/17 (vnet-spoke2)",
+	}, report.Overlaps)
+	// a prefix starting in the address space but larger than it is outside
+	assert.Equal(t, []string{
+		"10.1.0.0/24 (snet-outside) is outside the address space of vnet-hub",
+		"10.0.0.0/15 (snet-larger) is outside the address space of vnet-hub",
+	}, report.SubnetsOutsideAddressSpace)
+	assert.Equal(t, []SubnetCapacity{
+		{Name: "GatewaySubnet", AddressPrefixes: []string{"10.0.0.0/27"}, Size: 32, Reserved: 5, Used: 2, Available: 25},
+		// the /64 has more addresses than an int64 holds
+		{Name: "snet-dual-stack", AddressPrefixes: []string{"10.0.2.0/24", "fd00:db8:0:1::/64"}, Size: math.MaxInt64, Reserved: 10, Available: math.MaxInt64 - 10},
+		{Name: "snet-larger", AddressPrefixes: []string{"10.0.0.0/15"}, Size: 131072, Reserved: 5, Available: 131067},
+		{Name: "snet-outside", AddressPrefixes: []string{"10.1.0.0/24"}, Size: 256, Reserved: 5, Available: 251},
+		// more IP configurations than free addresses
+		{Name: "snet-small", AddressPrefixes: []string{"10.0.1.0/29"}, Size: 8, Reserved: 5, Used: 4, Available: 0},
+	}, report.Subnets)
+
+	var names []string
+	for _, subnet := range report.SubnetsBelowCapacity(26) {
+		names = append(names, subnet.Name)
+	}
+	assert.Equal(t, []string{"GatewaySubnet", "snet-small"}, names)
+	assert.Empty(t, report.SubnetsBelowCapacity(0))
+}
+
+func TestEvaluateVirtualNetworkAddressesEWithoutPeerings(t *testing.T) {
+	var virtualNetwork network.VirtualNetwork
+	loadTestNetworkResource(t, filepath.Join("addresses", "vnet-hub.json"), &virtualNetwork)
+
+	report, err := EvaluateVirtualNetworkAddressesE(&virtualNetwork, nil)
+	require.NoError(t, err)
+	assert.Empty(t, report.Overlaps)
+	assert.Len(t, report.Subnets, 5)
+}
+
+func TestEvaluateVirtualNetworkAddressesEErrors(t *testing.T) {
+	_, err := EvaluateVirtualNetworkAddressesE(&network.VirtualNetwork{}, nil)
+	assert.EqualError(t, err, "Virtual network has no properties")
+
+	var virtualNetwork network.VirtualNetwork
+	loadTestNetworkResource(t, filepath.Join("addresses", "vnet-hub.json"), &virtualNetwork)
+	var peerings []network.VirtualNetworkPeering
+	loadTestNetworkResource(t, filepath.Join("addresses", "peerings.json"), &peerings)
+	(*peerings[0].RemoteAddressSpace.AddressPrefixes)[0] = "10.1.0.0/33"
+	_, err = EvaluateVirtualNetworkAddressesE(&virtualNetwork, peerings)
+	assert.EqualError(t, err, "Peering hub-to-spoke1: invalid address prefix 10.1.0.0/33")
+
+	(*virtualNetwork.Subnets)[0].AddressPrefix = nil
+	(*virtualNetwork.Subnets)[0].AddressPrefixes = &[]string{"snet"}
+	_, err = EvaluateVirtualNetworkAddressesE(&virtualNetwork, nil)
+	assert.EqualError(t, err, "Subnet snet-small: invalid address prefix snet")
+}
+
+func TestPrefixWithin(t *testing.T) {
+	space, err := parsePrefixes([]string{"10.0.0.0/16", "fd00:db8::/48"})
+	require.NoError(t, err)
+	cases := []struct {
+		prefix string
+		within bool
+	}{
+		{"10.0.0.0/16", true},
+		{"10.0.255.0/24", true},
+		{"10.0.0.0/15", false},
+		{"10.1.0.0/24", false},
+		{"fd00:db8:0:ffff::/64", true},
+		{"fd00:db8::/47", false},
+	}
+	for _, c := range cases {
+		_, prefix, err := net.ParseCIDR(c.prefix)
+		require.NoError(t, err)
+		assert.Equal(t, c.within, prefixWithin(prefix, space), c.prefix)
+	}
+}
+
+func TestPrefixSize(t *testing.T) {
+	cases := []struct {
+		prefix string
+		size   int64
+	}{
+		{"10.0.0.4/32", 1},
+		{"10.0.0.0/24", 256},
+		{"0.0.0.0/0", 1 << 32},
+		{"fd00::/66", 1 << 62},
+		// 2^63 and more are capped
+		{"fd00::/65", math.MaxInt64},
+		{"fd00::/64", math.MaxInt64},
+	}
+	for _, c := range cases {
+		_, prefix, err := net.ParseCIDR(c.prefix)
+		require.NoError(t, err)
+		assert.Equal(t, c.size, prefixSize(prefix), c.prefix)
+	}
+
+	assert.Equal(t, int64(3), addCapped(1, 2))
+	assert.Equal(t, int64(math.MaxInt64), addCapped(math.MaxInt64-1, 1))
+	assert.Equal(t, int64(math.MaxInt64), addCapped(256, math.MaxInt64))
+}
@@ -0,0 +1,243 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/go-autorest/autorest/azure/cli"
+)
+
+const (
+	// AuthMethodEnvName forces a single credential source instead of the whole chain
+	AuthMethodEnvName = "ARM_AUTH_METHOD"
+	// ClientIDEnvName service principal (or user assigned identity) client id
+	ClientIDEnvName = "ARM_CLIENT_ID"
+	// ClientSecretEnvName service principal secret
+	ClientSecretEnvName = "ARM_CLIENT_SECRET"
+	// TenantIDEnvName azure ad tenant id
+	TenantIDEnvName = "ARM_TENANT_ID"
+	// ClientCertificatePathEnvName path to a PFX certificate for the service principal
+	ClientCertificatePathEnvName = "ARM_CLIENT_CERTIFICATE_PATH"
+	// ClientCertificatePasswordEnvName password of the PFX certificate
+	ClientCertificatePasswordEnvName = "ARM_CLIENT_CERTIFICATE_PASSWORD"
+	// UseMSIEnvName enables managed identity authentication when set to true
+	UseMSIEnvName = "ARM_USE_MSI"
+	// FederatedTokenFileEnvName path to a federated (OIDC) token file
+	FederatedTokenFileEnvName = "ARM_OIDC_TOKEN_FILE_PATH"
+	// WorkloadIdentityTokenFileEnvName path to the token file projected by AKS workload identity
+	WorkloadIdentityTokenFileEnvName = "AZURE_FEDERATED_TOKEN_FILE"
+)
+
+// CredentialSource identifies the credential used to create an Authorizer
+type CredentialSource string
+
+const (
+	// ClientSecretCredential service principal with a client secret
+	ClientSecretCredential CredentialSource = "secret"
+	// ClientCertificateCredential service principal with a client certificate
+	ClientCertificateCredential CredentialSource = "certificate"
+	// ManagedIdentityCredential system or user assigned managed identity
+	ManagedIdentityCredential CredentialSource = "msi"
+	// FederatedTokenCredential service principal with a federated token file
+	FederatedTokenCredential CredentialSource = "federated"
+	// AzureCLICredential credentials previously set with az login
+	AzureCLICredential CredentialSource = "cli"
+)
+
+// credentialChain is the order in which credential sources are tried
+var credentialChain = []CredentialSource{
+	ClientSecretCredential,
+	ClientCertificateCredential,
+	ManagedIdentityCredential,
+	FederatedTokenCredential,
+	AzureCLICredential,
+}
+
+// NewAuthorizerFromChainE returns an Authorizer for resource using the first configured credential source.
+// ARM_AUTH_METHOD can be used to force a single source (secret, certificate, msi, federated or cli).
+func NewAuthorizerFromChainE(resource string) (autorest.Authorizer, CredentialSource, error) {
+	env, err := GetCloudEnvironmentE()
+	if err != nil {
+		return nil, "", err
+	}
+	return NewAuthorizerForEnvironmentE(env, resource)
+}
+
+// NewAuthorizerForEnvironmentE is the same as NewAuthorizerFromChainE but authenticates against the
+// Azure AD endpoint of env instead of the cloud set in AZURE_ENVIRONMENT
+func NewAuthorizerForEnvironmentE(env azure.Environment, resource string) (autorest.Authorizer, CredentialSource, error) {
+	sources := credentialChain
+	if method := os.Getenv(AuthMethodEnvName); method != "" {
+		source := CredentialSource(strings.ToLower(method))
+		if !isKnownCredentialSource(source) {
+			return nil, "", fmt.Errorf("Unknown authentication method %s set in %s", method, AuthMethodEnvName)
+		}
+		sources = []CredentialSource{source}
+	}
+
+	errs := make([]string, 0, len(sources))
+	for _, source := range sources {
+		authorizer, err := newAuthorizerFromSource(source, env, resource)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", source, err))
+			continue
+		}
+		if authorizer == nil {
+			// source is not configured
+			continue
+		}
+		log.Printf("Using %s credentials for %s\n", source, resource)
+		return authorizer, source, nil
+	}
+	return nil, "", fmt.Errorf("Could not create an authorizer for %s: %s", resource, strings.Join(errs, "; "))
+}
+
+func isKnownCredentialSource(source CredentialSource) bool {
+	for _, s := range credentialChain {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// newAuthorizerFromSource returns a nil Authorizer and no error when the source is not configured
+func newAuthorizerFromSource(source CredentialSource, env azure.Environment, resource string) (autorest.Authorizer, error) {
+	clientID := os.Getenv(ClientIDEnvName)
+	tenantID := os.Getenv(TenantIDEnvName)
+	aadEndpoint := env.ActiveDirectoryEndpoint
+
+	switch source {
+	case ClientSecretCredential:
+		secret := os.Getenv(ClientSecretEnvName)
+		if secret == "" || clientID == "" || tenantID == "" {
+			return nil, nil
+		}
+		config := auth.NewClientCredentialsConfig(clientID, secret, tenantID)
+		config.Resource = resource
+		config.AADEndpoint = aadEndpoint
+		return config.Authorizer()
+
+	case ClientCertificateCredential:
+		certificatePath := os.Getenv(ClientCertificatePathEnvName)
+		if certificatePath == "" || clientID == "" || tenantID == "" {
+			return nil, nil
+		}
+		config := auth.NewClientCertificateConfig(certificatePath, os.Getenv(ClientCertificatePasswordEnvName), clientID, tenantID)
+		config.Resource = resource
+		config.AADEndpoint = aadEndpoint
+		return config.Authorizer()
+
+	case ManagedIdentityCredential:
+		useMSI, _ := strconv.ParseBool(os.Getenv(UseMSIEnvName))
+		if !useMSI && os.Getenv(AuthMethodEnvName) == "" {
+			return nil, nil
+		}
+		config := auth.NewMSIConfig()
+		config.Resource = resource
+		config.ClientID = clientID
+		return config.Authorizer()
+
+	case FederatedTokenCredential:
+		tokenFile := os.Getenv(FederatedTokenFileEnvName)
+		if tokenFile == "" {
+			tokenFile = os.Getenv(WorkloadIdentityTokenFileEnvName)
+		}
+		if tokenFile == "" || clientID == "" || tenantID == "" {
+			return nil, nil
+		}
+		oauthConfig, err := adal.NewOAuthConfig(aadEndpoint, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		token, err := adal.NewServicePrincipalTokenWithSecret(*oauthConfig, clientID, resource, &federatedTokenSecret{tokenFile: tokenFile})
+		if err != nil {
+			return nil, err
+		}
+		return autorest.NewBearerAuthorizer(token), nil
+
+	case AzureCLICredential:
+		provider := &cliTokenProvider{resource: resource}
+		if err := provider.RefreshWithContext(context.Background()); err != nil {
+			return nil, err
+		}
+		return autorest.NewBearerAuthorizer(provider), nil
+	}
+	return nil, fmt.Errorf("Unknown credential source %s", source)
+}
+
+// federatedTokenSecret exchanges the content of a federated token file for an access token.
+// The file is read on every refresh since projected tokens are rotated.
+type federatedTokenSecret struct {
+	tokenFile string
+}
+
+// SetAuthenticationValues implements adal.ServicePrincipalSecret
+func (s *federatedTokenSecret) SetAuthenticationValues(spt *adal.ServicePrincipalToken, v *url.Values) error {
+	assertion, err := ioutil.ReadFile(s.tokenFile)
+	if err != nil {
+		return fmt.Errorf("Can not read federated token file %s: %s", s.tokenFile, err)
+	}
+	v.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	v.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	return nil
+}
+
+// cliTokenProvider gets tokens from az cli and asks for a new one before the current one expires
+type cliTokenProvider struct {
+	resource string
+	mu       sync.Mutex
+	token    adal.Token
+}
+
+// OAuthToken implements adal.OAuthTokenProvider
+func (p *cliTokenProvider) OAuthToken() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.token.AccessToken
+}
+
+// EnsureFreshWithContext implements adal.RefresherWithContext
+func (p *cliTokenProvider) EnsureFreshWithContext(ctx context.Context) error {
+	p.mu.Lock()
+	fresh := !p.token.WillExpireIn(5 * time.Minute)
+	p.mu.Unlock()
+	if fresh {
+		return nil
+	}
+	return p.RefreshWithContext(ctx)
+}
+
+// RefreshWithContext implements adal.RefresherWithContext
+func (p *cliTokenProvider) RefreshWithContext(ctx context.Context) error {
+	return p.RefreshExchangeWithContext(ctx, p.resource)
+}
+
+// RefreshExchangeWithContext implements adal.RefresherWithContext
+func (p *cliTokenProvider) RefreshExchangeWithContext(ctx context.Context, resource string) error {
+	token, err := cli.GetTokenFromCLI(resource)
+	if err != nil {
+		return err
+	}
+	adalToken, err := token.ToADALToken()
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resource = resource
+	p.token = adalToken
+	return nil
+}
@@ -0,0 +1,84 @@
+package helper
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearAuthEnv unsets every variable read by the credential chain for the test
+func clearAuthEnv(t *testing.T) {
+	for _, name := range []string{
+		AuthMethodEnvName, ClientIDEnvName, ClientSecretEnvName, TenantIDEnvName,
+		ClientCertificatePathEnvName, ClientCertificatePasswordEnvName, UseMSIEnvName,
+		FederatedTokenFileEnvName, WorkloadIdentityTokenFileEnvName, CloudEnvironmentEnvName,
+	} {
+		t.Setenv(name, "")
+	}
+	// az is not found, so the cli source always fails
+	t.Setenv("PATH", "")
+}
+
+func TestNewAuthorizerFromChainE(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("assertion\n"), 0600))
+	missingCertificate := filepath.Join(t.TempDir(), "missing.pfx")
+
+	sp := map[string]string{ClientIDEnvName: "client", TenantIDEnvName: "tenant"}
+	cases := []struct {
+		name   string
+		env    map[string]string
+		source CredentialSource
+		err    string
+	}{
+		{"secret", map[string]string{ClientSecretEnvName: "secret"}, ClientSecretCredential, ""},
+		{"secret before msi and federated", map[string]string{ClientSecretEnvName: "secret", UseMSIEnvName: "true", FederatedTokenFileEnvName: tokenFile}, ClientSecretCredential, ""},
+		{"secret without tenant", map[string]string{ClientSecretEnvName: "secret", TenantIDEnvName: ""}, "", "cli: "},
+		{"federated", map[string]string{FederatedTokenFileEnvName: tokenFile}, FederatedTokenCredential, ""},
+		{"workload identity", map[string]string{WorkloadIdentityTokenFileEnvName: tokenFile}, FederatedTokenCredential, ""},
+		{"failing certificate falls through", map[string]string{ClientCertificatePathEnvName: missingCertificate, FederatedTokenFileEnvName: tokenFile}, FederatedTokenCredential, ""},
+		{"nothing configured", nil, "", "Could not create an authorizer for https://management.azure.com/: cli: "},
+		{"forced method", map[string]string{AuthMethodEnvName: "Federated", ClientSecretEnvName: "secret", FederatedTokenFileEnvName: tokenFile}, FederatedTokenCredential, ""},
+		{"forced method not configured", map[string]string{AuthMethodEnvName: "certificate", ClientCertificatePathEnvName: missingCertificate, ClientSecretEnvName: "secret"}, "", "certificate: "},
+		{"unknown method", map[string]string{AuthMethodEnvName: "password", ClientSecretEnvName: "secret"}, "", "Unknown authentication method password set in ARM_AUTH_METHOD"},
+		{"invalid cloud", map[string]string{CloudEnvironmentEnvName: "AzureMarsCloud", ClientSecretEnvName: "secret"}, "", "Invalid AZURE_ENVIRONMENT"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clearAuthEnv(t)
+			for name, value := range sp {
+				t.Setenv(name, value)
+			}
+			for name, value := range c.env {
+				t.Setenv(name, value)
+			}
+			authorizer, source, err := NewAuthorizerFromChainE(azure.PublicCloud.ResourceManagerEndpoint)
+			if c.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), c.err)
+				assert.Nil(t, authorizer)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, authorizer)
+			assert.Equal(t, c.source, source)
+		})
+	}
+}
+
+func TestFederatedTokenSecretReadsTheFileOnEveryRefresh(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	secret := &federatedTokenSecret{tokenFile: tokenFile}
+
+	for _, assertion := range []string{"first", "rotated"} {
+		require.NoError(t, ioutil.WriteFile(tokenFile, []byte(assertion+"\n"), 0600))
+		values := url.Values{}
+		require.NoError(t, secret.SetAuthenticationValues(nil, &values))
+		assert.Equal(t, assertion, values.Get("client_assertion"))
+	}
+}
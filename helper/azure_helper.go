@@ -7,6 +7,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/profiles/latest/containerservice/mgmt/containerservice"
 	"github.com/Azure/azure-sdk-for-go/profiles/latest/msi/mgmt/msi"
@@ -17,7 +18,6 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mgmt/2020-04-01/documentdb"
 	"github.com/Azure/azure-sdk-for-go/services/eventhub/mgmt/2017-04-01/eventhub"
 	"github.com/Azure/azure-sdk-for-go/services/frontdoor/mgmt/2019-05-01/frontdoor"
-	kvauth "github.com/Azure/azure-sdk-for-go/services/keyvault/auth"
 	kv "github.com/Azure/azure-sdk-for-go/services/keyvault/mgmt/2018-02-14/keyvault"
 	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.0/keyvault"
 	mysql "github.com/Azure/azure-sdk-for-go/services/mysql/mgmt/2020-01-01/mysql"
@@ -34,7 +34,6 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2019-06-01/storage"
 	"github.com/Azure/azure-sdk-for-go/services/web/mgmt/2019-08-01/web"
 	"github.com/Azure/go-autorest/autorest"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
 )
 
 const (
@@ -42,14 +41,26 @@ const (
 	SubscriptionIDEnvName = "ARM_SUBSCRIPTION_ID"
 )
 
+// getTargetSubscription returns the first subscription override that is not empty,
+// or the value of ARM_SUBSCRIPTION_ID when there is none
+func getTargetSubscription(subscriptionID ...string) string {
+	for _, id := range subscriptionID {
+		if id != "" {
+			return id
+		}
+	}
+	return os.Getenv(SubscriptionIDEnvName)
+}
+
 /********************************
 		Authorization
 *********************************/
 
-// NewAuthorizer will return Authorizer
-// using credentials previously set with az login
+// NewAuthorizer will return Authorizer for Azure Resource Manager
+// using the first credential source configured (see NewAuthorizerFromChainE).
+// The Authorizer is created once and shared by every client.
 func NewAuthorizer() (*autorest.Authorizer, error) {
-	authorizer, err := auth.NewAuthorizerFromCLI()
+	authorizer, err := defaultClientFactory.ResourceManagerAuthorizerE()
 	return &authorizer, err
 }
 
@@ -57,14 +68,27 @@ func NewAuthorizer() (*autorest.Authorizer, error) {
 		Resource Groups
 *********************************/
 
+// GetResourceGroup is the same as GetResourceGroupE but fails the test on error
+func GetResourceGroup(t *testing.T, resourceGroupName string, subscriptionID ...string) *resources.Group {
+	t.Helper()
+	resourceGroup, err := GetResourceGroupE(resourceGroupName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Resources/resourceGroups", resourceGroupName, "")
+	return resourceGroup
+}
+
 // GetResourceGroupE will return Group object and an error object
-func GetResourceGroupE(resourceGroupName string) (*resources.Group, error) {
+func GetResourceGroupE(resourceGroupName string, subscriptionID ...string) (*resources.Group, error) {
+	return GetResourceGroupWithContextE(context.Background(), resourceGroupName, subscriptionID...)
+}
+
+// GetResourceGroupWithContextE will return Group object and an error object
+func GetResourceGroupWithContextE(ctx context.Context, resourceGroupName string, subscriptionID ...string) (*resources.Group, error) {
 
-	client, err := GetGroupsClientE(os.Getenv(SubscriptionIDEnvName))
+	client, err := GetGroupsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	resourceGroup, err := client.Get(context.Background(), resourceGroupName)
+	resourceGroup, err := client.Get(ctx, resourceGroupName)
 	if err != nil {
 		return nil, err
 	}
@@ -73,27 +97,41 @@ func GetResourceGroupE(resourceGroupName string) (*resources.Group, error) {
 
 // GetGroupsClientE creates a GroupsClient
 func GetGroupsClientE(subscriptionID string) (*resources.GroupsClient, error) {
-	client := resources.NewGroupsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := resources.NewGroupsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*resources.GroupsClient), nil
 }
 
 /********************************
 		Virtual Machines
 *********************************/
 
+// GetVirtualMachine is the same as GetVirtualMachineE but fails the test on error
+func GetVirtualMachine(t *testing.T, resourceGroupName string, virtualMachineName string, subscriptionID ...string) *compute.VirtualMachine {
+	t.Helper()
+	virtualMachine, err := GetVirtualMachineE(resourceGroupName, virtualMachineName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Compute/virtualMachines", virtualMachineName, resourceGroupName)
+	return virtualMachine
+}
+
 // GetVirtualMachineE will return Group object and an error object
-func GetVirtualMachineE(resourceGroupName string, virtualMachineName string) (*compute.VirtualMachine, error) {
+func GetVirtualMachineE(resourceGroupName string, virtualMachineName string, subscriptionID ...string) (*compute.VirtualMachine, error) {
+	return GetVirtualMachineWithContextE(context.Background(), resourceGroupName, virtualMachineName, subscriptionID...)
+}
 
-	client, err := GetVirtualMachinesClientE(os.Getenv(SubscriptionIDEnvName))
+// GetVirtualMachineWithContextE will return Group object and an error object
+func GetVirtualMachineWithContextE(ctx context.Context, resourceGroupName string, virtualMachineName string, subscriptionID ...string) (*compute.VirtualMachine, error) {
+
+	client, err := GetVirtualMachinesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	virtualMachine, err := client.Get(context.Background(), resourceGroupName, virtualMachineName, "")
+	virtualMachine, err := client.Get(ctx, resourceGroupName, virtualMachineName, "")
 	if err != nil {
 		return nil, err
 	}
@@ -102,27 +140,41 @@ func GetVirtualMachineE(resourceGroupName string, virtualMachineName string) (*c
 
 // GetVirtualMachinesClientE creates a VirtualMachinesClient
 func GetVirtualMachinesClientE(subscriptionID string) (*compute.VirtualMachinesClient, error) {
-	client := compute.NewVirtualMachinesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := compute.NewVirtualMachinesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*compute.VirtualMachinesClient), nil
 }
 
 /********************************
 		Disks
 *********************************/
 
+// GetDisk is the same as GetDiskE but fails the test on error
+func GetDisk(t *testing.T, resourceGroupName string, diskName string, subscriptionID ...string) *compute.Disk {
+	t.Helper()
+	disk, err := GetDiskE(resourceGroupName, diskName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Compute/disks", diskName, resourceGroupName)
+	return disk
+}
+
 // GetDiskE will return Disk object and an error object
-func GetDiskE(resourceGroupName string, diskName string) (*compute.Disk, error) {
+func GetDiskE(resourceGroupName string, diskName string, subscriptionID ...string) (*compute.Disk, error) {
+	return GetDiskWithContextE(context.Background(), resourceGroupName, diskName, subscriptionID...)
+}
+
+// GetDiskWithContextE will return Disk object and an error object
+func GetDiskWithContextE(ctx context.Context, resourceGroupName string, diskName string, subscriptionID ...string) (*compute.Disk, error) {
 
-	client, err := GetDisksClientE(os.Getenv(SubscriptionIDEnvName))
+	client, err := GetDisksClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	disk, err := client.Get(context.Background(), resourceGroupName, diskName)
+	disk, err := client.Get(ctx, resourceGroupName, diskName)
 	if err != nil {
 		return nil, err
 	}
@@ -131,27 +183,41 @@ func GetDiskE(resourceGroupName string, diskName string) (*compute.Disk, error)
 
 // GetDisksClientE creates a DisksClient
 func GetDisksClientE(subscriptionID string) (*compute.DisksClient, error) {
-	client := compute.NewDisksClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := compute.NewDisksClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*compute.DisksClient), nil
 }
 
 /********************************
 		Network Interfaces
 *********************************/
 
+// GetInterface is the same as GetInterfaceE but fails the test on error
+func GetInterface(t *testing.T, resourceGroupName, networkInterfaceName string, subscriptionID ...string) *network.Interface {
+	t.Helper()
+	networkInterface, err := GetInterfaceE(resourceGroupName, networkInterfaceName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/networkInterfaces", networkInterfaceName, resourceGroupName)
+	return networkInterface
+}
+
 // GetInterfaceE gets NetworkInterface object
-func GetInterfaceE(resourceGroupName, networkInterfaceName string) (*network.Interface, error) {
+func GetInterfaceE(resourceGroupName, networkInterfaceName string, subscriptionID ...string) (*network.Interface, error) {
+	return GetInterfaceWithContextE(context.Background(), resourceGroupName, networkInterfaceName, subscriptionID...)
+}
+
+// GetInterfaceWithContextE gets NetworkInterface object
+func GetInterfaceWithContextE(ctx context.Context, resourceGroupName, networkInterfaceName string, subscriptionID ...string) (*network.Interface, error) {
 
-	client, err := GetInterfacesClient(os.Getenv(SubscriptionIDEnvName))
+	client, err := GetInterfacesClient(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	nic, err := client.Get(context.Background(), resourceGroupName, networkInterfaceName, "")
+	nic, err := client.Get(ctx, resourceGroupName, networkInterfaceName, "")
 	if err != nil {
 		return nil, err
 	}
@@ -160,43 +226,68 @@ func GetInterfaceE(resourceGroupName, networkInterfaceName string) (*network.Int
 
 // GetInterfacesClient creates a virtual network client
 func GetInterfacesClient(subscriptionID string) (*network.InterfacesClient, error) {
-	client := network.NewInterfacesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
-
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewInterfacesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*network.InterfacesClient), nil
 }
 
 /********************************
 		Storage Accounts
 *********************************/
 
+// GetStorageAccount is the same as GetStorageAccountE but fails the test on error
+func GetStorageAccount(t *testing.T, resourceGroupName, storageAccountName string, subscriptionID ...string) *storage.Account {
+	t.Helper()
+	storageAccount, err := GetStorageAccountE(resourceGroupName, storageAccountName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Storage/storageAccounts", storageAccountName, resourceGroupName)
+	return storageAccount
+}
+
 // GetStorageAccountE gets storage.Account object
-func GetStorageAccountE(resourceGroupName, storageAccountName string) (*storage.Account, error) {
+func GetStorageAccountE(resourceGroupName, storageAccountName string, subscriptionID ...string) (*storage.Account, error) {
+	return GetStorageAccountWithContextE(context.Background(), resourceGroupName, storageAccountName, subscriptionID...)
+}
 
-	client, err := GetStorageAccountsClientE(os.Getenv(SubscriptionIDEnvName))
+// GetStorageAccountWithContextE gets storage.Account object
+func GetStorageAccountWithContextE(ctx context.Context, resourceGroupName, storageAccountName string, subscriptionID ...string) (*storage.Account, error) {
+
+	client, err := GetStorageAccountsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	account, err := client.GetProperties(context.Background(), resourceGroupName, storageAccountName, "")
+	account, err := client.GetProperties(ctx, resourceGroupName, storageAccountName, "")
 	if err != nil {
 		return nil, err
 	}
 	return &account, nil
 }
 
+// GetStorageAccountKeys is the same as GetStorageAccountKeysE but fails the test on error
+func GetStorageAccountKeys(t *testing.T, resourceGroupName, storageAccountName string, subscriptionID ...string) *[]storage.AccountKey {
+	t.Helper()
+	storageAccountKeys, err := GetStorageAccountKeysE(resourceGroupName, storageAccountName, subscriptionID...)
+	failOnAzureError(t, err, "list keys of", "Microsoft.Storage/storageAccounts", storageAccountName, resourceGroupName)
+	return storageAccountKeys
+}
+
 // GetStorageAccountKeysE gets NetworkInterface object
-func GetStorageAccountKeysE(resourceGroupName, storageAccountName string) (*[]storage.AccountKey, error) {
+func GetStorageAccountKeysE(resourceGroupName, storageAccountName string, subscriptionID ...string) (*[]storage.AccountKey, error) {
+	return GetStorageAccountKeysWithContextE(context.Background(), resourceGroupName, storageAccountName, subscriptionID...)
+}
+
+// GetStorageAccountKeysWithContextE gets NetworkInterface object
+func GetStorageAccountKeysWithContextE(ctx context.Context, resourceGroupName, storageAccountName string, subscriptionID ...string) (*[]storage.AccountKey, error) {
 
-	client, err := GetStorageAccountsClientE(os.Getenv(SubscriptionIDEnvName))
+	client, err := GetStorageAccountsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	keys, err := client.ListKeys(context.Background(), resourceGroupName, storageAccountName, "")
+	keys, err := client.ListKeys(ctx, resourceGroupName, storageAccountName, "")
 	if err != nil {
 		return nil, err
 	}
@@ -205,29 +296,41 @@ func GetStorageAccountKeysE(resourceGroupName, storageAccountName string) (*[]st
 
 // GetStorageAccountsClientE creates a virtual network client
 func GetStorageAccountsClientE(subscriptionID string) (*storage.AccountsClient, error) {
-	client := storage.NewAccountsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
-
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := storage.NewAccountsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*storage.AccountsClient), nil
 }
 
 /********************************
 		Blob Containers
 *********************************/
 
+// ListBlobContainersForAccount is the same as ListBlobContainersForAccountE but fails the test on error
+func ListBlobContainersForAccount(t *testing.T, resourceGroupName string, storageAccountName string, subscriptionID ...string) *[]storage.ListContainerItem {
+	t.Helper()
+	blobContainersForAccount, err := ListBlobContainersForAccountE(resourceGroupName, storageAccountName, subscriptionID...)
+	failOnAzureError(t, err, "list blob containers of", "Microsoft.Storage/storageAccounts", storageAccountName, resourceGroupName)
+	return blobContainersForAccount
+}
+
 // ListBlobContainersForAccountE will return Group object and an error object
-func ListBlobContainersForAccountE(resourceGroupName string, storageAccountName string) (*[]storage.ListContainerItem, error) {
+func ListBlobContainersForAccountE(resourceGroupName string, storageAccountName string, subscriptionID ...string) (*[]storage.ListContainerItem, error) {
+	return ListBlobContainersForAccountWithContextE(context.Background(), resourceGroupName, storageAccountName, subscriptionID...)
+}
+
+// ListBlobContainersForAccountWithContextE will return Group object and an error object
+func ListBlobContainersForAccountWithContextE(ctx context.Context, resourceGroupName string, storageAccountName string, subscriptionID ...string) (*[]storage.ListContainerItem, error) {
 
-	client, err := GetBlobContainersClientE(os.Getenv(SubscriptionIDEnvName))
+	client, err := GetBlobContainersClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.List(context.Background(), resourceGroupName, storageAccountName, "", "", "")
+	result, err := client.List(ctx, resourceGroupName, storageAccountName, "", "", "")
 	if err != nil {
 		return nil, err
 	}
@@ -238,27 +341,41 @@ func ListBlobContainersForAccountE(resourceGroupName string, storageAccountName
 
 // GetBlobContainersClientE creates a GroupsClient
 func GetBlobContainersClientE(subscriptionID string) (*storage.BlobContainersClient, error) {
-	client := storage.NewBlobContainersClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := storage.NewBlobContainersClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*storage.BlobContainersClient), nil
 }
 
 /********************************
 		File Shares
 *********************************/
 
+// ListFileSharesForAccount is the same as ListFileSharesForAccountE but fails the test on error
+func ListFileSharesForAccount(t *testing.T, resourceGroupName string, storageAccountName string, subscriptionID ...string) *[]storage.FileShareItem {
+	t.Helper()
+	fileSharesForAccount, err := ListFileSharesForAccountE(resourceGroupName, storageAccountName, subscriptionID...)
+	failOnAzureError(t, err, "list file shares of", "Microsoft.Storage/storageAccounts", storageAccountName, resourceGroupName)
+	return fileSharesForAccount
+}
+
 // ListFileSharesForAccountE will return Group object and an error object
-func ListFileSharesForAccountE(resourceGroupName string, storageAccountName string) (*[]storage.FileShareItem, error) {
+func ListFileSharesForAccountE(resourceGroupName string, storageAccountName string, subscriptionID ...string) (*[]storage.FileShareItem, error) {
+	return ListFileSharesForAccountWithContextE(context.Background(), resourceGroupName, storageAccountName, subscriptionID...)
+}
+
+// ListFileSharesForAccountWithContextE will return Group object and an error object
+func ListFileSharesForAccountWithContextE(ctx context.Context, resourceGroupName string, storageAccountName string, subscriptionID ...string) (*[]storage.FileShareItem, error) {
 
-	client, err := GetFileSharesClientE(os.Getenv(SubscriptionIDEnvName))
+	client, err := GetFileSharesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.List(context.Background(), resourceGroupName, storageAccountName, "", "", "")
+	result, err := client.List(ctx, resourceGroupName, storageAccountName, "", "", "")
 	if err != nil {
 		return nil, err
 	}
@@ -269,27 +386,41 @@ func ListFileSharesForAccountE(resourceGroupName string, storageAccountName stri
 
 // GetFileSharesClientE creates a GroupsClient
 func GetFileSharesClientE(subscriptionID string) (*storage.FileSharesClient, error) {
-	client := storage.NewFileSharesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := storage.NewFileSharesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*storage.FileSharesClient), nil
 }
 
 /********************************
 		File Services
 *********************************/
 
+// ListFileServicesForAccount is the same as ListFileServicesForAccountE but fails the test on error
+func ListFileServicesForAccount(t *testing.T, resourceGroupName string, storageAccountName string, subscriptionID ...string) *storage.FileServiceItems {
+	t.Helper()
+	fileServicesForAccount, err := ListFileServicesForAccountE(resourceGroupName, storageAccountName, subscriptionID...)
+	failOnAzureError(t, err, "list file services of", "Microsoft.Storage/storageAccounts", storageAccountName, resourceGroupName)
+	return fileServicesForAccount
+}
+
 // ListFileServicesForAccountE will return Group object and an error object
-func ListFileServicesForAccountE(resourceGroupName string, storageAccountName string) (*storage.FileServiceItems, error) {
+func ListFileServicesForAccountE(resourceGroupName string, storageAccountName string, subscriptionID ...string) (*storage.FileServiceItems, error) {
+	return ListFileServicesForAccountWithContextE(context.Background(), resourceGroupName, storageAccountName, subscriptionID...)
+}
+
+// ListFileServicesForAccountWithContextE will return Group object and an error object
+func ListFileServicesForAccountWithContextE(ctx context.Context, resourceGroupName string, storageAccountName string, subscriptionID ...string) (*storage.FileServiceItems, error) {
 
-	client, err := GetFileServicesClientE(os.Getenv(SubscriptionIDEnvName))
+	client, err := GetFileServicesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	fileService, err := client.List(context.Background(), resourceGroupName, storageAccountName)
+	fileService, err := client.List(ctx, resourceGroupName, storageAccountName)
 	if err != nil {
 		return nil, err
 	}
@@ -298,27 +429,41 @@ func ListFileServicesForAccountE(resourceGroupName string, storageAccountName st
 
 // GetFileServicesClientE creates a GroupsClient
 func GetFileServicesClientE(subscriptionID string) (*storage.FileServicesClient, error) {
-	client := storage.NewFileServicesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := storage.NewFileServicesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*storage.FileServicesClient), nil
 }
 
 /********************************
 		Blob Services
 *********************************/
 
+// ListBlobServicesForAccount is the same as ListBlobServicesForAccountE but fails the test on error
+func ListBlobServicesForAccount(t *testing.T, resourceGroupName string, storageAccountName string, subscriptionID ...string) *storage.BlobServiceItems {
+	t.Helper()
+	blobServicesForAccount, err := ListBlobServicesForAccountE(resourceGroupName, storageAccountName, subscriptionID...)
+	failOnAzureError(t, err, "list blob services of", "Microsoft.Storage/storageAccounts", storageAccountName, resourceGroupName)
+	return blobServicesForAccount
+}
+
 // ListBlobServicesForAccountE will return Group object and an error object
-func ListBlobServicesForAccountE(resourceGroupName string, storageAccountName string) (*storage.BlobServiceItems, error) {
+func ListBlobServicesForAccountE(resourceGroupName string, storageAccountName string, subscriptionID ...string) (*storage.BlobServiceItems, error) {
+	return ListBlobServicesForAccountWithContextE(context.Background(), resourceGroupName, storageAccountName, subscriptionID...)
+}
 
-	client, err := GetBlobServicesClientE(os.Getenv(SubscriptionIDEnvName))
+// ListBlobServicesForAccountWithContextE will return Group object and an error object
+func ListBlobServicesForAccountWithContextE(ctx context.Context, resourceGroupName string, storageAccountName string, subscriptionID ...string) (*storage.BlobServiceItems, error) {
+
+	client, err := GetBlobServicesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	fileService, err := client.List(context.Background(), resourceGroupName, storageAccountName)
+	fileService, err := client.List(ctx, resourceGroupName, storageAccountName)
 	if err != nil {
 		return nil, err
 	}
@@ -327,27 +472,41 @@ func ListBlobServicesForAccountE(resourceGroupName string, storageAccountName st
 
 // GetBlobServicesClientE creates a BlobServicesClient
 func GetBlobServicesClientE(subscriptionID string) (*storage.BlobServicesClient, error) {
-	client := storage.NewBlobServicesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := storage.NewBlobServicesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*storage.BlobServicesClient), nil
 }
 
 /********************************
 		Virtual Network
 *********************************/
 
+// GetVirtualNetwork is the same as GetVirtualNetworkE but fails the test on error
+func GetVirtualNetwork(t *testing.T, resourceGroupName, virtualNetworkName string, subscriptionID ...string) *network.VirtualNetwork {
+	t.Helper()
+	virtualNetwork, err := GetVirtualNetworkE(resourceGroupName, virtualNetworkName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/virtualNetworks", virtualNetworkName, resourceGroupName)
+	return virtualNetwork
+}
+
 // GetVirtualNetworkE gets virtual network object
-func GetVirtualNetworkE(resourceGroupName, virtualNetworkName string) (*network.VirtualNetwork, error) {
+func GetVirtualNetworkE(resourceGroupName, virtualNetworkName string, subscriptionID ...string) (*network.VirtualNetwork, error) {
+	return GetVirtualNetworkWithContextE(context.Background(), resourceGroupName, virtualNetworkName, subscriptionID...)
+}
+
+// GetVirtualNetworkWithContextE gets virtual network object
+func GetVirtualNetworkWithContextE(ctx context.Context, resourceGroupName, virtualNetworkName string, subscriptionID ...string) (*network.VirtualNetwork, error) {
 
-	client, err := GetVirtualNetworksClient(os.Getenv(SubscriptionIDEnvName))
+	client, err := GetVirtualNetworksClient(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	virtualNetwork, err := client.Get(context.Background(), resourceGroupName, virtualNetworkName, "")
+	virtualNetwork, err := client.Get(ctx, resourceGroupName, virtualNetworkName, "")
 	if err != nil {
 		return nil, err
 	}
@@ -356,43 +515,68 @@ func GetVirtualNetworkE(resourceGroupName, virtualNetworkName string) (*network.
 
 // GetVirtualNetworksClient creates a virtual network client
 func GetVirtualNetworksClient(subscriptionID string) (*network.VirtualNetworksClient, error) {
-	vnClient := network.NewVirtualNetworksClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
-
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewVirtualNetworksClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	vnClient.Authorizer = *authorizer
-	return &vnClient, nil
+	return client.(*network.VirtualNetworksClient), nil
 }
 
 /********************************
 		Virtual Network Peerings
 *********************************/
 
+// GetVirtualNetworkPeering is the same as GetVirtualNetworkPeeringE but fails the test on error
+func GetVirtualNetworkPeering(t *testing.T, resourceGroupName, virtualNetworkName string, virtualNetworkPeeringName string, subscriptionID ...string) *network.VirtualNetworkPeering {
+	t.Helper()
+	virtualNetworkPeering, err := GetVirtualNetworkPeeringE(resourceGroupName, virtualNetworkName, virtualNetworkPeeringName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/virtualNetworks/virtualNetworkPeerings", virtualNetworkPeeringName, resourceGroupName)
+	return virtualNetworkPeering
+}
+
 // GetVirtualNetworkPeeringE gets a VirtualNetworkPeering object
-func GetVirtualNetworkPeeringE(resourceGroupName, virtualNetworkName string, virtualNetworkPeeringName string) (*network.VirtualNetworkPeering, error) {
+func GetVirtualNetworkPeeringE(resourceGroupName, virtualNetworkName string, virtualNetworkPeeringName string, subscriptionID ...string) (*network.VirtualNetworkPeering, error) {
+	return GetVirtualNetworkPeeringWithContextE(context.Background(), resourceGroupName, virtualNetworkName, virtualNetworkPeeringName, subscriptionID...)
+}
 
-	client, err := GetVirtualNetworkPeeringsClient(os.Getenv(SubscriptionIDEnvName))
+// GetVirtualNetworkPeeringWithContextE gets a VirtualNetworkPeering object
+func GetVirtualNetworkPeeringWithContextE(ctx context.Context, resourceGroupName, virtualNetworkName string, virtualNetworkPeeringName string, subscriptionID ...string) (*network.VirtualNetworkPeering, error) {
+
+	client, err := GetVirtualNetworkPeeringsClient(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, virtualNetworkName, virtualNetworkPeeringName)
+	result, err := client.Get(ctx, resourceGroupName, virtualNetworkName, virtualNetworkPeeringName)
 	if err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
+// ListVirtualNetworkPeering is the same as ListVirtualNetworkPeeringE but fails the test on error
+func ListVirtualNetworkPeering(t *testing.T, resourceGroupName, virtualNetworkName string, subscriptionID ...string) []network.VirtualNetworkPeering {
+	t.Helper()
+	virtualNetworkPeering, err := ListVirtualNetworkPeeringE(resourceGroupName, virtualNetworkName, subscriptionID...)
+	failOnAzureError(t, err, "list peerings of", "Microsoft.Network/virtualNetworks", virtualNetworkName, resourceGroupName)
+	return virtualNetworkPeering
+}
+
 // ListVirtualNetworkPeeringE gets an array of VirtualNetworkPeering objects
-func ListVirtualNetworkPeeringE(resourceGroupName, virtualNetworkName string) ([]network.VirtualNetworkPeering, error) {
+func ListVirtualNetworkPeeringE(resourceGroupName, virtualNetworkName string, subscriptionID ...string) ([]network.VirtualNetworkPeering, error) {
+	return ListVirtualNetworkPeeringWithContextE(context.Background(), resourceGroupName, virtualNetworkName, subscriptionID...)
+}
+
+// ListVirtualNetworkPeeringWithContextE gets an array of VirtualNetworkPeering objects
+func ListVirtualNetworkPeeringWithContextE(ctx context.Context, resourceGroupName, virtualNetworkName string, subscriptionID ...string) ([]network.VirtualNetworkPeering, error) {
 
-	client, err := GetVirtualNetworkPeeringsClient(os.Getenv(SubscriptionIDEnvName))
+	client, err := GetVirtualNetworkPeeringsClient(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.List(context.Background(), resourceGroupName, virtualNetworkName)
+	result, err := client.List(ctx, resourceGroupName, virtualNetworkName)
 	if err != nil {
 		return nil, err
 	}
@@ -401,43 +585,68 @@ func ListVirtualNetworkPeeringE(resourceGroupName, virtualNetworkName string) ([
 
 // GetVirtualNetworkPeeringsClient creates a VirtualNetworkPeeringsClient
 func GetVirtualNetworkPeeringsClient(subscriptionID string) (*network.VirtualNetworkPeeringsClient, error) {
-	vnClient := network.NewVirtualNetworkPeeringsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
-
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewVirtualNetworkPeeringsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	vnClient.Authorizer = *authorizer
-	return &vnClient, nil
+	return client.(*network.VirtualNetworkPeeringsClient), nil
 }
 
 /********************************
 		Subnets
 *********************************/
 
+// GetSubnet is the same as GetSubnetE but fails the test on error
+func GetSubnet(t *testing.T, resourceGroupName, virtualNetworkName string, subnetName string, subscriptionID ...string) *network.Subnet {
+	t.Helper()
+	subnet, err := GetSubnetE(resourceGroupName, virtualNetworkName, subnetName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/virtualNetworks/subnets", subnetName, resourceGroupName)
+	return subnet
+}
+
 // GetSubnetE gets virtual network object
-func GetSubnetE(resourceGroupName, virtualNetworkName string, subnetName string) (*network.Subnet, error) {
+func GetSubnetE(resourceGroupName, virtualNetworkName string, subnetName string, subscriptionID ...string) (*network.Subnet, error) {
+	return GetSubnetWithContextE(context.Background(), resourceGroupName, virtualNetworkName, subnetName, subscriptionID...)
+}
 
-	client, err := GetSubNetClient(os.Getenv(SubscriptionIDEnvName))
+// GetSubnetWithContextE gets virtual network object
+func GetSubnetWithContextE(ctx context.Context, resourceGroupName, virtualNetworkName string, subnetName string, subscriptionID ...string) (*network.Subnet, error) {
+
+	client, err := GetSubNetClient(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	subnet, err := client.Get(context.Background(), resourceGroupName, virtualNetworkName, subnetName, "")
+	subnet, err := client.Get(ctx, resourceGroupName, virtualNetworkName, subnetName, "")
 	if err != nil {
 		return nil, err
 	}
 	return &subnet, nil
 }
 
+// GetSubnetAddressesForVirtualNetwork is the same as GetSubnetAddressesForVirtualNetworkE but fails the test on error
+func GetSubnetAddressesForVirtualNetwork(t *testing.T, resourceGroupName, virtualNetworkName string, subscriptionID ...string) *map[string]string {
+	t.Helper()
+	subnetAddressesForVirtualNetwork, err := GetSubnetAddressesForVirtualNetworkE(resourceGroupName, virtualNetworkName, subscriptionID...)
+	failOnAzureError(t, err, "get subnet addresses of", "Microsoft.Network/virtualNetworks", virtualNetworkName, resourceGroupName)
+	return subnetAddressesForVirtualNetwork
+}
+
 //GetSubnetAddressesForVirtualNetworkE gets all virtual network subclients name, and address prefix
-func GetSubnetAddressesForVirtualNetworkE(resourceGroupName, virtualNetworkName string) (*map[string]string, error) {
-	client, err := GetSubNetClient(os.Getenv(SubscriptionIDEnvName))
+func GetSubnetAddressesForVirtualNetworkE(resourceGroupName, virtualNetworkName string, subscriptionID ...string) (*map[string]string, error) {
+	return GetSubnetAddressesForVirtualNetworkWithContextE(context.Background(), resourceGroupName, virtualNetworkName, subscriptionID...)
+}
+
+// GetSubnetAddressesForVirtualNetworkWithContextE gets all virtual network subclients name, and address prefix
+func GetSubnetAddressesForVirtualNetworkWithContextE(ctx context.Context, resourceGroupName, virtualNetworkName string, subscriptionID ...string) (*map[string]string, error) {
+	client, err := GetSubNetClient(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
 
-	subnets, err := client.List(context.Background(), resourceGroupName, virtualNetworkName)
+	subnets, err := client.List(ctx, resourceGroupName, virtualNetworkName)
 	if err != nil {
 		return nil, err
 	}
@@ -451,14 +660,27 @@ func GetSubnetAddressesForVirtualNetworkE(resourceGroupName, virtualNetworkName
 	return &subNetDetails, nil
 }
 
+// GetSubnetSecurityGroupsForVirtualNetwork is the same as GetSubnetSecurityGroupsForVirtualNetworkE but fails the test on error
+func GetSubnetSecurityGroupsForVirtualNetwork(t *testing.T, resourceGroupName, virtualNetworkName string, subscriptionID ...string) *map[string]string {
+	t.Helper()
+	subnetSecurityGroupsForVirtualNetwork, err := GetSubnetSecurityGroupsForVirtualNetworkE(resourceGroupName, virtualNetworkName, subscriptionID...)
+	failOnAzureError(t, err, "get subnet security groups of", "Microsoft.Network/virtualNetworks", virtualNetworkName, resourceGroupName)
+	return subnetSecurityGroupsForVirtualNetwork
+}
+
 //GetSubnetSecurityGroupsForVirtualNetworkE gets all virtual network subclients name, and security group IDs
-func GetSubnetSecurityGroupsForVirtualNetworkE(resourceGroupName, virtualNetworkName string) (*map[string]string, error) {
-	client, err := GetSubNetClient(os.Getenv(SubscriptionIDEnvName))
+func GetSubnetSecurityGroupsForVirtualNetworkE(resourceGroupName, virtualNetworkName string, subscriptionID ...string) (*map[string]string, error) {
+	return GetSubnetSecurityGroupsForVirtualNetworkWithContextE(context.Background(), resourceGroupName, virtualNetworkName, subscriptionID...)
+}
+
+// GetSubnetSecurityGroupsForVirtualNetworkWithContextE gets all virtual network subclients name, and security group IDs
+func GetSubnetSecurityGroupsForVirtualNetworkWithContextE(ctx context.Context, resourceGroupName, virtualNetworkName string, subscriptionID ...string) (*map[string]string, error) {
+	client, err := GetSubNetClient(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
 
-	subnets, err := client.List(context.Background(), resourceGroupName, virtualNetworkName)
+	subnets, err := client.List(ctx, resourceGroupName, virtualNetworkName)
 	if err != nil {
 		return nil, err
 	}
@@ -478,29 +700,41 @@ func GetSubnetSecurityGroupsForVirtualNetworkE(resourceGroupName, virtualNetwork
 
 // GetSubNetClient creates a virtual network subnet client
 func GetSubNetClient(subscriptionID string) (*network.SubnetsClient, error) {
-	subNetClient := network.NewSubnetsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
-
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewSubnetsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	subNetClient.Authorizer = *authorizer
-	return &subNetClient, nil
+	return client.(*network.SubnetsClient), nil
 }
 
 /********************************
 		Key Vault Management methods
 *********************************/
 
+// GetKeyVault is the same as GetKeyVaultE but fails the test on error
+func GetKeyVault(t *testing.T, resourceGroupName, keyVaultName string, subscriptionID ...string) *kv.Vault {
+	t.Helper()
+	keyVault, err := GetKeyVaultE(resourceGroupName, keyVaultName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.KeyVault/vaults", keyVaultName, resourceGroupName)
+	return keyVault
+}
+
 // GetKeyVaultE will return a Vault object and an error object
-func GetKeyVaultE(resourceGroupName, keyVaultName string) (*kv.Vault, error) {
-	client, err := GetKeyVaultManagementClientE(os.Getenv(SubscriptionIDEnvName))
+func GetKeyVaultE(resourceGroupName, keyVaultName string, subscriptionID ...string) (*kv.Vault, error) {
+	return GetKeyVaultWithContextE(context.Background(), resourceGroupName, keyVaultName, subscriptionID...)
+}
+
+// GetKeyVaultWithContextE will return a Vault object and an error object
+func GetKeyVaultWithContextE(ctx context.Context, resourceGroupName, keyVaultName string, subscriptionID ...string) (*kv.Vault, error) {
+	client, err := GetKeyVaultManagementClientE(getTargetSubscription(subscriptionID...))
 
 	if err != nil {
 		return nil, err
 	}
-	keyVault, err := client.Get(context.Background(), resourceGroupName, keyVaultName)
+	keyVault, err := client.Get(ctx, resourceGroupName, keyVaultName)
 	if err != nil {
 		return nil, err
 	}
@@ -509,13 +743,14 @@ func GetKeyVaultE(resourceGroupName, keyVaultName string) (*kv.Vault, error) {
 
 // GetKeyVaultManagementClientE creates a VaultsClient client
 func GetKeyVaultManagementClientE(subscriptionID string) (*kv.VaultsClient, error) {
-	client := kv.NewVaultsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := kv.NewVaultsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*kv.VaultsClient), nil
 }
 
 /********************************
@@ -523,34 +758,37 @@ func GetKeyVaultManagementClientE(subscriptionID string) (*kv.VaultsClient, erro
 *********************************/
 
 // NewKeyVaultAuthorizer witll return Authorizer for KeyVault
+// using the first credential source configured (see NewAuthorizerFromChainE)
 func NewKeyVaultAuthorizer() (*autorest.Authorizer, error) {
-	authorizer, err := kvauth.NewAuthorizerFromCLI()
+	authorizer, err := defaultClientFactory.KeyVaultAuthorizerE()
 	return &authorizer, err
 }
 
 // GetKeyVaultClientE creates a KeyVault client
 func GetKeyVaultClientE() (*keyvault.BaseClient, error) {
-	kvClient := keyvault.New()
-	authorizer, err := NewKeyVaultAuthorizer()
-
-	if err != nil {
-		return nil, err
-	}
-
-	kvClient.Authorizer = *authorizer
-	return &kvClient, nil
+	return defaultClientFactory.KeyVaultClientE()
 }
 
 // GetKeyVaultSecretCurrentVersion gets the current version of the KeyVault
 // e.g. https://foo.vault.azure.net/secrets/BAR/194bd7da9aa54944ab316faebd9120d0 -> 194bd7da9aa54944ab316faebd9120d0
 func GetKeyVaultSecretCurrentVersion(keyVaultName, secretName string) (string, error) {
+	return GetKeyVaultSecretCurrentVersionWithContext(context.Background(), keyVaultName, secretName)
+}
+
+// GetKeyVaultSecretCurrentVersionWithContext gets the current version of the KeyVault
+// e.g. https://foo.vault.azure.net/secrets/BAR/194bd7da9aa54944ab316faebd9120d0 -> 194bd7da9aa54944ab316faebd9120d0
+func GetKeyVaultSecretCurrentVersionWithContext(ctx context.Context, keyVaultName, secretName string) (string, error) {
 	client, err := GetKeyVaultClientE()
 	if err != nil {
 		return "", err
 	}
+	vaultURI, err := GetKeyVaultURIE(keyVaultName)
+	if err != nil {
+		return "", err
+	}
 	var maxVersionsCount int32 = 25
-	versions, err := client.GetSecretVersions(context.Background(),
-		fmt.Sprintf("https://%s.vault.azure.net/", keyVaultName),
+	versions, err := client.GetSecretVersions(ctx,
+		vaultURI,
 		secretName,
 		&maxVersionsCount)
 	if err != nil {
@@ -560,17 +798,26 @@ func GetKeyVaultSecretCurrentVersion(keyVaultName, secretName string) (string, e
 	sort.Slice(items, func(i, j int) bool {
 		return (*items[i].Attributes.Updated).Duration().Milliseconds() > (*items[j].Attributes.Updated).Duration().Milliseconds()
 	})
-	nonVersion := fmt.Sprintf("https://%s.vault.azure.net/secrets/%s/", keyVaultName, secretName)
+	nonVersion := fmt.Sprintf("%ssecrets/%s/", vaultURI, secretName)
 	return strings.Replace(*items[0].ID, nonVersion, "", 1), nil
 }
 
 // GetKeyVaultSecretWithVersion is get secret from the specific key vault.
 func GetKeyVaultSecretWithVersion(keyVaultName, secretName, version string) (string, error) {
+	return GetKeyVaultSecretWithVersionWithContext(context.Background(), keyVaultName, secretName, version)
+}
+
+// GetKeyVaultSecretWithVersionWithContext is get secret from the specific key vault.
+func GetKeyVaultSecretWithVersionWithContext(ctx context.Context, keyVaultName, secretName, version string) (string, error) {
 	client, err := GetKeyVaultClientE()
 	if err != nil {
 		return "", err
 	}
-	secret, err := client.GetSecret(context.Background(), fmt.Sprintf("https://%s.vault.azure.net/", keyVaultName), secretName, version)
+	vaultURI, err := GetKeyVaultURIE(keyVaultName)
+	if err != nil {
+		return "", err
+	}
+	secret, err := client.GetSecret(ctx, vaultURI, secretName, version)
 	if err != nil {
 		return "", err
 	}
@@ -579,11 +826,16 @@ func GetKeyVaultSecretWithVersion(keyVaultName, secretName, version string) (str
 
 // GetKeyVaultSecret returns current secret
 func GetKeyVaultSecret(keyVaultName, secretName string) (string, error) {
-	version, err := GetKeyVaultSecretCurrentVersion(keyVaultName, secretName)
+	return GetKeyVaultSecretWithContext(context.Background(), keyVaultName, secretName)
+}
+
+// GetKeyVaultSecretWithContext returns current secret
+func GetKeyVaultSecretWithContext(ctx context.Context, keyVaultName, secretName string) (string, error) {
+	version, err := GetKeyVaultSecretCurrentVersionWithContext(ctx, keyVaultName, secretName)
 	if err != nil {
 		return "", err
 	}
-	secret, err := GetKeyVaultSecretWithVersion(keyVaultName, secretName, version)
+	secret, err := GetKeyVaultSecretWithVersionWithContext(ctx, keyVaultName, secretName, version)
 	if err != nil {
 		return "", err
 	}
@@ -594,13 +846,26 @@ func GetKeyVaultSecret(keyVaultName, secretName string) (string, error) {
 		Private Endpoints
 ******************************************/
 
+// GetPrivateEndpoint is the same as GetPrivateEndpointE but fails the test on error
+func GetPrivateEndpoint(t *testing.T, resourceGroupName, endpointName string, subscriptionID ...string) *network.PrivateEndpoint {
+	t.Helper()
+	privateEndpoint, err := GetPrivateEndpointE(resourceGroupName, endpointName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/privateEndpoints", endpointName, resourceGroupName)
+	return privateEndpoint
+}
+
 // GetPrivateEndpointE will return a PrivateEndpoint object and an error object
-func GetPrivateEndpointE(resourceGroupName, endpointName string) (*network.PrivateEndpoint, error) {
-	client, err := GetPrivateEndpointsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetPrivateEndpointE(resourceGroupName, endpointName string, subscriptionID ...string) (*network.PrivateEndpoint, error) {
+	return GetPrivateEndpointWithContextE(context.Background(), resourceGroupName, endpointName, subscriptionID...)
+}
+
+// GetPrivateEndpointWithContextE will return a PrivateEndpoint object and an error object
+func GetPrivateEndpointWithContextE(ctx context.Context, resourceGroupName, endpointName string, subscriptionID ...string) (*network.PrivateEndpoint, error) {
+	client, err := GetPrivateEndpointsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	endpoint, err := client.Get(context.Background(), resourceGroupName, endpointName, "")
+	endpoint, err := client.Get(ctx, resourceGroupName, endpointName, "")
 	if err != nil {
 		return nil, err
 	}
@@ -609,39 +874,66 @@ func GetPrivateEndpointE(resourceGroupName, endpointName string) (*network.Priva
 
 // GetPrivateEndpointsClientE creates a PrivateEndpointsClient client
 func GetPrivateEndpointsClientE(subscriptionID string) (*network.PrivateEndpointsClient, error) {
-	client := network.NewPrivateEndpointsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewPrivateEndpointsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*network.PrivateEndpointsClient), nil
 }
 
 /*****************************************
 		Private DNS Zone Groups
 ******************************************/
 
+// GetPrivateDNSZoneGroup is the same as GetPrivateDNSZoneGroupE but fails the test on error
+func GetPrivateDNSZoneGroup(t *testing.T, resourceGroupName, endpointName string, groupName string, subscriptionID ...string) *network.PrivateDNSZoneGroup {
+	t.Helper()
+	privateDNSZoneGroup, err := GetPrivateDNSZoneGroupE(resourceGroupName, endpointName, groupName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/privateEndpoints/privateDnsZoneGroups", groupName, resourceGroupName)
+	return privateDNSZoneGroup
+}
+
 // GetPrivateDNSZoneGroupE will return a PrivateDNSZoneGroup object and an error object
-func GetPrivateDNSZoneGroupE(resourceGroupName, endpointName string, groupName string) (*network.PrivateDNSZoneGroup, error) {
-	client, err := GetPrivateDNSZoneGroupsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetPrivateDNSZoneGroupE(resourceGroupName, endpointName string, groupName string, subscriptionID ...string) (*network.PrivateDNSZoneGroup, error) {
+	return GetPrivateDNSZoneGroupWithContextE(context.Background(), resourceGroupName, endpointName, groupName, subscriptionID...)
+}
+
+// GetPrivateDNSZoneGroupWithContextE will return a PrivateDNSZoneGroup object and an error object
+func GetPrivateDNSZoneGroupWithContextE(ctx context.Context, resourceGroupName, endpointName string, groupName string, subscriptionID ...string) (*network.PrivateDNSZoneGroup, error) {
+	client, err := GetPrivateDNSZoneGroupsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, endpointName, groupName)
+	result, err := client.Get(ctx, resourceGroupName, endpointName, groupName)
 	if err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
+// ListPrivateDNSZoneGroups is the same as ListPrivateDNSZoneGroupsE but fails the test on error
+func ListPrivateDNSZoneGroups(t *testing.T, resourceGroupName, endpointName string, subscriptionID ...string) *[]network.PrivateDNSZoneGroup {
+	t.Helper()
+	privateDNSZoneGroups, err := ListPrivateDNSZoneGroupsE(resourceGroupName, endpointName, subscriptionID...)
+	failOnAzureError(t, err, "list private DNS zone groups of", "Microsoft.Network/privateEndpoints", endpointName, resourceGroupName)
+	return privateDNSZoneGroups
+}
+
 // ListPrivateDNSZoneGroupsE will return an array of PrivateDNSZoneGroup object and an error object
-func ListPrivateDNSZoneGroupsE(resourceGroupName, endpointName string) (*[]network.PrivateDNSZoneGroup, error) {
-	client, err := GetPrivateDNSZoneGroupsClientE(os.Getenv(SubscriptionIDEnvName))
+func ListPrivateDNSZoneGroupsE(resourceGroupName, endpointName string, subscriptionID ...string) (*[]network.PrivateDNSZoneGroup, error) {
+	return ListPrivateDNSZoneGroupsWithContextE(context.Background(), resourceGroupName, endpointName, subscriptionID...)
+}
+
+// ListPrivateDNSZoneGroupsWithContextE will return an array of PrivateDNSZoneGroup object and an error object
+func ListPrivateDNSZoneGroupsWithContextE(ctx context.Context, resourceGroupName, endpointName string, subscriptionID ...string) (*[]network.PrivateDNSZoneGroup, error) {
+	client, err := GetPrivateDNSZoneGroupsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.List(context.Background(), endpointName, resourceGroupName)
+	result, err := client.List(ctx, endpointName, resourceGroupName)
 	if err != nil {
 		return nil, err
 	}
@@ -651,26 +943,40 @@ func ListPrivateDNSZoneGroupsE(resourceGroupName, endpointName string) (*[]netwo
 
 // GetPrivateDNSZoneGroupsClientE creates a PrivateDNSZoneGroupsClient client
 func GetPrivateDNSZoneGroupsClientE(subscriptionID string) (*network.PrivateDNSZoneGroupsClient, error) {
-	client := network.NewPrivateDNSZoneGroupsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewPrivateDNSZoneGroupsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*network.PrivateDNSZoneGroupsClient), nil
 }
 
 /*****************************************
 		Private DNS Zones
 ******************************************/
 
+// GetPrivateDNSZone is the same as GetPrivateDNSZoneE but fails the test on error
+func GetPrivateDNSZone(t *testing.T, resourceGroupName string, dnsZoneName string, subscriptionID ...string) *privatedns.PrivateZone {
+	t.Helper()
+	privateDNSZone, err := GetPrivateDNSZoneE(resourceGroupName, dnsZoneName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/privateDnsZones", dnsZoneName, resourceGroupName)
+	return privateDNSZone
+}
+
 // GetPrivateDNSZoneE will return a PrivateDNSZoneGroup object and an error object
-func GetPrivateDNSZoneE(resourceGroupName string, dnsZoneName string) (*privatedns.PrivateZone, error) {
-	client, err := GetPrivateDNSZonesClientE(os.Getenv(SubscriptionIDEnvName))
+func GetPrivateDNSZoneE(resourceGroupName string, dnsZoneName string, subscriptionID ...string) (*privatedns.PrivateZone, error) {
+	return GetPrivateDNSZoneWithContextE(context.Background(), resourceGroupName, dnsZoneName, subscriptionID...)
+}
+
+// GetPrivateDNSZoneWithContextE will return a PrivateDNSZoneGroup object and an error object
+func GetPrivateDNSZoneWithContextE(ctx context.Context, resourceGroupName string, dnsZoneName string, subscriptionID ...string) (*privatedns.PrivateZone, error) {
+	client, err := GetPrivateDNSZonesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	zone, err := client.Get(context.Background(), resourceGroupName, dnsZoneName)
+	zone, err := client.Get(ctx, resourceGroupName, dnsZoneName)
 	if err != nil {
 		return nil, err
 	}
@@ -679,26 +985,40 @@ func GetPrivateDNSZoneE(resourceGroupName string, dnsZoneName string) (*privated
 
 // GetPrivateDNSZonesClientE creates a PrivateZonesClient
 func GetPrivateDNSZonesClientE(subscriptionID string) (*privatedns.PrivateZonesClient, error) {
-	client := privatedns.NewPrivateZonesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := privatedns.NewPrivateZonesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*privatedns.PrivateZonesClient), nil
 }
 
 /*****************************************
 		Record Sets
 ******************************************/
 
+// ListRecordSets is the same as ListRecordSetsE but fails the test on error
+func ListRecordSets(t *testing.T, resourceGroupName string, dnsZoneName string, numberToRetrieve int32, subscriptionID ...string) *[]privatedns.RecordSet {
+	t.Helper()
+	recordSets, err := ListRecordSetsE(resourceGroupName, dnsZoneName, numberToRetrieve, subscriptionID...)
+	failOnAzureError(t, err, "list record sets of", "Microsoft.Network/privateDnsZones", dnsZoneName, resourceGroupName)
+	return recordSets
+}
+
 // ListRecordSetsE will return a PrivateDNSZoneGroup object and an error object
-func ListRecordSetsE(resourceGroupName string, dnsZoneName string, numberToRetrieve int32) (*[]privatedns.RecordSet, error) {
-	client, err := GetRecordSetsClientE(os.Getenv(SubscriptionIDEnvName))
+func ListRecordSetsE(resourceGroupName string, dnsZoneName string, numberToRetrieve int32, subscriptionID ...string) (*[]privatedns.RecordSet, error) {
+	return ListRecordSetsWithContextE(context.Background(), resourceGroupName, dnsZoneName, numberToRetrieve, subscriptionID...)
+}
+
+// ListRecordSetsWithContextE will return a PrivateDNSZoneGroup object and an error object
+func ListRecordSetsWithContextE(ctx context.Context, resourceGroupName string, dnsZoneName string, numberToRetrieve int32, subscriptionID ...string) (*[]privatedns.RecordSet, error) {
+	client, err := GetRecordSetsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.List(context.Background(), resourceGroupName, dnsZoneName, &numberToRetrieve, "")
+	result, err := client.List(ctx, resourceGroupName, dnsZoneName, &numberToRetrieve, "")
 	if err != nil {
 		return nil, err
 	}
@@ -711,27 +1031,41 @@ func ListRecordSetsE(resourceGroupName string, dnsZoneName string, numberToRetri
 
 // GetRecordSetsClientE creates a PrivateZonesClient
 func GetRecordSetsClientE(subscriptionID string) (*privatedns.RecordSetsClient, error) {
-	client := privatedns.NewRecordSetsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := privatedns.NewRecordSetsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*privatedns.RecordSetsClient), nil
 }
 
 /*****************************************
 		Virtual Network Link
 ******************************************/
 
+// ListVirtualNetworkLink is the same as ListVirtualNetworkLinkE but fails the test on error
+func ListVirtualNetworkLink(t *testing.T, resourceGroupName string, dnsZoneName string, numberToRetrieve int32, subscriptionID ...string) *[]privatedns.VirtualNetworkLink {
+	t.Helper()
+	virtualNetworkLink, err := ListVirtualNetworkLinkE(resourceGroupName, dnsZoneName, numberToRetrieve, subscriptionID...)
+	failOnAzureError(t, err, "list virtual network links of", "Microsoft.Network/privateDnsZones", dnsZoneName, resourceGroupName)
+	return virtualNetworkLink
+}
+
 // ListVirtualNetworkLinkE will return an array of VirtualNetworkLink objects and an error object
-func ListVirtualNetworkLinkE(resourceGroupName string, dnsZoneName string, numberToRetrieve int32) (*[]privatedns.VirtualNetworkLink, error) {
-	client, err := GetVirtualNetworkLinksClient(os.Getenv(SubscriptionIDEnvName))
+func ListVirtualNetworkLinkE(resourceGroupName string, dnsZoneName string, numberToRetrieve int32, subscriptionID ...string) (*[]privatedns.VirtualNetworkLink, error) {
+	return ListVirtualNetworkLinkWithContextE(context.Background(), resourceGroupName, dnsZoneName, numberToRetrieve, subscriptionID...)
+}
+
+// ListVirtualNetworkLinkWithContextE will return an array of VirtualNetworkLink objects and an error object
+func ListVirtualNetworkLinkWithContextE(ctx context.Context, resourceGroupName string, dnsZoneName string, numberToRetrieve int32, subscriptionID ...string) (*[]privatedns.VirtualNetworkLink, error) {
+	client, err := GetVirtualNetworkLinksClient(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := client.List(context.Background(), resourceGroupName, dnsZoneName, &numberToRetrieve)
+	result, err := client.List(ctx, resourceGroupName, dnsZoneName, &numberToRetrieve)
 	if err != nil {
 		return nil, err
 	}
@@ -743,14 +1077,27 @@ func ListVirtualNetworkLinkE(resourceGroupName string, dnsZoneName string, numbe
 	return &networkLinks, nil
 }
 
+// GetVirtualNetworkLink is the same as GetVirtualNetworkLinkE but fails the test on error
+func GetVirtualNetworkLink(t *testing.T, resourceGroupName string, dnsZoneName string, name string, subscriptionID ...string) *privatedns.VirtualNetworkLink {
+	t.Helper()
+	virtualNetworkLink, err := GetVirtualNetworkLinkE(resourceGroupName, dnsZoneName, name, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/privateDnsZones/virtualNetworkLinks", name, resourceGroupName)
+	return virtualNetworkLink
+}
+
 // GetVirtualNetworkLinkE will return a privatedns.VirtualNetworkLink object and an error object
-func GetVirtualNetworkLinkE(resourceGroupName string, dnsZoneName string, name string) (*privatedns.VirtualNetworkLink, error) {
-	client, err := GetVirtualNetworkLinksClient(os.Getenv(SubscriptionIDEnvName))
+func GetVirtualNetworkLinkE(resourceGroupName string, dnsZoneName string, name string, subscriptionID ...string) (*privatedns.VirtualNetworkLink, error) {
+	return GetVirtualNetworkLinkWithContextE(context.Background(), resourceGroupName, dnsZoneName, name, subscriptionID...)
+}
+
+// GetVirtualNetworkLinkWithContextE will return a privatedns.VirtualNetworkLink object and an error object
+func GetVirtualNetworkLinkWithContextE(ctx context.Context, resourceGroupName string, dnsZoneName string, name string, subscriptionID ...string) (*privatedns.VirtualNetworkLink, error) {
+	client, err := GetVirtualNetworkLinksClient(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := client.Get(context.Background(), resourceGroupName, dnsZoneName, name)
+	result, err := client.Get(ctx, resourceGroupName, dnsZoneName, name)
 	if err != nil {
 		return nil, err
 	}
@@ -760,26 +1107,40 @@ func GetVirtualNetworkLinkE(resourceGroupName string, dnsZoneName string, name s
 
 // GetVirtualNetworkLinksClient creates a PrivateZonesClient
 func GetVirtualNetworkLinksClient(subscriptionID string) (*privatedns.VirtualNetworkLinksClient, error) {
-	client := privatedns.NewVirtualNetworkLinksClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := privatedns.NewVirtualNetworkLinksClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*privatedns.VirtualNetworkLinksClient), nil
 }
 
 /*****************************************
 		Route Tables
 ******************************************/
 
+// GetRouteTable is the same as GetRouteTableE but fails the test on error
+func GetRouteTable(t *testing.T, resourceGroupName, routeTableName string, subscriptionID ...string) *network.RouteTable {
+	t.Helper()
+	routeTable, err := GetRouteTableE(resourceGroupName, routeTableName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/routeTables", routeTableName, resourceGroupName)
+	return routeTable
+}
+
 // GetRouteTableE will return a RouteTable object and an error object
-func GetRouteTableE(resourceGroupName, routeTableName string) (*network.RouteTable, error) {
-	client, err := GetRouteTableClientE(os.Getenv(SubscriptionIDEnvName))
+func GetRouteTableE(resourceGroupName, routeTableName string, subscriptionID ...string) (*network.RouteTable, error) {
+	return GetRouteTableWithContextE(context.Background(), resourceGroupName, routeTableName, subscriptionID...)
+}
+
+// GetRouteTableWithContextE will return a RouteTable object and an error object
+func GetRouteTableWithContextE(ctx context.Context, resourceGroupName, routeTableName string, subscriptionID ...string) (*network.RouteTable, error) {
+	client, err := GetRouteTableClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	table, err := client.Get(context.Background(), resourceGroupName, routeTableName, "")
+	table, err := client.Get(ctx, resourceGroupName, routeTableName, "")
 	if err != nil {
 		return nil, err
 	}
@@ -788,26 +1149,40 @@ func GetRouteTableE(resourceGroupName, routeTableName string) (*network.RouteTab
 
 // GetRouteTableClientE creates a RouteTablesClient
 func GetRouteTableClientE(subscriptionID string) (*network.RouteTablesClient, error) {
-	client := network.NewRouteTablesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewRouteTablesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*network.RouteTablesClient), nil
 }
 
 /*****************************************
 		Network Security Groups (NSG)
 ******************************************/
 
+// GetSecurityGroup is the same as GetSecurityGroupE but fails the test on error
+func GetSecurityGroup(t *testing.T, resourceGroupName, securityGroupName string, subscriptionID ...string) *network.SecurityGroup {
+	t.Helper()
+	securityGroup, err := GetSecurityGroupE(resourceGroupName, securityGroupName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/networkSecurityGroups", securityGroupName, resourceGroupName)
+	return securityGroup
+}
+
 // GetSecurityGroupE will return a SecurityGroup object and an error object
-func GetSecurityGroupE(resourceGroupName, securityGroupName string) (*network.SecurityGroup, error) {
-	client, err := GetSecurityGroupsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetSecurityGroupE(resourceGroupName, securityGroupName string, subscriptionID ...string) (*network.SecurityGroup, error) {
+	return GetSecurityGroupWithContextE(context.Background(), resourceGroupName, securityGroupName, subscriptionID...)
+}
+
+// GetSecurityGroupWithContextE will return a SecurityGroup object and an error object
+func GetSecurityGroupWithContextE(ctx context.Context, resourceGroupName, securityGroupName string, subscriptionID ...string) (*network.SecurityGroup, error) {
+	client, err := GetSecurityGroupsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	securityGroup, err := client.Get(context.Background(), resourceGroupName, securityGroupName, "")
+	securityGroup, err := client.Get(ctx, resourceGroupName, securityGroupName, "")
 	if err != nil {
 		return nil, err
 	}
@@ -816,39 +1191,66 @@ func GetSecurityGroupE(resourceGroupName, securityGroupName string) (*network.Se
 
 // GetSecurityGroupsClientE creates a SecurityGroupClient client
 func GetSecurityGroupsClientE(subscriptionID string) (*network.SecurityGroupsClient, error) {
-	client := network.NewSecurityGroupsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewSecurityGroupsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*network.SecurityGroupsClient), nil
 }
 
 /*****************************************
 		Container Registry
 ******************************************/
 
+// GetRegistry is the same as GetRegistryE but fails the test on error
+func GetRegistry(t *testing.T, resourceGroupName, containerRegistry string, subscriptionID ...string) *containerregistry.Registry {
+	t.Helper()
+	registry, err := GetRegistryE(resourceGroupName, containerRegistry, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.ContainerRegistry/registries", containerRegistry, resourceGroupName)
+	return registry
+}
+
 // GetRegistryE will return a Registry object and an error object
-func GetRegistryE(resourceGroupName, containerRegistry string) (*containerregistry.Registry, error) {
-	client, err := GetRegistriesClientE(os.Getenv(SubscriptionIDEnvName))
+func GetRegistryE(resourceGroupName, containerRegistry string, subscriptionID ...string) (*containerregistry.Registry, error) {
+	return GetRegistryWithContextE(context.Background(), resourceGroupName, containerRegistry, subscriptionID...)
+}
+
+// GetRegistryWithContextE will return a Registry object and an error object
+func GetRegistryWithContextE(ctx context.Context, resourceGroupName, containerRegistry string, subscriptionID ...string) (*containerregistry.Registry, error) {
+	client, err := GetRegistriesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	securityGroup, err := client.Get(context.Background(), resourceGroupName, containerRegistry)
+	securityGroup, err := client.Get(ctx, resourceGroupName, containerRegistry)
 	if err != nil {
 		return nil, err
 	}
 	return &securityGroup, nil
 }
 
+// GetRegistryPolicies is the same as GetRegistryPoliciesE but fails the test on error
+func GetRegistryPolicies(t *testing.T, resourceGroupName, containerRegistry string, subscriptionID ...string) *containerregistry.RegistryPolicies {
+	t.Helper()
+	registryPolicies, err := GetRegistryPoliciesE(resourceGroupName, containerRegistry, subscriptionID...)
+	failOnAzureError(t, err, "get policies of", "Microsoft.ContainerRegistry/registries", containerRegistry, resourceGroupName)
+	return registryPolicies
+}
+
 // GetRegistryPoliciesE will return a RegistryPolicies object and an error object
-func GetRegistryPoliciesE(resourceGroupName, containerRegistry string) (*containerregistry.RegistryPolicies, error) {
-	client, err := GetRegistriesClientE(os.Getenv(SubscriptionIDEnvName))
+func GetRegistryPoliciesE(resourceGroupName, containerRegistry string, subscriptionID ...string) (*containerregistry.RegistryPolicies, error) {
+	return GetRegistryPoliciesWithContextE(context.Background(), resourceGroupName, containerRegistry, subscriptionID...)
+}
+
+// GetRegistryPoliciesWithContextE will return a RegistryPolicies object and an error object
+func GetRegistryPoliciesWithContextE(ctx context.Context, resourceGroupName, containerRegistry string, subscriptionID ...string) (*containerregistry.RegistryPolicies, error) {
+	client, err := GetRegistriesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	securityGroup, err := client.ListPolicies(context.Background(), resourceGroupName, containerRegistry)
+	securityGroup, err := client.ListPolicies(ctx, resourceGroupName, containerRegistry)
 	if err != nil {
 		return nil, err
 	}
@@ -857,26 +1259,40 @@ func GetRegistryPoliciesE(resourceGroupName, containerRegistry string) (*contain
 
 // GetRegistriesClientE creates a RegistriesClient client
 func GetRegistriesClientE(subscriptionID string) (*containerregistry.RegistriesClient, error) {
-	client := containerregistry.NewRegistriesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := containerregistry.NewRegistriesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*containerregistry.RegistriesClient), nil
 }
 
 /********************************
 		Bastion
 *********************************/
 
+// GetBastionHost is the same as GetBastionHostE but fails the test on error
+func GetBastionHost(t *testing.T, resourceGroupName string, bastionHostName string, subscriptionID ...string) *network.BastionHost {
+	t.Helper()
+	bastionHost, err := GetBastionHostE(resourceGroupName, bastionHostName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/bastionHosts", bastionHostName, resourceGroupName)
+	return bastionHost
+}
+
 // GetBastionHostE will return BastionHost object and an error object
-func GetBastionHostE(resourceGroupName string, bastionHostName string) (*network.BastionHost, error) {
-	client, err := GetBastionHostClientE(os.Getenv(SubscriptionIDEnvName))
+func GetBastionHostE(resourceGroupName string, bastionHostName string, subscriptionID ...string) (*network.BastionHost, error) {
+	return GetBastionHostWithContextE(context.Background(), resourceGroupName, bastionHostName, subscriptionID...)
+}
+
+// GetBastionHostWithContextE will return BastionHost object and an error object
+func GetBastionHostWithContextE(ctx context.Context, resourceGroupName string, bastionHostName string, subscriptionID ...string) (*network.BastionHost, error) {
+	client, err := GetBastionHostClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	bastionHost, err := client.Get(context.Background(), resourceGroupName, bastionHostName)
+	bastionHost, err := client.Get(ctx, resourceGroupName, bastionHostName)
 	if err != nil {
 		return nil, err
 	}
@@ -885,26 +1301,40 @@ func GetBastionHostE(resourceGroupName string, bastionHostName string) (*network
 
 // GetBastionHostClientE creates a BastionHostsClient client
 func GetBastionHostClientE(subscriptionID string) (*network.BastionHostsClient, error) {
-	client := network.NewBastionHostsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewBastionHostsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*network.BastionHostsClient), nil
 }
 
 /********************************
 		Application Gateway
 *********************************/
 
+// GetApplicationGateway is the same as GetApplicationGatewayE but fails the test on error
+func GetApplicationGateway(t *testing.T, resourceGroupName, applicationGatewayName string, subscriptionID ...string) *network.ApplicationGateway {
+	t.Helper()
+	applicationGateway, err := GetApplicationGatewayE(resourceGroupName, applicationGatewayName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/applicationGateways", applicationGatewayName, resourceGroupName)
+	return applicationGateway
+}
+
 // GetApplicationGatewayE will return ApplicationGateway object and an error object
-func GetApplicationGatewayE(resourceGroupName, applicationGatewayName string) (*network.ApplicationGateway, error) {
-	client, err := GetApplicationGatewayClientE(os.Getenv(SubscriptionIDEnvName))
+func GetApplicationGatewayE(resourceGroupName, applicationGatewayName string, subscriptionID ...string) (*network.ApplicationGateway, error) {
+	return GetApplicationGatewayWithContextE(context.Background(), resourceGroupName, applicationGatewayName, subscriptionID...)
+}
+
+// GetApplicationGatewayWithContextE will return ApplicationGateway object and an error object
+func GetApplicationGatewayWithContextE(ctx context.Context, resourceGroupName, applicationGatewayName string, subscriptionID ...string) (*network.ApplicationGateway, error) {
+	client, err := GetApplicationGatewayClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	applicationGateway, err := client.Get(context.Background(), resourceGroupName, applicationGatewayName)
+	applicationGateway, err := client.Get(ctx, resourceGroupName, applicationGatewayName)
 	if err != nil {
 		return nil, err
 	}
@@ -913,26 +1343,40 @@ func GetApplicationGatewayE(resourceGroupName, applicationGatewayName string) (*
 
 // GetApplicationGatewayClientE creates a ApplicationGatewaysClient client
 func GetApplicationGatewayClientE(subscriptionID string) (*network.ApplicationGatewaysClient, error) {
-	client := network.NewApplicationGatewaysClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewApplicationGatewaysClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*network.ApplicationGatewaysClient), nil
 }
 
 /********************************
 		PublicIP
 *********************************/
 
+// GetPublicIPAddress is the same as GetPublicIPAddressE but fails the test on error
+func GetPublicIPAddress(t *testing.T, resourceGroupName, publicIPAddressName string, subscriptionID ...string) *network.PublicIPAddress {
+	t.Helper()
+	publicIPAddress, err := GetPublicIPAddressE(resourceGroupName, publicIPAddressName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/publicIPAddresses", publicIPAddressName, resourceGroupName)
+	return publicIPAddress
+}
+
 // GetPublicIPAddressE will return PublicIPAddress object and an error object
-func GetPublicIPAddressE(resourceGroupName, publicIPAddressName string) (*network.PublicIPAddress, error) {
-	client, err := GetPublicIPAddressClientE(os.Getenv(SubscriptionIDEnvName))
+func GetPublicIPAddressE(resourceGroupName, publicIPAddressName string, subscriptionID ...string) (*network.PublicIPAddress, error) {
+	return GetPublicIPAddressWithContextE(context.Background(), resourceGroupName, publicIPAddressName, subscriptionID...)
+}
+
+// GetPublicIPAddressWithContextE will return PublicIPAddress object and an error object
+func GetPublicIPAddressWithContextE(ctx context.Context, resourceGroupName, publicIPAddressName string, subscriptionID ...string) (*network.PublicIPAddress, error) {
+	client, err := GetPublicIPAddressClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	publicIPAddress, err := client.Get(context.Background(), resourceGroupName, publicIPAddressName, "")
+	publicIPAddress, err := client.Get(ctx, resourceGroupName, publicIPAddressName, "")
 	if err != nil {
 		return nil, err
 	}
@@ -941,26 +1385,40 @@ func GetPublicIPAddressE(resourceGroupName, publicIPAddressName string) (*networ
 
 // GetPublicIPAddressClientE creates a PublicIPAddresses client
 func GetPublicIPAddressClientE(subscriptionID string) (*network.PublicIPAddressesClient, error) {
-	client := network.NewPublicIPAddressesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewPublicIPAddressesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*network.PublicIPAddressesClient), nil
 }
 
 /********************************
 		UserAssignedIdentity
 *********************************/
 
+// GetUserAssignedIdentity is the same as GetUserAssignedIdentityE but fails the test on error
+func GetUserAssignedIdentity(t *testing.T, resourceGroupName, identityName string, subscriptionID ...string) *msi.Identity {
+	t.Helper()
+	userAssignedIdentity, err := GetUserAssignedIdentityE(resourceGroupName, identityName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.ManagedIdentity/userAssignedIdentities", identityName, resourceGroupName)
+	return userAssignedIdentity
+}
+
 // GetUserAssignedIdentityE will return Identity object and an error object
-func GetUserAssignedIdentityE(resourceGroupName, identityName string) (*msi.Identity, error) {
-	client, err := GetUserAssignedIdentitiesClientE(os.Getenv(SubscriptionIDEnvName))
+func GetUserAssignedIdentityE(resourceGroupName, identityName string, subscriptionID ...string) (*msi.Identity, error) {
+	return GetUserAssignedIdentityWithContextE(context.Background(), resourceGroupName, identityName, subscriptionID...)
+}
+
+// GetUserAssignedIdentityWithContextE will return Identity object and an error object
+func GetUserAssignedIdentityWithContextE(ctx context.Context, resourceGroupName, identityName string, subscriptionID ...string) (*msi.Identity, error) {
+	client, err := GetUserAssignedIdentitiesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	identity, err := client.Get(context.Background(), resourceGroupName, identityName)
+	identity, err := client.Get(ctx, resourceGroupName, identityName)
 	if err != nil {
 		return nil, err
 	}
@@ -969,13 +1427,14 @@ func GetUserAssignedIdentityE(resourceGroupName, identityName string) (*msi.Iden
 
 // GetUserAssignedIdentitiesClientE creates a UserAssignedIdentitiesClient
 func GetUserAssignedIdentitiesClientE(subscriptionID string) (*msi.UserAssignedIdentitiesClient, error) {
-	client := msi.NewUserAssignedIdentitiesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := msi.NewUserAssignedIdentitiesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*msi.UserAssignedIdentitiesClient), nil
 }
 
 /********************************
@@ -983,14 +1442,19 @@ func GetUserAssignedIdentitiesClientE(subscriptionID string) (*msi.UserAssignedI
 *********************************/
 
 // ListRoleAssignmentsForPrincipalID will return Identity object and an error object
-func ListRoleAssignmentsForPrincipalID(resourceGroupName string, principalID string) (*[]string, error) {
-	client, err := GetRoleAssignmentsClientE(os.Getenv(SubscriptionIDEnvName))
+func ListRoleAssignmentsForPrincipalID(resourceGroupName string, principalID string, subscriptionID ...string) (*[]string, error) {
+	return ListRoleAssignmentsForPrincipalIDWithContext(context.Background(), resourceGroupName, principalID, subscriptionID...)
+}
+
+// ListRoleAssignmentsForPrincipalIDWithContext will return Identity object and an error object
+func ListRoleAssignmentsForPrincipalIDWithContext(ctx context.Context, resourceGroupName string, principalID string, subscriptionID ...string) (*[]string, error) {
+	client, err := GetRoleAssignmentsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
 
 	filter := fmt.Sprintf("principalId eq '{%s}'", principalID)
-	result, err := client.ListForResourceGroup(context.Background(), resourceGroupName, filter)
+	result, err := client.ListForResourceGroup(ctx, resourceGroupName, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -1005,26 +1469,40 @@ func ListRoleAssignmentsForPrincipalID(resourceGroupName string, principalID str
 
 // GetRoleAssignmentsClientE creates a RoleAssignmentsClient
 func GetRoleAssignmentsClientE(subscriptionID string) (*authorization.RoleAssignmentsClient, error) {
-	client := authorization.NewRoleAssignmentsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := authorization.NewRoleAssignmentsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*authorization.RoleAssignmentsClient), nil
 }
 
 /********************************
 		Role Definitions
 *********************************/
 
+// GetRoleDefinition is the same as GetRoleDefinitionE but fails the test on error
+func GetRoleDefinition(t *testing.T, roleDefinitionID string, subscriptionID ...string) *authorization.RoleDefinition {
+	t.Helper()
+	roleDefinition, err := GetRoleDefinitionE(roleDefinitionID, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Authorization/roleDefinitions", roleDefinitionID, "")
+	return roleDefinition
+}
+
 // GetRoleDefinitionE will return RoleDefinition object and an error object
-func GetRoleDefinitionE(roleDefinitionID string) (*authorization.RoleDefinition, error) {
-	client, err := GetRoleDefinitionsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetRoleDefinitionE(roleDefinitionID string, subscriptionID ...string) (*authorization.RoleDefinition, error) {
+	return GetRoleDefinitionWithContextE(context.Background(), roleDefinitionID, subscriptionID...)
+}
+
+// GetRoleDefinitionWithContextE will return RoleDefinition object and an error object
+func GetRoleDefinitionWithContextE(ctx context.Context, roleDefinitionID string, subscriptionID ...string) (*authorization.RoleDefinition, error) {
+	client, err := GetRoleDefinitionsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	identity, err := client.GetByID(context.Background(), roleDefinitionID)
+	identity, err := client.GetByID(ctx, roleDefinitionID)
 	if err != nil {
 		return nil, err
 	}
@@ -1033,26 +1511,40 @@ func GetRoleDefinitionE(roleDefinitionID string) (*authorization.RoleDefinition,
 
 // GetRoleDefinitionsClientE creates a RoleDefinitionsClient
 func GetRoleDefinitionsClientE(subscriptionID string) (*authorization.RoleDefinitionsClient, error) {
-	client := authorization.NewRoleDefinitionsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := authorization.NewRoleDefinitionsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*authorization.RoleDefinitionsClient), nil
 }
 
 /********************************
 		AKS (Managed Cluster)
 *********************************/
 
+// GetManagedCluster is the same as GetManagedClusterE but fails the test on error
+func GetManagedCluster(t *testing.T, resourceGroupName, clusterName string, subscriptionID ...string) *containerservice.ManagedCluster {
+	t.Helper()
+	managedCluster, err := GetManagedClusterE(resourceGroupName, clusterName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.ContainerService/managedClusters", clusterName, resourceGroupName)
+	return managedCluster
+}
+
 // GetManagedClusterE will return ContainerService object and an error object
-func GetManagedClusterE(resourceGroupName, clusterName string) (*containerservice.ManagedCluster, error) {
-	client, err := GetManagedClustersClientE(os.Getenv(SubscriptionIDEnvName))
+func GetManagedClusterE(resourceGroupName, clusterName string, subscriptionID ...string) (*containerservice.ManagedCluster, error) {
+	return GetManagedClusterWithContextE(context.Background(), resourceGroupName, clusterName, subscriptionID...)
+}
+
+// GetManagedClusterWithContextE will return ContainerService object and an error object
+func GetManagedClusterWithContextE(ctx context.Context, resourceGroupName, clusterName string, subscriptionID ...string) (*containerservice.ManagedCluster, error) {
+	client, err := GetManagedClustersClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	managedCluster, err := client.Get(context.Background(), resourceGroupName, clusterName)
+	managedCluster, err := client.Get(ctx, resourceGroupName, clusterName)
 	if err != nil {
 		return nil, err
 	}
@@ -1061,29 +1553,50 @@ func GetManagedClusterE(resourceGroupName, clusterName string) (*containerservic
 
 // GetManagedClustersClientE creates a ContainerServicesClient client
 func GetManagedClustersClientE(subscriptionID string) (*containerservice.ManagedClustersClient, error) {
-	client := containerservice.NewManagedClustersClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := containerservice.NewManagedClustersClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*containerservice.ManagedClustersClient), nil
+}
+
+// GetClusterAdminCredentials is the same as GetClusterAdminCredentialsE but fails the test on error
+func GetClusterAdminCredentials(t *testing.T, resourceGroupName, clusterName string, subscriptionID ...string) *containerservice.CredentialResults {
+	t.Helper()
+	clusterAdminCredentials, err := GetClusterAdminCredentialsE(resourceGroupName, clusterName, subscriptionID...)
+	failOnAzureError(t, err, "get admin credentials of", "Microsoft.ContainerService/managedClusters", clusterName, resourceGroupName)
+	return clusterAdminCredentials
 }
 
 // GetClusterAdminCredentialsE returns credential information includes kubeconfig
-func GetClusterAdminCredentialsE(resourceGroupName, clusterName string) (*containerservice.CredentialResults, error) {
-	client, err := GetManagedClustersClientE(os.Getenv(SubscriptionIDEnvName))
+func GetClusterAdminCredentialsE(resourceGroupName, clusterName string, subscriptionID ...string) (*containerservice.CredentialResults, error) {
+	return GetClusterAdminCredentialsWithContextE(context.Background(), resourceGroupName, clusterName, subscriptionID...)
+}
+
+// GetClusterAdminCredentialsWithContextE returns credential information includes kubeconfig
+func GetClusterAdminCredentialsWithContextE(ctx context.Context, resourceGroupName, clusterName string, subscriptionID ...string) (*containerservice.CredentialResults, error) {
+	client, err := GetManagedClustersClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
 
-	credentials, err := client.ListClusterAdminCredentials(context.Background(), resourceGroupName, clusterName)
+	credentials, err := client.ListClusterAdminCredentials(ctx, resourceGroupName, clusterName)
 	if err != nil {
 		return nil, err
 	}
 	return &credentials, nil
 }
 
+// WriteKubeconfigFromCredentials is the same as WriteKubeconfigFromCredentialsE but fails the test on error
+func WriteKubeconfigFromCredentials(t *testing.T, credentialResults *containerservice.CredentialResults, filePath string) {
+	t.Helper()
+	err := WriteKubeconfigFromCredentialsE(credentialResults, filePath)
+	failOnAzureError(t, err, "write kubeconfig", "", filePath, "")
+}
+
 // WriteKubeconfigFromCredentialsE writes a kubeconfig file
 func WriteKubeconfigFromCredentialsE(credentialResults *containerservice.CredentialResults, filePath string) error {
 	kubeconfig := (*(*credentialResults.Kubeconfigs)[0].Value)
@@ -1094,13 +1607,26 @@ func WriteKubeconfigFromCredentialsE(credentialResults *containerservice.Credent
 		Availability Sets
 *********************************/
 
+// GetAvailabilitySet is the same as GetAvailabilitySetE but fails the test on error
+func GetAvailabilitySet(t *testing.T, resourceGroupName string, availabilitySetName string, subscriptionID ...string) *compute.AvailabilitySet {
+	t.Helper()
+	availabilitySet, err := GetAvailabilitySetE(resourceGroupName, availabilitySetName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Compute/availabilitySets", availabilitySetName, resourceGroupName)
+	return availabilitySet
+}
+
 // GetAvailabilitySetE will return AvailabilitySet object and an error object
-func GetAvailabilitySetE(resourceGroupName string, availabilitySetName string) (*compute.AvailabilitySet, error) {
-	client, err := GetAvailabilitySetsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetAvailabilitySetE(resourceGroupName string, availabilitySetName string, subscriptionID ...string) (*compute.AvailabilitySet, error) {
+	return GetAvailabilitySetWithContextE(context.Background(), resourceGroupName, availabilitySetName, subscriptionID...)
+}
+
+// GetAvailabilitySetWithContextE will return AvailabilitySet object and an error object
+func GetAvailabilitySetWithContextE(ctx context.Context, resourceGroupName string, availabilitySetName string, subscriptionID ...string) (*compute.AvailabilitySet, error) {
+	client, err := GetAvailabilitySetsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, availabilitySetName)
+	result, err := client.Get(ctx, resourceGroupName, availabilitySetName)
 	if err != nil {
 		return nil, err
 	}
@@ -1109,26 +1635,40 @@ func GetAvailabilitySetE(resourceGroupName string, availabilitySetName string) (
 
 // GetAvailabilitySetsClientE creates a AvailabilitySetsClient
 func GetAvailabilitySetsClientE(subscriptionID string) (*compute.AvailabilitySetsClient, error) {
-	client := compute.NewAvailabilitySetsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := compute.NewAvailabilitySetsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*compute.AvailabilitySetsClient), nil
 }
 
 /********************************
 		SQL Managed Instance
 *********************************/
 
+// GetManagedInstance is the same as GetManagedInstanceE but fails the test on error
+func GetManagedInstance(t *testing.T, resourceGroupName string, managedInstanceName string, subscriptionID ...string) *sqlmi.ManagedInstance {
+	t.Helper()
+	managedInstance, err := GetManagedInstanceE(resourceGroupName, managedInstanceName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Sql/managedInstances", managedInstanceName, resourceGroupName)
+	return managedInstance
+}
+
 // GetManagedInstanceE will return ManagedInstance object and an error object
-func GetManagedInstanceE(resourceGroupName string, managedInstanceName string) (*sqlmi.ManagedInstance, error) {
-	client, err := GetManagedInstancesClientE(os.Getenv(SubscriptionIDEnvName))
+func GetManagedInstanceE(resourceGroupName string, managedInstanceName string, subscriptionID ...string) (*sqlmi.ManagedInstance, error) {
+	return GetManagedInstanceWithContextE(context.Background(), resourceGroupName, managedInstanceName, subscriptionID...)
+}
+
+// GetManagedInstanceWithContextE will return ManagedInstance object and an error object
+func GetManagedInstanceWithContextE(ctx context.Context, resourceGroupName string, managedInstanceName string, subscriptionID ...string) (*sqlmi.ManagedInstance, error) {
+	client, err := GetManagedInstancesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	instance, err := client.Get(context.Background(), resourceGroupName, managedInstanceName)
+	instance, err := client.Get(ctx, resourceGroupName, managedInstanceName)
 	if err != nil {
 		return nil, err
 	}
@@ -1137,26 +1677,40 @@ func GetManagedInstanceE(resourceGroupName string, managedInstanceName string) (
 
 // GetManagedInstancesClientE creates a ManagedInstancesClient
 func GetManagedInstancesClientE(subscriptionID string) (*sqlmi.ManagedInstancesClient, error) {
-	client := sqlmi.NewManagedInstancesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := sqlmi.NewManagedInstancesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*sqlmi.ManagedInstancesClient), nil
 }
 
 /********************************
 		Log Analytics Workspace
 *********************************/
 
+// ListLogAnalyticsWorkspacesByResourceGroup is the same as ListLogAnalyticsWorkspacesByResourceGroupE but fails the test on error
+func ListLogAnalyticsWorkspacesByResourceGroup(t *testing.T, resourceGroupName string, subscriptionID ...string) map[string]string {
+	t.Helper()
+	logAnalyticsWorkspacesByResourceGroup, err := ListLogAnalyticsWorkspacesByResourceGroupE(resourceGroupName, subscriptionID...)
+	failOnAzureError(t, err, "list", "Microsoft.OperationalInsights/workspaces", "", resourceGroupName)
+	return logAnalyticsWorkspacesByResourceGroup
+}
+
 // ListLogAnalyticsWorkspacesByResourceGroupE will return a map[string]string with Workspace IDs and Names and an error object
-func ListLogAnalyticsWorkspacesByResourceGroupE(resourceGroupName string) (map[string]string, error) {
-	client, err := GetLogAnalyticsWorkspacesClientE(os.Getenv(SubscriptionIDEnvName))
+func ListLogAnalyticsWorkspacesByResourceGroupE(resourceGroupName string, subscriptionID ...string) (map[string]string, error) {
+	return ListLogAnalyticsWorkspacesByResourceGroupWithContextE(context.Background(), resourceGroupName, subscriptionID...)
+}
+
+// ListLogAnalyticsWorkspacesByResourceGroupWithContextE will return a map[string]string with Workspace IDs and Names and an error object
+func ListLogAnalyticsWorkspacesByResourceGroupWithContextE(ctx context.Context, resourceGroupName string, subscriptionID ...string) (map[string]string, error) {
+	client, err := GetLogAnalyticsWorkspacesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.ListByResourceGroup(context.Background(), resourceGroupName)
+	result, err := client.ListByResourceGroup(ctx, resourceGroupName)
 	if err != nil {
 		return nil, err
 	}
@@ -1167,13 +1721,26 @@ func ListLogAnalyticsWorkspacesByResourceGroupE(resourceGroupName string) (map[s
 	return workspaces, nil
 }
 
+// GetLogAnalyticsWorkspace is the same as GetLogAnalyticsWorkspaceE but fails the test on error
+func GetLogAnalyticsWorkspace(t *testing.T, resourceGroupName string, workspaceName string, subscriptionID ...string) *operationalinsights.Workspace {
+	t.Helper()
+	logAnalyticsWorkspace, err := GetLogAnalyticsWorkspaceE(resourceGroupName, workspaceName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.OperationalInsights/workspaces", workspaceName, resourceGroupName)
+	return logAnalyticsWorkspace
+}
+
 // GetLogAnalyticsWorkspaceE will return Workspace object and an error object
-func GetLogAnalyticsWorkspaceE(resourceGroupName string, workspaceName string) (*operationalinsights.Workspace, error) {
-	client, err := GetLogAnalyticsWorkspacesClientE(os.Getenv(SubscriptionIDEnvName))
+func GetLogAnalyticsWorkspaceE(resourceGroupName string, workspaceName string, subscriptionID ...string) (*operationalinsights.Workspace, error) {
+	return GetLogAnalyticsWorkspaceWithContextE(context.Background(), resourceGroupName, workspaceName, subscriptionID...)
+}
+
+// GetLogAnalyticsWorkspaceWithContextE will return Workspace object and an error object
+func GetLogAnalyticsWorkspaceWithContextE(ctx context.Context, resourceGroupName string, workspaceName string, subscriptionID ...string) (*operationalinsights.Workspace, error) {
+	client, err := GetLogAnalyticsWorkspacesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	workspace, err := client.Get(context.Background(), resourceGroupName, workspaceName)
+	workspace, err := client.Get(ctx, resourceGroupName, workspaceName)
 	if err != nil {
 		return nil, err
 	}
@@ -1182,26 +1749,40 @@ func GetLogAnalyticsWorkspaceE(resourceGroupName string, workspaceName string) (
 
 // GetLogAnalyticsWorkspacesClientE creates a WorkspacesClient
 func GetLogAnalyticsWorkspacesClientE(subscriptionID string) (*operationalinsights.WorkspacesClient, error) {
-	client := operationalinsights.NewWorkspacesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := operationalinsights.NewWorkspacesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*operationalinsights.WorkspacesClient), nil
 }
 
 /********************************
 		Solutions (OperationsManagement)
 *********************************/
 
+// GetSolution is the same as GetSolutionE but fails the test on error
+func GetSolution(t *testing.T, resourceGroupName string, solutionName string, subscriptionID ...string) *operationsmanagement.Solution {
+	t.Helper()
+	solution, err := GetSolutionE(resourceGroupName, solutionName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.OperationsManagement/solutions", solutionName, resourceGroupName)
+	return solution
+}
+
 // GetSolutionE will return Solution object and an error object
-func GetSolutionE(resourceGroupName string, solutionName string) (*operationsmanagement.Solution, error) {
-	client, err := GetSolutionsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetSolutionE(resourceGroupName string, solutionName string, subscriptionID ...string) (*operationsmanagement.Solution, error) {
+	return GetSolutionWithContextE(context.Background(), resourceGroupName, solutionName, subscriptionID...)
+}
+
+// GetSolutionWithContextE will return Solution object and an error object
+func GetSolutionWithContextE(ctx context.Context, resourceGroupName string, solutionName string, subscriptionID ...string) (*operationsmanagement.Solution, error) {
+	client, err := GetSolutionsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	solution, err := client.Get(context.Background(), resourceGroupName, solutionName)
+	solution, err := client.Get(ctx, resourceGroupName, solutionName)
 	if err != nil {
 		return nil, err
 	}
@@ -1210,26 +1791,40 @@ func GetSolutionE(resourceGroupName string, solutionName string) (*operationsman
 
 // GetSolutionsClientE creates a SolutionsClient
 func GetSolutionsClientE(subscriptionID string) (*operationsmanagement.SolutionsClient, error) {
-	client := operationsmanagement.NewSolutionsClient(subscriptionID, "", "", "")
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := operationsmanagement.NewSolutionsClient(subscriptionID, "", "", "")
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*operationsmanagement.SolutionsClient), nil
 }
 
 /********************************
 		Azure Firewall
 *********************************/
 
+// GetFirewall is the same as GetFirewallE but fails the test on error
+func GetFirewall(t *testing.T, resourceGroupName string, firewallName string, subscriptionID ...string) *network.AzureFirewall {
+	t.Helper()
+	firewall, err := GetFirewallE(resourceGroupName, firewallName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/azureFirewalls", firewallName, resourceGroupName)
+	return firewall
+}
+
 // GetFirewallE will return Firewall object and an error object
-func GetFirewallE(resourceGroupName string, firewallName string) (*network.AzureFirewall, error) {
-	client, err := GetAzureFirewallsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetFirewallE(resourceGroupName string, firewallName string, subscriptionID ...string) (*network.AzureFirewall, error) {
+	return GetFirewallWithContextE(context.Background(), resourceGroupName, firewallName, subscriptionID...)
+}
+
+// GetFirewallWithContextE will return Firewall object and an error object
+func GetFirewallWithContextE(ctx context.Context, resourceGroupName string, firewallName string, subscriptionID ...string) (*network.AzureFirewall, error) {
+	client, err := GetAzureFirewallsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	firewall, err := client.Get(context.Background(), resourceGroupName, firewallName)
+	firewall, err := client.Get(ctx, resourceGroupName, firewallName)
 	if err != nil {
 		return nil, err
 	}
@@ -1238,26 +1833,40 @@ func GetFirewallE(resourceGroupName string, firewallName string) (*network.Azure
 
 // GetAzureFirewallsClientE creates a FirewallsClient
 func GetAzureFirewallsClientE(subscriptionID string) (*network.AzureFirewallsClient, error) {
-	client := network.NewAzureFirewallsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewAzureFirewallsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*network.AzureFirewallsClient), nil
 }
 
 /********************************
 		DDOS Plan
 *********************************/
 
+// GetDdosProtectionPlan is the same as GetDdosProtectionPlanE but fails the test on error
+func GetDdosProtectionPlan(t *testing.T, resourceGroupName string, planName string, subscriptionID ...string) *network.DdosProtectionPlan {
+	t.Helper()
+	ddosProtectionPlan, err := GetDdosProtectionPlanE(resourceGroupName, planName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/ddosProtectionPlans", planName, resourceGroupName)
+	return ddosProtectionPlan
+}
+
 // GetDdosProtectionPlanE will return DdosProtectionPlan object and an error object
-func GetDdosProtectionPlanE(resourceGroupName string, planName string) (*network.DdosProtectionPlan, error) {
-	client, err := GetDdosProtectionPlansClientE(os.Getenv(SubscriptionIDEnvName))
+func GetDdosProtectionPlanE(resourceGroupName string, planName string, subscriptionID ...string) (*network.DdosProtectionPlan, error) {
+	return GetDdosProtectionPlanWithContextE(context.Background(), resourceGroupName, planName, subscriptionID...)
+}
+
+// GetDdosProtectionPlanWithContextE will return DdosProtectionPlan object and an error object
+func GetDdosProtectionPlanWithContextE(ctx context.Context, resourceGroupName string, planName string, subscriptionID ...string) (*network.DdosProtectionPlan, error) {
+	client, err := GetDdosProtectionPlansClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	ddos, err := client.Get(context.Background(), resourceGroupName, planName)
+	ddos, err := client.Get(ctx, resourceGroupName, planName)
 	if err != nil {
 		return nil, err
 	}
@@ -1266,26 +1875,40 @@ func GetDdosProtectionPlanE(resourceGroupName string, planName string) (*network
 
 // GetDdosProtectionPlansClientE creates a DdosProtectionPlansClient
 func GetDdosProtectionPlansClientE(subscriptionID string) (*network.DdosProtectionPlansClient, error) {
-	client := network.NewDdosProtectionPlansClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewDdosProtectionPlansClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*network.DdosProtectionPlansClient), nil
 }
 
 /********************************
 		Recovery Services Vault
 *********************************/
 
+// GetRecoveryServicesVault is the same as GetRecoveryServicesVaultE but fails the test on error
+func GetRecoveryServicesVault(t *testing.T, resourceGroupName string, vaultName string, subscriptionID ...string) *recoveryservices.Vault {
+	t.Helper()
+	recoveryServicesVault, err := GetRecoveryServicesVaultE(resourceGroupName, vaultName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.RecoveryServices/vaults", vaultName, resourceGroupName)
+	return recoveryServicesVault
+}
+
 // GetRecoveryServicesVaultE will return Vault object and an error object
-func GetRecoveryServicesVaultE(resourceGroupName string, vaultName string) (*recoveryservices.Vault, error) {
-	client, err := GetVaultsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetRecoveryServicesVaultE(resourceGroupName string, vaultName string, subscriptionID ...string) (*recoveryservices.Vault, error) {
+	return GetRecoveryServicesVaultWithContextE(context.Background(), resourceGroupName, vaultName, subscriptionID...)
+}
+
+// GetRecoveryServicesVaultWithContextE will return Vault object and an error object
+func GetRecoveryServicesVaultWithContextE(ctx context.Context, resourceGroupName string, vaultName string, subscriptionID ...string) (*recoveryservices.Vault, error) {
+	client, err := GetVaultsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, vaultName)
+	result, err := client.Get(ctx, resourceGroupName, vaultName)
 	if err != nil {
 		return nil, err
 	}
@@ -1294,26 +1917,40 @@ func GetRecoveryServicesVaultE(resourceGroupName string, vaultName string) (*rec
 
 // GetVaultsClientE creates a VaultsClient
 func GetVaultsClientE(subscriptionID string) (*recoveryservices.VaultsClient, error) {
-	client := recoveryservices.NewVaultsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := recoveryservices.NewVaultsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*recoveryservices.VaultsClient), nil
 }
 
 /********************************
 		Backup Policies
 *********************************/
 
+// ListBackupPolicies is the same as ListBackupPoliciesE but fails the test on error
+func ListBackupPolicies(t *testing.T, resourceGroupName string, vaultName string, subscriptionID ...string) []backup.ProtectionPolicyResource {
+	t.Helper()
+	backupPolicies, err := ListBackupPoliciesE(resourceGroupName, vaultName, subscriptionID...)
+	failOnAzureError(t, err, "list backup policies of", "Microsoft.RecoveryServices/vaults", vaultName, resourceGroupName)
+	return backupPolicies
+}
+
 // ListBackupPoliciesE will return list of backup policies associated with Recovery Services Vault.
-func ListBackupPoliciesE(resourceGroupName string, vaultName string) ([]backup.ProtectionPolicyResource, error) {
-	client, err := GetPoliciesClientE(os.Getenv(SubscriptionIDEnvName))
+func ListBackupPoliciesE(resourceGroupName string, vaultName string, subscriptionID ...string) ([]backup.ProtectionPolicyResource, error) {
+	return ListBackupPoliciesWithContextE(context.Background(), resourceGroupName, vaultName, subscriptionID...)
+}
+
+// ListBackupPoliciesWithContextE will return list of backup policies associated with Recovery Services Vault.
+func ListBackupPoliciesWithContextE(ctx context.Context, resourceGroupName string, vaultName string, subscriptionID ...string) ([]backup.ProtectionPolicyResource, error) {
+	client, err := GetPoliciesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.List(context.Background(), vaultName, resourceGroupName, "")
+	result, err := client.List(ctx, vaultName, resourceGroupName, "")
 	if err != nil {
 		return nil, err
 	}
@@ -1323,26 +1960,40 @@ func ListBackupPoliciesE(resourceGroupName string, vaultName string) ([]backup.P
 
 // GetPoliciesClientE creates a PoliciesClient
 func GetPoliciesClientE(subscriptionID string) (*backup.PoliciesClient, error) {
-	client := backup.NewPoliciesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := backup.NewPoliciesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*backup.PoliciesClient), nil
 }
 
 /********************************
 		Protected Items
 *********************************/
 
+// GetProtectedItem is the same as GetProtectedItemE but fails the test on error
+func GetProtectedItem(t *testing.T, resourceGroupName string, vaultName string, fabricName string, containerName string, protectedItemName string, subscriptionID ...string) *backup.ProtectedItemResource {
+	t.Helper()
+	protectedItem, err := GetProtectedItemE(resourceGroupName, vaultName, fabricName, containerName, protectedItemName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.RecoveryServices/vaults/backupFabrics/protectionContainers/protectedItems", protectedItemName, resourceGroupName)
+	return protectedItem
+}
+
 // GetProtectedItemE will return ProtectedItemsResource object and an error object
-func GetProtectedItemE(resourceGroupName string, vaultName string, fabricName string, containerName string, protectedItemName string) (*backup.ProtectedItemResource, error) {
-	client, err := GetProtectedItemsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetProtectedItemE(resourceGroupName string, vaultName string, fabricName string, containerName string, protectedItemName string, subscriptionID ...string) (*backup.ProtectedItemResource, error) {
+	return GetProtectedItemWithContextE(context.Background(), resourceGroupName, vaultName, fabricName, containerName, protectedItemName, subscriptionID...)
+}
+
+// GetProtectedItemWithContextE will return ProtectedItemsResource object and an error object
+func GetProtectedItemWithContextE(ctx context.Context, resourceGroupName string, vaultName string, fabricName string, containerName string, protectedItemName string, subscriptionID ...string) (*backup.ProtectedItemResource, error) {
+	client, err := GetProtectedItemsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), vaultName, resourceGroupName, fabricName, containerName, protectedItemName, "")
+	result, err := client.Get(ctx, vaultName, resourceGroupName, fabricName, containerName, protectedItemName, "")
 	if err != nil {
 		return nil, err
 	}
@@ -1351,39 +2002,66 @@ func GetProtectedItemE(resourceGroupName string, vaultName string, fabricName st
 
 // GetProtectedItemsClientE creates a ProtectedItemsClient
 func GetProtectedItemsClientE(subscriptionID string) (*backup.ProtectedItemsClient, error) {
-	client := backup.NewProtectedItemsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := backup.NewProtectedItemsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*backup.ProtectedItemsClient), nil
 }
 
 /********************************
 		Redis Cache
 *********************************/
 
+// GetRedis is the same as GetRedisE but fails the test on error
+func GetRedis(t *testing.T, resourceGroupName string, redisName string, subscriptionID ...string) *redis.ResourceType {
+	t.Helper()
+	redis, err := GetRedisE(resourceGroupName, redisName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Cache/redis", redisName, resourceGroupName)
+	return redis
+}
+
 // GetRedisE will return redis.ResourceStype object and an error object
-func GetRedisE(resourceGroupName string, redisName string) (*redis.ResourceType, error) {
-	client, err := GetRedisClientE(os.Getenv(SubscriptionIDEnvName))
+func GetRedisE(resourceGroupName string, redisName string, subscriptionID ...string) (*redis.ResourceType, error) {
+	return GetRedisWithContextE(context.Background(), resourceGroupName, redisName, subscriptionID...)
+}
+
+// GetRedisWithContextE will return redis.ResourceStype object and an error object
+func GetRedisWithContextE(ctx context.Context, resourceGroupName string, redisName string, subscriptionID ...string) (*redis.ResourceType, error) {
+	client, err := GetRedisClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, redisName)
+	result, err := client.Get(ctx, resourceGroupName, redisName)
 	if err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
+// GetRedisAccessKeys is the same as GetRedisAccessKeysE but fails the test on error
+func GetRedisAccessKeys(t *testing.T, resourceGroupName string, redisName string, subscriptionID ...string) *redis.AccessKeys {
+	t.Helper()
+	redisAccessKeys, err := GetRedisAccessKeysE(resourceGroupName, redisName, subscriptionID...)
+	failOnAzureError(t, err, "get access keys of", "Microsoft.Cache/redis", redisName, resourceGroupName)
+	return redisAccessKeys
+}
+
 // GetRedisAccessKeysE will return redis.AccessKeys object and an error object
-func GetRedisAccessKeysE(resourceGroupName string, redisName string) (*redis.AccessKeys, error) {
-	client, err := GetRedisClientE(os.Getenv(SubscriptionIDEnvName))
+func GetRedisAccessKeysE(resourceGroupName string, redisName string, subscriptionID ...string) (*redis.AccessKeys, error) {
+	return GetRedisAccessKeysWithContextE(context.Background(), resourceGroupName, redisName, subscriptionID...)
+}
+
+// GetRedisAccessKeysWithContextE will return redis.AccessKeys object and an error object
+func GetRedisAccessKeysWithContextE(ctx context.Context, resourceGroupName string, redisName string, subscriptionID ...string) (*redis.AccessKeys, error) {
+	client, err := GetRedisClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.ListKeys(context.Background(), resourceGroupName, redisName)
+	result, err := client.ListKeys(ctx, resourceGroupName, redisName)
 	if err != nil {
 		return nil, err
 	}
@@ -1392,26 +2070,40 @@ func GetRedisAccessKeysE(resourceGroupName string, redisName string) (*redis.Acc
 
 // GetRedisClientE creates a redis.Client object
 func GetRedisClientE(subscriptionID string) (*redis.Client, error) {
-	client := redis.NewClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := redis.NewClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*redis.Client), nil
 }
 
 /********************************
 		Virtual Network Gateway
 *********************************/
 
+// GetVirtualNetworkGateway is the same as GetVirtualNetworkGatewayE but fails the test on error
+func GetVirtualNetworkGateway(t *testing.T, resourceGroupName string, virtualNetworkGatewayName string, subscriptionID ...string) *network.VirtualNetworkGateway {
+	t.Helper()
+	virtualNetworkGateway, err := GetVirtualNetworkGatewayE(resourceGroupName, virtualNetworkGatewayName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/virtualNetworkGateways", virtualNetworkGatewayName, resourceGroupName)
+	return virtualNetworkGateway
+}
+
 // GetVirtualNetworkGatewayE will return redis.VirtualNetworkGateway object and an error object
-func GetVirtualNetworkGatewayE(resourceGroupName string, virtualNetworkGatewayName string) (*network.VirtualNetworkGateway, error) {
-	client, err := GetVirtualNetworkGatewaysClientE(os.Getenv(SubscriptionIDEnvName))
+func GetVirtualNetworkGatewayE(resourceGroupName string, virtualNetworkGatewayName string, subscriptionID ...string) (*network.VirtualNetworkGateway, error) {
+	return GetVirtualNetworkGatewayWithContextE(context.Background(), resourceGroupName, virtualNetworkGatewayName, subscriptionID...)
+}
+
+// GetVirtualNetworkGatewayWithContextE will return redis.VirtualNetworkGateway object and an error object
+func GetVirtualNetworkGatewayWithContextE(ctx context.Context, resourceGroupName string, virtualNetworkGatewayName string, subscriptionID ...string) (*network.VirtualNetworkGateway, error) {
+	client, err := GetVirtualNetworkGatewaysClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, virtualNetworkGatewayName)
+	result, err := client.Get(ctx, resourceGroupName, virtualNetworkGatewayName)
 	if err != nil {
 		return nil, err
 	}
@@ -1420,39 +2112,66 @@ func GetVirtualNetworkGatewayE(resourceGroupName string, virtualNetworkGatewayNa
 
 // GetVirtualNetworkGatewaysClientE creates a network.VirtualNetworksClient object
 func GetVirtualNetworkGatewaysClientE(subscriptionID string) (*network.VirtualNetworkGatewaysClient, error) {
-	client := network.NewVirtualNetworkGatewaysClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewVirtualNetworkGatewaysClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*network.VirtualNetworkGatewaysClient), nil
 }
 
 /********************************
 		Diagnostic Settings
 *********************************/
 
+// GetDiagnosticSettings is the same as GetDiagnosticSettingsE but fails the test on error
+func GetDiagnosticSettings(t *testing.T, resourceURI string, name string, subscriptionID ...string) *insights.DiagnosticSettings {
+	t.Helper()
+	diagnosticSettings, err := GetDiagnosticSettingsE(resourceURI, name, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Insights/diagnosticSettings", name, "")
+	return diagnosticSettings
+}
+
 // GetDiagnosticSettingsE will return insights.DiagnosticSettings object and an error object
-func GetDiagnosticSettingsE(resourceURI string, name string) (*insights.DiagnosticSettings, error) {
-	client, err := GetDiagnosticSettingsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetDiagnosticSettingsE(resourceURI string, name string, subscriptionID ...string) (*insights.DiagnosticSettings, error) {
+	return GetDiagnosticSettingsWithContextE(context.Background(), resourceURI, name, subscriptionID...)
+}
+
+// GetDiagnosticSettingsWithContextE will return insights.DiagnosticSettings object and an error object
+func GetDiagnosticSettingsWithContextE(ctx context.Context, resourceURI string, name string, subscriptionID ...string) (*insights.DiagnosticSettings, error) {
+	client, err := GetDiagnosticSettingsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceURI, name)
+	result, err := client.Get(ctx, resourceURI, name)
 	if err != nil {
 		return nil, err
 	}
 	return result.DiagnosticSettings, nil
 }
 
+// ListDiagnosticSettings is the same as ListDiagnosticSettingsE but fails the test on error
+func ListDiagnosticSettings(t *testing.T, resourceURI string, subscriptionID ...string) *[]insights.DiagnosticSettingsResource {
+	t.Helper()
+	diagnosticSettings, err := ListDiagnosticSettingsE(resourceURI, subscriptionID...)
+	failOnAzureError(t, err, "list diagnostic settings of", "", resourceURI, "")
+	return diagnosticSettings
+}
+
 // ListDiagnosticSettingsE will return a list of insights.DiagnosticSettingsResource object and an error object
-func ListDiagnosticSettingsE(resourceURI string) (*[]insights.DiagnosticSettingsResource, error) {
-	client, err := GetDiagnosticSettingsClientE(os.Getenv(SubscriptionIDEnvName))
+func ListDiagnosticSettingsE(resourceURI string, subscriptionID ...string) (*[]insights.DiagnosticSettingsResource, error) {
+	return ListDiagnosticSettingsWithContextE(context.Background(), resourceURI, subscriptionID...)
+}
+
+// ListDiagnosticSettingsWithContextE will return a list of insights.DiagnosticSettingsResource object and an error object
+func ListDiagnosticSettingsWithContextE(ctx context.Context, resourceURI string, subscriptionID ...string) (*[]insights.DiagnosticSettingsResource, error) {
+	client, err := GetDiagnosticSettingsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.List(context.Background(), resourceURI)
+	result, err := client.List(ctx, resourceURI)
 	if err != nil {
 		return nil, err
 	}
@@ -1461,26 +2180,40 @@ func ListDiagnosticSettingsE(resourceURI string) (*[]insights.DiagnosticSettings
 
 // GetDiagnosticSettingsClientE creates a insights.DiagnosticSettingsClient  object
 func GetDiagnosticSettingsClientE(subscriptionID string) (*insights.DiagnosticSettingsClient, error) {
-	client := insights.NewDiagnosticSettingsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := insights.NewDiagnosticSettingsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*insights.DiagnosticSettingsClient), nil
 }
 
 /********************************
 		MySql Server
 *********************************/
 
+// GetMySQLServer is the same as GetMySQLServerE but fails the test on error
+func GetMySQLServer(t *testing.T, resourceGroupName string, serverName string, subscriptionID ...string) *mysql.Server {
+	t.Helper()
+	mySQLServer, err := GetMySQLServerE(resourceGroupName, serverName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.DBforMySQL/servers", serverName, resourceGroupName)
+	return mySQLServer
+}
+
 // GetMySQLServerE will return insights.DiagnosticSettings object and an error object
-func GetMySQLServerE(resourceGroupName string, serverName string) (*mysql.Server, error) {
-	client, err := GetMySQLServersClientE(os.Getenv(SubscriptionIDEnvName))
+func GetMySQLServerE(resourceGroupName string, serverName string, subscriptionID ...string) (*mysql.Server, error) {
+	return GetMySQLServerWithContextE(context.Background(), resourceGroupName, serverName, subscriptionID...)
+}
+
+// GetMySQLServerWithContextE will return insights.DiagnosticSettings object and an error object
+func GetMySQLServerWithContextE(ctx context.Context, resourceGroupName string, serverName string, subscriptionID ...string) (*mysql.Server, error) {
+	client, err := GetMySQLServersClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, serverName)
+	result, err := client.Get(ctx, resourceGroupName, serverName)
 	if err != nil {
 		return nil, err
 	}
@@ -1489,26 +2222,40 @@ func GetMySQLServerE(resourceGroupName string, serverName string) (*mysql.Server
 
 // GetMySQLServersClientE creates a mysql.ServersClient  object
 func GetMySQLServersClientE(subscriptionID string) (*mysql.ServersClient, error) {
-	client := mysql.NewServersClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := mysql.NewServersClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*mysql.ServersClient), nil
 }
 
 /********************************
 		MySql Databases
 *********************************/
 
+// GetMySQLDatabase is the same as GetMySQLDatabaseE but fails the test on error
+func GetMySQLDatabase(t *testing.T, resourceGroupName string, serverName string, databaseName string, subscriptionID ...string) *mysql.Database {
+	t.Helper()
+	mySQLDatabase, err := GetMySQLDatabaseE(resourceGroupName, serverName, databaseName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.DBforMySQL/servers/databases", databaseName, resourceGroupName)
+	return mySQLDatabase
+}
+
 // GetMySQLDatabaseE will return insights.DiagnosticSettings object and an error object
-func GetMySQLDatabaseE(resourceGroupName string, serverName string, databaseName string) (*mysql.Database, error) {
-	client, err := GetMySQLDatabasesClientE(os.Getenv(SubscriptionIDEnvName))
+func GetMySQLDatabaseE(resourceGroupName string, serverName string, databaseName string, subscriptionID ...string) (*mysql.Database, error) {
+	return GetMySQLDatabaseWithContextE(context.Background(), resourceGroupName, serverName, databaseName, subscriptionID...)
+}
+
+// GetMySQLDatabaseWithContextE will return insights.DiagnosticSettings object and an error object
+func GetMySQLDatabaseWithContextE(ctx context.Context, resourceGroupName string, serverName string, databaseName string, subscriptionID ...string) (*mysql.Database, error) {
+	client, err := GetMySQLDatabasesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, serverName, databaseName)
+	result, err := client.Get(ctx, resourceGroupName, serverName, databaseName)
 	if err != nil {
 		return nil, err
 	}
@@ -1517,26 +2264,40 @@ func GetMySQLDatabaseE(resourceGroupName string, serverName string, databaseName
 
 // GetMySQLDatabasesClientE creates a mysql.DatabasesClient  object
 func GetMySQLDatabasesClientE(subscriptionID string) (*mysql.DatabasesClient, error) {
-	client := mysql.NewDatabasesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := mysql.NewDatabasesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*mysql.DatabasesClient), nil
 }
 
 /********************************
 		MySql Server Configurations
 *********************************/
 
+// ListMySQLServerConfig is the same as ListMySQLServerConfigE but fails the test on error
+func ListMySQLServerConfig(t *testing.T, resourceGroupName string, serverName string, subscriptionID ...string) *[]mysql.Configuration {
+	t.Helper()
+	mySQLServerConfig, err := ListMySQLServerConfigE(resourceGroupName, serverName, subscriptionID...)
+	failOnAzureError(t, err, "list configurations of", "Microsoft.DBforMySQL/servers", serverName, resourceGroupName)
+	return mySQLServerConfig
+}
+
 // ListMySQLServerConfigE will return mysql.ConfigurationListResult object and an error object
-func ListMySQLServerConfigE(resourceGroupName string, serverName string) (*[]mysql.Configuration, error) {
-	client, err := GetMySQLConfigsClientE(os.Getenv(SubscriptionIDEnvName))
+func ListMySQLServerConfigE(resourceGroupName string, serverName string, subscriptionID ...string) (*[]mysql.Configuration, error) {
+	return ListMySQLServerConfigWithContextE(context.Background(), resourceGroupName, serverName, subscriptionID...)
+}
+
+// ListMySQLServerConfigWithContextE will return mysql.ConfigurationListResult object and an error object
+func ListMySQLServerConfigWithContextE(ctx context.Context, resourceGroupName string, serverName string, subscriptionID ...string) (*[]mysql.Configuration, error) {
+	client, err := GetMySQLConfigsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.ListByServer(context.Background(), resourceGroupName, serverName)
+	result, err := client.ListByServer(ctx, resourceGroupName, serverName)
 	if err != nil {
 		return nil, err
 	}
@@ -1545,26 +2306,40 @@ func ListMySQLServerConfigE(resourceGroupName string, serverName string) (*[]mys
 
 // GetMySQLConfigsClientE creates a mysql.ConfigurationsClient   object
 func GetMySQLConfigsClientE(subscriptionID string) (*mysql.ConfigurationsClient, error) {
-	client := mysql.NewConfigurationsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := mysql.NewConfigurationsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*mysql.ConfigurationsClient), nil
 }
 
 /********************************
 		MySql Server Configurations
 *********************************/
 
+// ListMySQLVirtualNetworkRules is the same as ListMySQLVirtualNetworkRulesE but fails the test on error
+func ListMySQLVirtualNetworkRules(t *testing.T, resourceGroupName string, serverName string, subscriptionID ...string) *[]mysql.VirtualNetworkRule {
+	t.Helper()
+	mySQLVirtualNetworkRules, err := ListMySQLVirtualNetworkRulesE(resourceGroupName, serverName, subscriptionID...)
+	failOnAzureError(t, err, "list virtual network rules of", "Microsoft.DBforMySQL/servers", serverName, resourceGroupName)
+	return mySQLVirtualNetworkRules
+}
+
 // ListMySQLVirtualNetworkRulesE will return mysql.ConfigurationListResult object and an error object
-func ListMySQLVirtualNetworkRulesE(resourceGroupName string, serverName string) (*[]mysql.VirtualNetworkRule, error) {
-	client, err := GetMySQLVirtualNetworkRulesClientE(os.Getenv(SubscriptionIDEnvName))
+func ListMySQLVirtualNetworkRulesE(resourceGroupName string, serverName string, subscriptionID ...string) (*[]mysql.VirtualNetworkRule, error) {
+	return ListMySQLVirtualNetworkRulesWithContextE(context.Background(), resourceGroupName, serverName, subscriptionID...)
+}
+
+// ListMySQLVirtualNetworkRulesWithContextE will return mysql.ConfigurationListResult object and an error object
+func ListMySQLVirtualNetworkRulesWithContextE(ctx context.Context, resourceGroupName string, serverName string, subscriptionID ...string) (*[]mysql.VirtualNetworkRule, error) {
+	client, err := GetMySQLVirtualNetworkRulesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.ListByServer(context.Background(), resourceGroupName, serverName)
+	result, err := client.ListByServer(ctx, resourceGroupName, serverName)
 	if err != nil {
 		return nil, err
 	}
@@ -1574,39 +2349,66 @@ func ListMySQLVirtualNetworkRulesE(resourceGroupName string, serverName string)
 
 // GetMySQLVirtualNetworkRulesClientE creates a mysql.ConfigurationsClient   object
 func GetMySQLVirtualNetworkRulesClientE(subscriptionID string) (*mysql.VirtualNetworkRulesClient, error) {
-	client := mysql.NewVirtualNetworkRulesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := mysql.NewVirtualNetworkRulesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*mysql.VirtualNetworkRulesClient), nil
 }
 
 /********************************
 		Cosmos Database Account
 *********************************/
 
+// GetCosmosDatabaseAccount is the same as GetCosmosDatabaseAccountE but fails the test on error
+func GetCosmosDatabaseAccount(t *testing.T, resourceGroupName string, accountName string, subscriptionID ...string) *documentdb.DatabaseAccountGetResults {
+	t.Helper()
+	cosmosDatabaseAccount, err := GetCosmosDatabaseAccountE(resourceGroupName, accountName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.DocumentDB/databaseAccounts", accountName, resourceGroupName)
+	return cosmosDatabaseAccount
+}
+
 // GetCosmosDatabaseAccountE will return documentdb.DatabaseAccountGetResults object and an error object
-func GetCosmosDatabaseAccountE(resourceGroupName string, accountName string) (*documentdb.DatabaseAccountGetResults, error) {
-	client, err := GetDatabaseAccountsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetCosmosDatabaseAccountE(resourceGroupName string, accountName string, subscriptionID ...string) (*documentdb.DatabaseAccountGetResults, error) {
+	return GetCosmosDatabaseAccountWithContextE(context.Background(), resourceGroupName, accountName, subscriptionID...)
+}
+
+// GetCosmosDatabaseAccountWithContextE will return documentdb.DatabaseAccountGetResults object and an error object
+func GetCosmosDatabaseAccountWithContextE(ctx context.Context, resourceGroupName string, accountName string, subscriptionID ...string) (*documentdb.DatabaseAccountGetResults, error) {
+	client, err := GetDatabaseAccountsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, accountName)
+	result, err := client.Get(ctx, resourceGroupName, accountName)
 	if err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
+// GetCosmosKeys is the same as GetCosmosKeysE but fails the test on error
+func GetCosmosKeys(t *testing.T, resourceGroupName string, accountName string, subscriptionID ...string) *documentdb.DatabaseAccountListKeysResult {
+	t.Helper()
+	cosmosKeys, err := GetCosmosKeysE(resourceGroupName, accountName, subscriptionID...)
+	failOnAzureError(t, err, "list keys of", "Microsoft.DocumentDB/databaseAccounts", accountName, resourceGroupName)
+	return cosmosKeys
+}
+
 // GetCosmosKeysE will return documentdb.DatabaseAccountGetResults object and an error object
-func GetCosmosKeysE(resourceGroupName string, accountName string) (*documentdb.DatabaseAccountListKeysResult, error) {
-	client, err := GetDatabaseAccountsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetCosmosKeysE(resourceGroupName string, accountName string, subscriptionID ...string) (*documentdb.DatabaseAccountListKeysResult, error) {
+	return GetCosmosKeysWithContextE(context.Background(), resourceGroupName, accountName, subscriptionID...)
+}
+
+// GetCosmosKeysWithContextE will return documentdb.DatabaseAccountGetResults object and an error object
+func GetCosmosKeysWithContextE(ctx context.Context, resourceGroupName string, accountName string, subscriptionID ...string) (*documentdb.DatabaseAccountListKeysResult, error) {
+	client, err := GetDatabaseAccountsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.ListKeys(context.Background(), resourceGroupName, accountName)
+	result, err := client.ListKeys(ctx, resourceGroupName, accountName)
 	if err != nil {
 		return nil, err
 	}
@@ -1615,26 +2417,40 @@ func GetCosmosKeysE(resourceGroupName string, accountName string) (*documentdb.D
 
 // GetDatabaseAccountsClientE creates a documentdb.DatabaseAccountsClient object
 func GetDatabaseAccountsClientE(subscriptionID string) (*documentdb.DatabaseAccountsClient, error) {
-	client := documentdb.NewDatabaseAccountsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := documentdb.NewDatabaseAccountsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*documentdb.DatabaseAccountsClient), nil
 }
 
 /********************************
 		Cassandra Resources
 *********************************/
 
+// GetCassandraKeySpace is the same as GetCassandraKeySpaceE but fails the test on error
+func GetCassandraKeySpace(t *testing.T, resourceGroupName string, accountName string, keySpaceName string, subscriptionID ...string) *documentdb.CassandraKeyspaceGetResults {
+	t.Helper()
+	cassandraKeySpace, err := GetCassandraKeySpaceE(resourceGroupName, accountName, keySpaceName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.DocumentDB/databaseAccounts/cassandraKeyspaces", keySpaceName, resourceGroupName)
+	return cassandraKeySpace
+}
+
 // GetCassandraKeySpaceE will return documentdb.CassandraKeyspaceGetResults object and an error object
-func GetCassandraKeySpaceE(resourceGroupName string, accountName string, keySpaceName string) (*documentdb.CassandraKeyspaceGetResults, error) {
-	client, err := GetCassandraResourcesClientE(os.Getenv(SubscriptionIDEnvName))
+func GetCassandraKeySpaceE(resourceGroupName string, accountName string, keySpaceName string, subscriptionID ...string) (*documentdb.CassandraKeyspaceGetResults, error) {
+	return GetCassandraKeySpaceWithContextE(context.Background(), resourceGroupName, accountName, keySpaceName, subscriptionID...)
+}
+
+// GetCassandraKeySpaceWithContextE will return documentdb.CassandraKeyspaceGetResults object and an error object
+func GetCassandraKeySpaceWithContextE(ctx context.Context, resourceGroupName string, accountName string, keySpaceName string, subscriptionID ...string) (*documentdb.CassandraKeyspaceGetResults, error) {
+	client, err := GetCassandraResourcesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.GetCassandraKeyspace(context.Background(), resourceGroupName, accountName, keySpaceName)
+	result, err := client.GetCassandraKeyspace(ctx, resourceGroupName, accountName, keySpaceName)
 	if err != nil {
 		return nil, err
 	}
@@ -1643,26 +2459,40 @@ func GetCassandraKeySpaceE(resourceGroupName string, accountName string, keySpac
 
 // GetCassandraResourcesClientE creates a documentdb.CassandraResourcesClient  object
 func GetCassandraResourcesClientE(subscriptionID string) (*documentdb.CassandraResourcesClient, error) {
-	client := documentdb.NewCassandraResourcesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := documentdb.NewCassandraResourcesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*documentdb.CassandraResourcesClient), nil
 }
 
 /********************************
 		Event Hub Namespace
 *********************************/
 
+// GetEventHubNamespace is the same as GetEventHubNamespaceE but fails the test on error
+func GetEventHubNamespace(t *testing.T, resourceGroupName string, namespaceName string, subscriptionID ...string) *eventhub.EHNamespace {
+	t.Helper()
+	eventHubNamespace, err := GetEventHubNamespaceE(resourceGroupName, namespaceName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.EventHub/namespaces", namespaceName, resourceGroupName)
+	return eventHubNamespace
+}
+
 // GetEventHubNamespaceE will return documentdb.EHNamespace object and an error object
-func GetEventHubNamespaceE(resourceGroupName string, namespaceName string) (*eventhub.EHNamespace, error) {
-	client, err := GetEventHubNamespacesClientE(os.Getenv(SubscriptionIDEnvName))
+func GetEventHubNamespaceE(resourceGroupName string, namespaceName string, subscriptionID ...string) (*eventhub.EHNamespace, error) {
+	return GetEventHubNamespaceWithContextE(context.Background(), resourceGroupName, namespaceName, subscriptionID...)
+}
+
+// GetEventHubNamespaceWithContextE will return documentdb.EHNamespace object and an error object
+func GetEventHubNamespaceWithContextE(ctx context.Context, resourceGroupName string, namespaceName string, subscriptionID ...string) (*eventhub.EHNamespace, error) {
+	client, err := GetEventHubNamespacesClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, namespaceName)
+	result, err := client.Get(ctx, resourceGroupName, namespaceName)
 	if err != nil {
 		return nil, err
 	}
@@ -1671,26 +2501,40 @@ func GetEventHubNamespaceE(resourceGroupName string, namespaceName string) (*eve
 
 // GetEventHubNamespacesClientE creates a mysql.ServersClient  object
 func GetEventHubNamespacesClientE(subscriptionID string) (*eventhub.NamespacesClient, error) {
-	client := eventhub.NewNamespacesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := eventhub.NewNamespacesClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*eventhub.NamespacesClient), nil
 }
 
 /********************************
 		Event Hub
 *********************************/
 
+// GetEventHub is the same as GetEventHubE but fails the test on error
+func GetEventHub(t *testing.T, resourceGroupName string, namespaceName string, eventHubName string, subscriptionID ...string) *eventhub.Model {
+	t.Helper()
+	eventHub, err := GetEventHubE(resourceGroupName, namespaceName, eventHubName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.EventHub/namespaces/eventhubs", eventHubName, resourceGroupName)
+	return eventHub
+}
+
 // GetEventHubE will return documentdb.EHNamespace object and an error object
-func GetEventHubE(resourceGroupName string, namespaceName string, eventHubName string) (*eventhub.Model, error) {
-	client, err := GetEventHubsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetEventHubE(resourceGroupName string, namespaceName string, eventHubName string, subscriptionID ...string) (*eventhub.Model, error) {
+	return GetEventHubWithContextE(context.Background(), resourceGroupName, namespaceName, eventHubName, subscriptionID...)
+}
+
+// GetEventHubWithContextE will return documentdb.EHNamespace object and an error object
+func GetEventHubWithContextE(ctx context.Context, resourceGroupName string, namespaceName string, eventHubName string, subscriptionID ...string) (*eventhub.Model, error) {
+	client, err := GetEventHubsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, namespaceName, eventHubName)
+	result, err := client.Get(ctx, resourceGroupName, namespaceName, eventHubName)
 	if err != nil {
 		return nil, err
 	}
@@ -1699,26 +2543,40 @@ func GetEventHubE(resourceGroupName string, namespaceName string, eventHubName s
 
 // GetEventHubsClientE creates a mysql.ServersClient  object
 func GetEventHubsClientE(subscriptionID string) (*eventhub.EventHubsClient, error) {
-	client := eventhub.NewEventHubsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := eventhub.NewEventHubsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*eventhub.EventHubsClient), nil
 }
 
 /********************************
 		App Service Plans
 *********************************/
 
+// GetAppServicePlan is the same as GetAppServicePlanE but fails the test on error
+func GetAppServicePlan(t *testing.T, resourceGroupName string, name string, subscriptionID ...string) *web.AppServicePlan {
+	t.Helper()
+	appServicePlan, err := GetAppServicePlanE(resourceGroupName, name, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Web/serverfarms", name, resourceGroupName)
+	return appServicePlan
+}
+
 // GetAppServicePlanE will return web.AppServicePlan object and an error object
-func GetAppServicePlanE(resourceGroupName string, name string) (*web.AppServicePlan, error) {
-	client, err := GetAppServicePlansClientE(os.Getenv(SubscriptionIDEnvName))
+func GetAppServicePlanE(resourceGroupName string, name string, subscriptionID ...string) (*web.AppServicePlan, error) {
+	return GetAppServicePlanWithContextE(context.Background(), resourceGroupName, name, subscriptionID...)
+}
+
+// GetAppServicePlanWithContextE will return web.AppServicePlan object and an error object
+func GetAppServicePlanWithContextE(ctx context.Context, resourceGroupName string, name string, subscriptionID ...string) (*web.AppServicePlan, error) {
+	client, err := GetAppServicePlansClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, name)
+	result, err := client.Get(ctx, resourceGroupName, name)
 	if err != nil {
 		return nil, err
 	}
@@ -1727,52 +2585,92 @@ func GetAppServicePlanE(resourceGroupName string, name string) (*web.AppServiceP
 
 // GetAppServicePlansClientE creates a mysql.ServersClient  object
 func GetAppServicePlansClientE(subscriptionID string) (*web.AppServicePlansClient, error) {
-	client := web.NewAppServicePlansClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := web.NewAppServicePlansClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*web.AppServicePlansClient), nil
 }
 
 /********************************
 		Web Apps
 *********************************/
 
+// GetSite is the same as GetSiteE but fails the test on error
+func GetSite(t *testing.T, resourceGroupName string, name string, subscriptionID ...string) *web.Site {
+	t.Helper()
+	site, err := GetSiteE(resourceGroupName, name, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Web/sites", name, resourceGroupName)
+	return site
+}
+
 // GetSiteE will return web.Site object and an error object
-func GetSiteE(resourceGroupName string, name string) (*web.Site, error) {
-	client, err := GetAppsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetSiteE(resourceGroupName string, name string, subscriptionID ...string) (*web.Site, error) {
+	return GetSiteWithContextE(context.Background(), resourceGroupName, name, subscriptionID...)
+}
+
+// GetSiteWithContextE will return web.Site object and an error object
+func GetSiteWithContextE(ctx context.Context, resourceGroupName string, name string, subscriptionID ...string) (*web.Site, error) {
+	client, err := GetAppsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, name)
+	result, err := client.Get(ctx, resourceGroupName, name)
 	if err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
+// ListApplicationSettings is the same as ListApplicationSettingsE but fails the test on error
+func ListApplicationSettings(t *testing.T, resourceGroupName string, name string, subscriptionID ...string) *web.StringDictionary {
+	t.Helper()
+	applicationSettings, err := ListApplicationSettingsE(resourceGroupName, name, subscriptionID...)
+	failOnAzureError(t, err, "list application settings of", "Microsoft.Web/sites", name, resourceGroupName)
+	return applicationSettings
+}
+
 // ListApplicationSettingsE will return web.StringDictionary
-func ListApplicationSettingsE(resourceGroupName string, name string) (*web.StringDictionary, error) {
-	client, err := GetAppsClientE(os.Getenv(SubscriptionIDEnvName))
+func ListApplicationSettingsE(resourceGroupName string, name string, subscriptionID ...string) (*web.StringDictionary, error) {
+	return ListApplicationSettingsWithContextE(context.Background(), resourceGroupName, name, subscriptionID...)
+}
+
+// ListApplicationSettingsWithContextE will return web.StringDictionary
+func ListApplicationSettingsWithContextE(ctx context.Context, resourceGroupName string, name string, subscriptionID ...string) (*web.StringDictionary, error) {
+	client, err := GetAppsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.ListApplicationSettings(context.Background(), resourceGroupName, name)
+	result, err := client.ListApplicationSettings(ctx, resourceGroupName, name)
 	if err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
+// ListSiteConfigurations is the same as ListSiteConfigurationsE but fails the test on error
+func ListSiteConfigurations(t *testing.T, resourceGroupName string, name string, subscriptionID ...string) []web.SiteConfigResource {
+	t.Helper()
+	siteConfigurations, err := ListSiteConfigurationsE(resourceGroupName, name, subscriptionID...)
+	failOnAzureError(t, err, "list configurations of", "Microsoft.Web/sites", name, resourceGroupName)
+	return siteConfigurations
+}
+
 // ListFunctionSettingsE will return an array of web.SiteConfigResource
-func ListSiteConfigurationsE(resourceGroupName string, name string) ([]web.SiteConfigResource, error) {
-	client, err := GetAppsClientE(os.Getenv(SubscriptionIDEnvName))
+func ListSiteConfigurationsE(resourceGroupName string, name string, subscriptionID ...string) ([]web.SiteConfigResource, error) {
+	return ListSiteConfigurationsWithContextE(context.Background(), resourceGroupName, name, subscriptionID...)
+}
+
+// ListSiteConfigurationsWithContextE will return an array of web.SiteConfigResource
+func ListSiteConfigurationsWithContextE(ctx context.Context, resourceGroupName string, name string, subscriptionID ...string) ([]web.SiteConfigResource, error) {
+	client, err := GetAppsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.ListConfigurations(context.Background(), resourceGroupName, name)
+	result, err := client.ListConfigurations(ctx, resourceGroupName, name)
 	if err != nil {
 		return nil, err
 	}
@@ -1780,26 +2678,52 @@ func ListSiteConfigurationsE(resourceGroupName string, name string) ([]web.SiteC
 	return result.Values(), nil
 }
 
+// GetFunction is the same as GetFunctionE but fails the test on error
+func GetFunction(t *testing.T, resourceGroupName string, name string, functionName string, subscriptionID ...string) *web.FunctionEnvelope {
+	t.Helper()
+	function, err := GetFunctionE(resourceGroupName, name, functionName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Web/sites/functions", functionName, resourceGroupName)
+	return function
+}
+
 // GetFunctionE will return web.AppServicePlan object and an error object
-func GetFunctionE(resourceGroupName string, name string, functionName string) (*web.FunctionEnvelope, error) {
-	client, err := GetAppsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetFunctionE(resourceGroupName string, name string, functionName string, subscriptionID ...string) (*web.FunctionEnvelope, error) {
+	return GetFunctionWithContextE(context.Background(), resourceGroupName, name, functionName, subscriptionID...)
+}
+
+// GetFunctionWithContextE will return web.AppServicePlan object and an error object
+func GetFunctionWithContextE(ctx context.Context, resourceGroupName string, name string, functionName string, subscriptionID ...string) (*web.FunctionEnvelope, error) {
+	client, err := GetAppsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.GetFunction(context.Background(), resourceGroupName, name, functionName)
+	result, err := client.GetFunction(ctx, resourceGroupName, name, functionName)
 	if err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
+// GetSwiftVirtualNetworkConnection is the same as GetSwiftVirtualNetworkConnectionE but fails the test on error
+func GetSwiftVirtualNetworkConnection(t *testing.T, resourceGroupName string, name string, subscriptionID ...string) *web.SwiftVirtualNetwork {
+	t.Helper()
+	swiftVirtualNetworkConnection, err := GetSwiftVirtualNetworkConnectionE(resourceGroupName, name, subscriptionID...)
+	failOnAzureError(t, err, "get virtual network connection of", "Microsoft.Web/sites", name, resourceGroupName)
+	return swiftVirtualNetworkConnection
+}
+
 //GetSwiftVirtualNetworkConnectionE will return web.SwiftVirtualNetwork object and an error object
-func GetSwiftVirtualNetworkConnectionE(resourceGroupName string, name string) (*web.SwiftVirtualNetwork, error) {
-	client, err := GetAppsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetSwiftVirtualNetworkConnectionE(resourceGroupName string, name string, subscriptionID ...string) (*web.SwiftVirtualNetwork, error) {
+	return GetSwiftVirtualNetworkConnectionWithContextE(context.Background(), resourceGroupName, name, subscriptionID...)
+}
+
+// GetSwiftVirtualNetworkConnectionWithContextE will return web.SwiftVirtualNetwork object and an error object
+func GetSwiftVirtualNetworkConnectionWithContextE(ctx context.Context, resourceGroupName string, name string, subscriptionID ...string) (*web.SwiftVirtualNetwork, error) {
+	client, err := GetAppsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.GetSwiftVirtualNetworkConnection(context.Background(), resourceGroupName, name)
+	result, err := client.GetSwiftVirtualNetworkConnection(ctx, resourceGroupName, name)
 	if err != nil {
 		return nil, err
 	}
@@ -1808,26 +2732,40 @@ func GetSwiftVirtualNetworkConnectionE(resourceGroupName string, name string) (*
 
 // GetAppsClientE creates a mysql.ServersClient  object
 func GetAppsClientE(subscriptionID string) (*web.AppsClient, error) {
-	client := web.NewAppsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := web.NewAppsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*web.AppsClient), nil
 }
 
 /********************************
 	SQL Server
 *********************************/
 
+// GetSQLServer is the same as GetSQLServerE but fails the test on error
+func GetSQLServer(t *testing.T, resourceGroupName string, serverName string, subscriptionID ...string) *sql.Server {
+	t.Helper()
+	sqlServer, err := GetSQLServerE(resourceGroupName, serverName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Sql/servers", serverName, resourceGroupName)
+	return sqlServer
+}
+
 // GetSQLServerE will return sql.Server object and an error object
-func GetSQLServerE(resourceGroupName string, serverName string) (*sql.Server, error) {
-	client, err := GetSQLServersClientE(os.Getenv(SubscriptionIDEnvName))
+func GetSQLServerE(resourceGroupName string, serverName string, subscriptionID ...string) (*sql.Server, error) {
+	return GetSQLServerWithContextE(context.Background(), resourceGroupName, serverName, subscriptionID...)
+}
+
+// GetSQLServerWithContextE will return sql.Server object and an error object
+func GetSQLServerWithContextE(ctx context.Context, resourceGroupName string, serverName string, subscriptionID ...string) (*sql.Server, error) {
+	client, err := GetSQLServersClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, serverName)
+	result, err := client.Get(ctx, resourceGroupName, serverName)
 	if err != nil {
 		return nil, err
 	}
@@ -1836,26 +2774,40 @@ func GetSQLServerE(resourceGroupName string, serverName string) (*sql.Server, er
 
 // GetSQLServersClientE creates a sql.ServersClient
 func GetSQLServersClientE(subscriptionID string) (*sql.ServersClient, error) {
-	client := sql.NewServersClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := sql.NewServersClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*sql.ServersClient), nil
 }
 
 /************************************
 	Virtual Machine Scale Set (VMSS)
 *************************************/
 
+// GetVMScaleSet is the same as GetVMScaleSetE but fails the test on error
+func GetVMScaleSet(t *testing.T, resourceGroupName string, vmScaleSetName string, subscriptionID ...string) *compute.VirtualMachineScaleSet {
+	t.Helper()
+	vmScaleSet, err := GetVMScaleSetE(resourceGroupName, vmScaleSetName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Compute/virtualMachineScaleSets", vmScaleSetName, resourceGroupName)
+	return vmScaleSet
+}
+
 // GetVMScaleSetE will return compute.VirtualMachiuneScaleSet object and an error object
-func GetVMScaleSetE(resourceGroupName string, vmScaleSetName string) (*compute.VirtualMachineScaleSet, error) {
-	client, err := GetVMScaleSetsClientE(os.Getenv(SubscriptionIDEnvName))
+func GetVMScaleSetE(resourceGroupName string, vmScaleSetName string, subscriptionID ...string) (*compute.VirtualMachineScaleSet, error) {
+	return GetVMScaleSetWithContextE(context.Background(), resourceGroupName, vmScaleSetName, subscriptionID...)
+}
+
+// GetVMScaleSetWithContextE will return compute.VirtualMachiuneScaleSet object and an error object
+func GetVMScaleSetWithContextE(ctx context.Context, resourceGroupName string, vmScaleSetName string, subscriptionID ...string) (*compute.VirtualMachineScaleSet, error) {
+	client, err := GetVMScaleSetsClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, vmScaleSetName)
+	result, err := client.Get(ctx, resourceGroupName, vmScaleSetName)
 	if err != nil {
 		return nil, err
 	}
@@ -1864,26 +2816,40 @@ func GetVMScaleSetE(resourceGroupName string, vmScaleSetName string) (*compute.V
 
 // GetVMScaleSetsClientE creates a sql.ServersClient
 func GetVMScaleSetsClientE(subscriptionID string) (*compute.VirtualMachineScaleSetsClient, error) {
-	client := compute.NewVirtualMachineScaleSetsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := compute.NewVirtualMachineScaleSetsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*compute.VirtualMachineScaleSetsClient), nil
 }
 
 /************************************
 	frontdoor.FrontDoor
 *************************************/
 
+// GetFrontDoor is the same as GetFrontDoorE but fails the test on error
+func GetFrontDoor(t *testing.T, resourceGroupName string, frontDoorName string, subscriptionID ...string) *frontdoor.FrontDoor {
+	t.Helper()
+	frontDoor, err := GetFrontDoorE(resourceGroupName, frontDoorName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/frontDoors", frontDoorName, resourceGroupName)
+	return frontDoor
+}
+
 // GetFrontDoorE will return  frontdoor.FrontDoor object and an error object
-func GetFrontDoorE(resourceGroupName string, frontDoorName string) (*frontdoor.FrontDoor, error) {
-	client, err := GetFrontDoorClientE(os.Getenv(SubscriptionIDEnvName))
+func GetFrontDoorE(resourceGroupName string, frontDoorName string, subscriptionID ...string) (*frontdoor.FrontDoor, error) {
+	return GetFrontDoorWithContextE(context.Background(), resourceGroupName, frontDoorName, subscriptionID...)
+}
+
+// GetFrontDoorWithContextE will return  frontdoor.FrontDoor object and an error object
+func GetFrontDoorWithContextE(ctx context.Context, resourceGroupName string, frontDoorName string, subscriptionID ...string) (*frontdoor.FrontDoor, error) {
+	client, err := GetFrontDoorClientE(getTargetSubscription(subscriptionID...))
 	if err != nil {
 		return nil, err
 	}
-	result, err := client.Get(context.Background(), resourceGroupName, frontDoorName)
+	result, err := client.Get(ctx, resourceGroupName, frontDoorName)
 	if err != nil {
 		return nil, err
 	}
@@ -1892,11 +2858,12 @@ func GetFrontDoorE(resourceGroupName string, frontDoorName string) (*frontdoor.F
 
 // GetFrontDoorClientE creates a frontdoor.FrontDoorsClient
 func GetFrontDoorClientE(subscriptionID string) (*frontdoor.FrontDoorsClient, error) {
-	client := frontdoor.NewFrontDoorsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := frontdoor.NewFrontDoorsClient(subscriptionID)
+		return &client
+	})
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
-	return &client, nil
+	return client.(*frontdoor.FrontDoorsClient), nil
 }
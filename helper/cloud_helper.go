@@ -0,0 +1,106 @@
+package helper
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+const (
+	// CloudEnvironmentEnvName name of the Azure cloud, e.g. AzurePublicCloud, AzureUSGovernment or AzureChinaCloud
+	CloudEnvironmentEnvName = "AZURE_ENVIRONMENT"
+)
+
+// cloudAliases maps the cloud names used by az cli to the autorest environment names
+var cloudAliases = map[string]string{
+	"AZURECLOUD":        "AzurePublicCloud",
+	"AZUREUSGOVERNMENT": "AzureUSGovernmentCloud",
+	"AZUREGERMANCLOUD":  "AzureGermanCloud",
+	"AZURECHINACLOUD":   "AzureChinaCloud",
+}
+
+// GetCloudEnvironmentE returns the cloud set in AZURE_ENVIRONMENT, AzurePublicCloud if it is empty.
+// Both autorest (AzureUSGovernmentCloud) and az cli (AzureUSGovernment) names are accepted.
+func GetCloudEnvironmentE() (azure.Environment, error) {
+	name := os.Getenv(CloudEnvironmentEnvName)
+	if name == "" {
+		return azure.PublicCloud, nil
+	}
+	if alias, ok := cloudAliases[strings.ToUpper(name)]; ok {
+		name = alias
+	}
+	env, err := azure.EnvironmentFromName(name)
+	if err != nil {
+		return env, fmt.Errorf("Invalid %s: %s", CloudEnvironmentEnvName, err)
+	}
+	return env, nil
+}
+
+// resourceManagerAudience returns the token audience for Azure Resource Manager
+func resourceManagerAudience(env azure.Environment) string {
+	if env.TokenAudience != "" {
+		return env.TokenAudience
+	}
+	return env.ResourceManagerEndpoint
+}
+
+/********************************
+		Data plane hostnames
+*********************************/
+
+// GetKeyVaultURIE returns the URI of a key vault in the configured cloud, e.g. https://foo.vault.azure.net/
+func GetKeyVaultURIE(keyVaultName string) (string, error) {
+	env, err := defaultClientFactory.EnvironmentE()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.%s/", keyVaultName, env.KeyVaultDNSSuffix), nil
+}
+
+// GetStorageEndpointE returns the endpoint of a storage account service (blob, file, queue, table or dfs)
+// in the configured cloud, e.g. https://foo.blob.core.windows.net/
+func GetStorageEndpointE(storageAccountName string, service string) (string, error) {
+	env, err := defaultClientFactory.EnvironmentE()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.%s.%s/", storageAccountName, service, env.StorageEndpointSuffix), nil
+}
+
+// GetSQLServerFQDNE returns the fully qualified name of an Azure SQL server in the configured cloud
+func GetSQLServerFQDNE(serverName string) (string, error) {
+	env, err := defaultClientFactory.EnvironmentE()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.%s", serverName, env.SQLDatabaseDNSSuffix), nil
+}
+
+// GetCosmosDBEndpointE returns the endpoint of a Cosmos DB account in the configured cloud
+func GetCosmosDBEndpointE(accountName string) (string, error) {
+	env, err := defaultClientFactory.EnvironmentE()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.%s:443/", accountName, env.CosmosDBDNSSuffix), nil
+}
+
+// GetContainerRegistryLoginServerE returns the login server of a container registry in the configured cloud
+func GetContainerRegistryLoginServerE(registryName string) (string, error) {
+	env, err := defaultClientFactory.EnvironmentE()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.%s", strings.ToLower(registryName), env.ContainerRegistryDNSSuffix), nil
+}
+
+// GetServiceBusEndpointE returns the endpoint of a Service Bus or Event Hub namespace in the configured cloud
+func GetServiceBusEndpointE(namespaceName string) (string, error) {
+	env, err := defaultClientFactory.EnvironmentE()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.%s/", namespaceName, env.ServiceBusEndpointSuffix), nil
+}
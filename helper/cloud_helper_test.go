@@ -0,0 +1,116 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setTestCloud makes the default client factory use env for the test
+func setTestCloud(t *testing.T, env azure.Environment) {
+	f := defaultClientFactory
+	f.mu.Lock()
+	previous := f.environment
+	f.mu.Unlock()
+	f.SetEnvironment(env)
+	t.Cleanup(func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.environment = previous
+		f.resetLocked()
+	})
+}
+
+func TestGetCloudEnvironmentE(t *testing.T) {
+	cases := []struct {
+		name string
+		env  azure.Environment
+	}{
+		{"", azure.PublicCloud},
+		{"AzurePublicCloud", azure.PublicCloud},
+		{"AzureCloud", azure.PublicCloud},
+		{"azurecloud", azure.PublicCloud},
+		{"AzureUSGovernmentCloud", azure.USGovernmentCloud},
+		{"AzureUSGovernment", azure.USGovernmentCloud},
+		{"AzureGermanCloud", azure.GermanCloud},
+		{"AZUREGERMANCLOUD", azure.GermanCloud},
+		{"AzureChinaCloud", azure.ChinaCloud},
+		{"azurechinacloud", azure.ChinaCloud},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv(CloudEnvironmentEnvName, c.name)
+			env, err := GetCloudEnvironmentE()
+			require.NoError(t, err)
+			assert.Equal(t, c.env.Name, env.Name)
+		})
+	}
+
+	t.Setenv(CloudEnvironmentEnvName, "AzureMarsCloud")
+	_, err := GetCloudEnvironmentE()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid AZURE_ENVIRONMENT")
+}
+
+func TestResourceManagerAudience(t *testing.T) {
+	assert.Equal(t, "https://management.azure.com/", resourceManagerAudience(azure.PublicCloud))
+	assert.Equal(t, "https://management.chinacloudapi.cn/", resourceManagerAudience(azure.ChinaCloud))
+	assert.Equal(t, "https://management.example.com/", resourceManagerAudience(azure.Environment{ResourceManagerEndpoint: "https://management.example.com/"}))
+}
+
+func TestDataPlaneEndpoints(t *testing.T) {
+	endpoints := func(t *testing.T) []string {
+		values := make([]string, 0)
+		for _, get := range []func() (string, error){
+			func() (string, error) { return GetKeyVaultURIE("kv") },
+			func() (string, error) { return GetStorageEndpointE("st", "blob") },
+			func() (string, error) { return GetSQLServerFQDNE("sql") },
+			func() (string, error) { return GetCosmosDBEndpointE("cosmos") },
+			func() (string, error) { return GetContainerRegistryLoginServerE("ACR") },
+			func() (string, error) { return GetServiceBusEndpointE("sb") },
+		} {
+			value, err := get()
+			require.NoError(t, err)
+			values = append(values, value)
+		}
+		return values
+	}
+
+	cases := []struct {
+		env       azure.Environment
+		endpoints []string
+	}{
+		{azure.PublicCloud, []string{
+			"https://kv.vault.azure.net/",
+			"https://st.blob.core.windows.net/",
+			"sql.database.windows.net",
+			"https://cosmos.documents.azure.com:443/",
+			"acr.azurecr.io",
+			"https://sb.servicebus.windows.net/",
+		}},
+		{azure.USGovernmentCloud, []string{
+			"https://kv.vault.usgovcloudapi.net/",
+			"https://st.blob.core.usgovcloudapi.net/",
+			"sql.database.usgovcloudapi.net",
+			"https://cosmos.documents.azure.us:443/",
+			"acr.azurecr.us",
+			"https://sb.servicebus.usgovcloudapi.net/",
+		}},
+		{azure.ChinaCloud, []string{
+			"https://kv.vault.azure.cn/",
+			"https://st.blob.core.chinacloudapi.cn/",
+			"sql.database.chinacloudapi.cn",
+			"https://cosmos.documents.azure.cn:443/",
+			"acr.azurecr.cn",
+			"https://sb.servicebus.chinacloudapi.cn/",
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.env.Name, func(t *testing.T) {
+			setTestCloud(t, c.env)
+			assert.Equal(t, c.endpoints, endpoints(t))
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
+)
+
+/********************************
+		Deployments
+*********************************/
+
+// deploymentSucceeded is the provisioning state of a deployment that completed
+const deploymentSucceeded = "Succeeded"
+
+// GetDeployment is the same as GetDeploymentE but fails the test on error
+func GetDeployment(t *testing.T, resourceGroupName string, deploymentName string, subscriptionID ...string) *resources.DeploymentExtended {
+	t.Helper()
+	deployment, err := GetDeploymentE(resourceGroupName, deploymentName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Resources/deployments", deploymentName, resourceGroupName)
+	return deployment
+}
+
+// GetDeploymentE returns an ARM or Bicep deployment of a resource group
+func GetDeploymentE(resourceGroupName string, deploymentName string, subscriptionID ...string) (*resources.DeploymentExtended, error) {
+	return GetDeploymentWithContextE(context.Background(), resourceGroupName, deploymentName, subscriptionID...)
+}
+
+// GetDeploymentWithContextE returns an ARM or Bicep deployment of a resource group
+func GetDeploymentWithContextE(ctx context.Context, resourceGroupName string, deploymentName string, subscriptionID ...string) (*resources.DeploymentExtended, error) {
+	client, err := GetDeploymentsClientE(getTargetSubscription(subscriptionID...))
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := client.Get(ctx, resourceGroupName, deploymentName)
+	if err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// GetLatestSuccessfulDeploymentE returns the most recent deployment of a resource group that succeeded and whose name
+// matches namePattern, e.g. "hub-*" for deployments named after the pipeline run ("*" matches any deployment)
+func GetLatestSuccessfulDeploymentE(resourceGroupName string, namePattern string, subscriptionID ...string) (*resources.DeploymentExtended, error) {
+	return GetLatestSuccessfulDeploymentWithContextE(context.Background(), resourceGroupName, namePattern, subscriptionID...)
+}
+
+// GetLatestSuccessfulDeploymentWithContextE returns the most recent deployment of a resource group that succeeded and whose name
+// matches namePattern, e.g. "hub-*" for deployments named after the pipeline run ("*" matches any deployment)
+func GetLatestSuccessfulDeploymentWithContextE(ctx context.Context, resourceGroupName string, namePattern string, subscriptionID ...string) (*resources.DeploymentExtended, error) {
+	if _, err := path.Match(namePattern, ""); err != nil {
+		return nil, fmt.Errorf("Invalid deployment name pattern %s: %s", namePattern, err)
+	}
+	client, err := GetDeploymentsClientE(getTargetSubscription(subscriptionID...))
+	if err != nil {
+		return nil, err
+	}
+	iterator, err := client.ListByResourceGroupComplete(ctx, resourceGroupName, fmt.Sprintf("provisioningState eq '%s'", deploymentSucceeded), nil)
+	if err != nil {
+		return nil, err
+	}
+	var latest *resources.DeploymentExtended
+	var latestTime time.Time
+	for iterator.NotDone() {
+		deployment := iterator.Value()
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+		if deployment.Name == nil || deployment.Properties == nil || deployment.Properties.ProvisioningState == nil ||
+			!strings.EqualFold(*deployment.Properties.ProvisioningState, deploymentSucceeded) {
+			continue
+		}
+		if matched, _ := path.Match(strings.ToLower(namePattern), strings.ToLower(*deployment.Name)); !matched {
+			continue
+		}
+		var timestamp time.Time
+		if deployment.Properties.Timestamp != nil {
+			timestamp = deployment.Properties.Timestamp.Time
+		}
+		if latest == nil || timestamp.After(latestTime) {
+			latest = &deployment
+			latestTime = timestamp
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("No successful deployment matching %s in resource group %s", namePattern, resourceGroupName)
+	}
+	return latest, nil
+}
+
+// GetDeploymentOutputsE returns the value of every output of a deployment by name. deploymentName can be a pattern
+// with *, ? or [...], then the outputs of the latest successful deployment matching it are returned (see
+// GetLatestSuccessfulDeploymentE). Otherwise the deployment must have succeeded.
+func GetDeploymentOutputsE(resourceGroupName string, deploymentName string, subscriptionID ...string) (map[string]interface{}, error) {
+	return GetDeploymentOutputsWithContextE(context.Background(), resourceGroupName, deploymentName, subscriptionID...)
+}
+
+// GetDeploymentOutputsWithContextE returns the value of every output of a deployment by name. deploymentName can be a pattern
+// with *, ? or [...], then the outputs of the latest successful deployment matching it are returned (see
+// GetLatestSuccessfulDeploymentE). Otherwise the deployment must have succeeded.
+func GetDeploymentOutputsWithContextE(ctx context.Context, resourceGroupName string, deploymentName string, subscriptionID ...string) (map[string]interface{}, error) {
+	var deployment *resources.DeploymentExtended
+	var err error
+	if strings.ContainsAny(deploymentName, "*?[") {
+		deployment, err = GetLatestSuccessfulDeploymentWithContextE(ctx, resourceGroupName, deploymentName, subscriptionID...)
+	} else {
+		deployment, err = GetDeploymentWithContextE(ctx, resourceGroupName, deploymentName, subscriptionID...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	name := deploymentName
+	if deployment.Name != nil {
+		name = *deployment.Name
+	}
+	if deployment.Properties == nil || deployment.Properties.ProvisioningState == nil ||
+		!strings.EqualFold(*deployment.Properties.ProvisioningState, deploymentSucceeded) {
+		state := "in an unknown state"
+		if deployment.Properties != nil && deployment.Properties.ProvisioningState != nil {
+			state = *deployment.Properties.ProvisioningState
+		}
+		return nil, fmt.Errorf("Deployment %s in resource group %s is %s, its outputs are not available", name, resourceGroupName, state)
+	}
+
+	values := make(map[string]interface{})
+	outputs, ok := deployment.Properties.Outputs.(map[string]interface{})
+	if !ok {
+		return values, nil
+	}
+	for outputName, output := range outputs {
+		// each output is {"type": "String", "value": ...}
+		if typed, ok := output.(map[string]interface{}); ok {
+			values[outputName] = typed["value"]
+		}
+	}
+	return values, nil
+}
+
+// FetchDeploymentOutputsE fills the fields having an `armoutput` tag with the outputs of ARM or Bicep deployments of a resource group.
+// The tag is the deployment name and the output name, followed by a property path for object and array outputs, e.g.
+// `armoutput:"hub/vnetName"` or `armoutput:"hub/subnets[0].id"`. A deployment name with a wildcard picks the latest successful
+// deployment matching it, e.g. `armoutput:"hub-*/vnetName"` or `armoutput:"*/vnetName"`. Use it after InitializeTestValues
+// and FetchKeyVaultSecretE to fill a config struct mixing `env`, `kv` and `armoutput` tags.
+func FetchDeploymentOutputsE(s interface{}, resourceGroupName string, subscriptionID ...string) (interface{}, error) {
+	return FetchDeploymentOutputsWithContextE(context.Background(), s, resourceGroupName, subscriptionID...)
+}
+
+// FetchDeploymentOutputsWithContextE fills the fields having an `armoutput` tag with the outputs of ARM or Bicep deployments of a resource group,
+// see FetchDeploymentOutputsE
+func FetchDeploymentOutputsWithContextE(ctx context.Context, s interface{}, resourceGroupName string, subscriptionID ...string) (interface{}, error) {
+	// outputs of each deployment, so every deployment is read once
+	deployments := make(map[string]map[string]interface{})
+
+	fields := reflect.ValueOf(s).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		typeField := fields.Type().Field(i)
+		tag := typeField.Tag.Get("armoutput")
+		if tag == "" {
+			continue
+		}
+		parts := strings.SplitN(tag, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("Invalid armoutput tag %s on field %s: expected deploymentName/outputName", tag, typeField.Name)
+		}
+		deploymentName, outputPath := parts[0], parts[1]
+
+		outputs, ok := deployments[deploymentName]
+		if !ok {
+			var err error
+			if outputs, err = GetDeploymentOutputsWithContextE(ctx, resourceGroupName, deploymentName, subscriptionID...); err != nil {
+				return nil, err
+			}
+			deployments[deploymentName] = outputs
+		}
+		value, err := GetPropertyByPathE(outputs, outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("Can not find output %s of deployment %s for field %s: %s", outputPath, deploymentName, typeField.Name, err)
+		}
+		if err := setConfigField(fields.Field(i), value); err != nil {
+			return nil, fmt.Errorf("Can not set field %s from output %s of deployment %s: %s", typeField.Name, outputPath, deploymentName, err)
+		}
+	}
+	return s, nil
+}
+
+// GetDeploymentsClientE creates a DeploymentsClient
+func GetDeploymentsClientE(subscriptionID string) (*resources.DeploymentsClient, error) {
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := resources.NewDeploymentsClient(subscriptionID)
+		return &client
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(*resources.DeploymentsClient), nil
+}
@@ -0,0 +1,123 @@
+package helper
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDeployments seeds a deployment of rg-test for each file of testdata/deployments, named after the file
+func setupDeployments(t *testing.T) *FakeARMServer {
+	s := setupTestARMServer(t)
+	files, err := filepath.Glob(filepath.Join("testdata", "deployments", "*.json"))
+	require.NoError(t, err)
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		s.AddResource(t, testResourceGroupID+"/providers/Microsoft.Resources/deployments/"+name, content)
+	}
+	return s
+}
+
+func TestGetDeploymentOutputsE(t *testing.T) {
+	setupDeployments(t)
+
+	outputs, err := GetDeploymentOutputsE("rg-test", "hub-20210302")
+	require.NoError(t, err)
+	assert.Equal(t, "vnet-hub", outputs["vnetName"])
+	assert.Equal(t, []interface{}{"10.0.0.0/16", "10.1.0.0/16"}, outputs["addressPrefixes"])
+	assert.Equal(t, map[string]interface{}{"environment": "test"}, outputs["tags"])
+	assert.Equal(t, true, outputs["ddosEnabled"])
+
+	_, err = GetDeploymentOutputsE("rg-test", "hub-20210303")
+	assert.EqualError(t, err, "Deployment hub-20210303 in resource group rg-test is Failed, its outputs are not available")
+
+	_, err = GetDeploymentOutputsE("rg-test", "hub-19990101")
+	assert.Error(t, err)
+}
+
+func TestGetDeploymentOutputsEPattern(t *testing.T) {
+	setupDeployments(t)
+
+	// the latest successful deployment, hub-20210303 failed
+	outputs, err := GetDeploymentOutputsE("rg-test", "hub-*")
+	require.NoError(t, err)
+	assert.Equal(t, "vnet-hub", outputs["vnetName"])
+
+	outputs, err = GetDeploymentOutputsE("rg-test", "SPOKE*")
+	require.NoError(t, err)
+	assert.Equal(t, "vnet-spoke", outputs["vnetName"])
+
+	_, err = GetDeploymentOutputsE("rg-test", "firewall-*")
+	assert.EqualError(t, err, "No successful deployment matching firewall-* in resource group rg-test")
+
+	_, err = GetLatestSuccessfulDeploymentE("rg-test", "hub-[")
+	assert.Error(t, err)
+}
+
+func TestFetchDeploymentOutputsE(t *testing.T) {
+	s := setupDeployments(t)
+
+	config := struct {
+		VnetName         string            `armoutput:"hub-20210302/vnetName"`
+		LatestVnetName   string            `armoutput:"hub-*/vnetName"`
+		SpokeVnetName    string            `armoutput:"spoke/vnetName"`
+		FirewallSubnetID string            `armoutput:"hub-20210302/subnets[0].id"`
+		AddressPrefixes  []string          `armoutput:"hub-20210302/addressPrefixes"`
+		Tags             map[string]string `armoutput:"hub-20210302/tags"`
+		FirewallCount    int64             `armoutput:"hub-20210302/firewallCount"`
+		FirewallText     string            `armoutput:"hub-20210302/firewallCount"`
+		DdosEnabled      bool              `armoutput:"hub-20210302/ddosEnabled"`
+	}{}
+
+	_, err := FetchDeploymentOutputsE(&config, "rg-test")
+	require.NoError(t, err)
+	assert.Equal(t, "vnet-hub", config.VnetName)
+	assert.Equal(t, "vnet-hub", config.LatestVnetName)
+	assert.Equal(t, "vnet-spoke", config.SpokeVnetName)
+	assert.Equal(t, testResourceGroupID+"/providers/Microsoft.Network/virtualNetworks/vnet-hub/subnets/AzureFirewallSubnet", config.FirewallSubnetID)
+	assert.Equal(t, []string{"10.0.0.0/16", "10.1.0.0/16"}, config.AddressPrefixes)
+	assert.Equal(t, map[string]string{"environment": "test"}, config.Tags)
+	assert.Equal(t, int64(2), config.FirewallCount)
+	assert.Equal(t, "2", config.FirewallText)
+	assert.True(t, config.DdosEnabled)
+	assert.True(t, ValidateTestValues(&config, false))
+
+	// each deployment is read once
+	gets := 0
+	for _, request := range s.Requests() {
+		if strings.HasSuffix(request, "/deployments/hub-20210302") {
+			gets++
+		}
+	}
+	assert.Equal(t, 1, gets)
+}
+
+func TestFetchDeploymentOutputsEErrors(t *testing.T) {
+	setupDeployments(t)
+
+	invalidTag := struct {
+		VnetName string `armoutput:"vnetName"`
+	}{}
+	_, err := FetchDeploymentOutputsE(&invalidTag, "rg-test")
+	assert.EqualError(t, err, "Invalid armoutput tag vnetName on field VnetName: expected deploymentName/outputName")
+
+	missingOutput := struct {
+		FirewallName string `armoutput:"spoke/firewallName"`
+	}{}
+	_, err = FetchDeploymentOutputsE(&missingOutput, "rg-test")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Can not find output firewallName of deployment spoke for field FirewallName")
+	}
+
+	wrongType := struct {
+		Subnets string `armoutput:"hub-20210302/subnets"`
+	}{}
+	_, err = FetchDeploymentOutputsE(&wrongType, "rg-test")
+	assert.EqualError(t, err, "Can not set field Subnets from output subnets of deployment hub-20210302: the value is a []interface {}, not a string")
+}
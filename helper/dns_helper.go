@@ -0,0 +1,767 @@
+package helper
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+/********************************
+		DNS resolution
+*********************************/
+
+// DNSRecordType is the type of a DNS record supported by the DNS helpers
+type DNSRecordType string
+
+// DNS record types
+const (
+	DNSRecordTypeA     DNSRecordType = "A"
+	DNSRecordTypeAAAA  DNSRecordType = "AAAA"
+	DNSRecordTypeCNAME DNSRecordType = "CNAME"
+	DNSRecordTypeTXT   DNSRecordType = "TXT"
+	DNSRecordTypeSRV   DNSRecordType = "SRV"
+)
+
+// DNSTimeout is the time to wait for the answer of a DNS server when the context has no deadline
+var DNSTimeout = 5 * time.Second
+
+// maxCNAMEChainLength is the number of CNAME records followed before giving up on a loop
+const maxCNAMEChainLength = 10
+
+var dnsRecordTypes = map[DNSRecordType]dnsmessage.Type{
+	DNSRecordTypeA:     dnsmessage.TypeA,
+	DNSRecordTypeAAAA:  dnsmessage.TypeAAAA,
+	DNSRecordTypeCNAME: dnsmessage.TypeCNAME,
+	DNSRecordTypeTXT:   dnsmessage.TypeTXT,
+	DNSRecordTypeSRV:   dnsmessage.TypeSRV,
+}
+
+// DNSRecord is a record of a DNS answer. Names are lower case without the trailing dot.
+// Value is the address of A and AAAA records, the canonical name of CNAME records, the concatenated strings of
+// TXT records and "priority weight port target" for SRV records.
+type DNSRecord struct {
+	Name  string
+	Type  DNSRecordType
+	Value string
+	TTL   uint32
+}
+
+func (r DNSRecord) String() string {
+	return fmt.Sprintf("%s %d %s %s", r.Name, r.TTL, r.Type, r.Value)
+}
+
+// ResolveDNSE queries server for the records of a given type of hostname and returns the answer section, which includes
+// the CNAME records followed by the server before the records of the requested type.
+// server is the address of the DNS server, with an optional port (10.0.0.4, 10.0.0.4:5353, [fd00::4]:53), e.g. the
+// private IP address of a DNS forwarder or Azure DNS (168.63.129.16) when the test runs inside the virtual network.
+// The query goes over UDP and is retried over TCP when the answer is truncated.
+// A name that does not exist returns no records and no error, other server failures return an error.
+func ResolveDNSE(server string, hostname string, recordType DNSRecordType) ([]DNSRecord, error) {
+	return ResolveDNSWithContextE(context.Background(), server, hostname, recordType)
+}
+
+// ResolveDNSWithContextE queries server for the records of a given type of hostname, see ResolveDNSE
+func ResolveDNSWithContextE(ctx context.Context, server string, hostname string, recordType DNSRecordType) ([]DNSRecord, error) {
+	qtype, ok := dnsRecordTypes[recordType]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported DNS record type %s", recordType)
+	}
+	address, err := dnsServerAddress(server)
+	if err != nil {
+		return nil, err
+	}
+	name, err := dnsmessage.NewName(dnsFQDN(hostname))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid host name %s: %s", hostname, err)
+	}
+	question := dnsmessage.Question{Name: name, Type: qtype, Class: dnsmessage.ClassINET}
+	id := uint16(rand.Intn(1 << 16))
+	var query []byte
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true})
+	if err = builder.StartQuestions(); err == nil {
+		err = builder.Question(question)
+	}
+	if err == nil {
+		query, err = builder.Finish()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Can not build the DNS query for %s: %s", hostname, err)
+	}
+
+	header, records, err := exchangeDNS(ctx, "udp", address, id, query)
+	if err == nil && header.Truncated {
+		header, records, err = exchangeDNS(ctx, "tcp", address, id, query)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Can not resolve %s %s through %s: %s", recordType, hostname, address, err)
+	}
+	switch header.RCode {
+	case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+		return records, nil
+	default:
+		return nil, fmt.Errorf("Can not resolve %s %s through %s: %s", recordType, hostname, address, header.RCode)
+	}
+}
+
+// LookupDNSE returns the sorted values of the records of a given type of hostname resolved through server, see ResolveDNSE
+func LookupDNSE(server string, hostname string, recordType DNSRecordType) ([]string, error) {
+	return LookupDNSWithContextE(context.Background(), server, hostname, recordType)
+}
+
+// LookupDNSWithContextE returns the sorted values of the records of a given type of hostname resolved through server, see ResolveDNSE
+func LookupDNSWithContextE(ctx context.Context, server string, hostname string, recordType DNSRecordType) ([]string, error) {
+	records, err := ResolveDNSWithContextE(ctx, server, hostname, recordType)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, 0)
+	for _, record := range records {
+		if record.Type == recordType {
+			values = append(values, record.Value)
+		}
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+// LookupHostAddressesE returns the sorted IPv4 and IPv6 addresses of hostname resolved through server
+func LookupHostAddressesE(server string, hostname string) ([]string, error) {
+	return LookupHostAddressesWithContextE(context.Background(), server, hostname)
+}
+
+// LookupHostAddressesWithContextE returns the sorted IPv4 and IPv6 addresses of hostname resolved through server
+func LookupHostAddressesWithContextE(ctx context.Context, server string, hostname string) ([]string, error) {
+	addresses := make([]string, 0)
+	for _, recordType := range []DNSRecordType{DNSRecordTypeA, DNSRecordTypeAAAA} {
+		values, err := LookupDNSWithContextE(ctx, server, hostname, recordType)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, values...)
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// ResolveCNAMEChainE returns the names hostname goes through to its canonical name when resolved through server,
+// starting with hostname itself, e.g. mystorage.blob.core.windows.net, mystorage.privatelink.blob.core.windows.net.
+// A server that does not follow CNAME records is queried again for each target.
+func ResolveCNAMEChainE(server string, hostname string) ([]string, error) {
+	return ResolveCNAMEChainWithContextE(context.Background(), server, hostname)
+}
+
+// ResolveCNAMEChainWithContextE returns the names hostname goes through to its canonical name when resolved through server,
+// see ResolveCNAMEChainE
+func ResolveCNAMEChainWithContextE(ctx context.Context, server string, hostname string) ([]string, error) {
+	chain := []string{dnsName(hostname)}
+	for {
+		current := chain[len(chain)-1]
+		records, err := ResolveDNSWithContextE(ctx, server, current, DNSRecordTypeA)
+		if err != nil {
+			return nil, err
+		}
+		followed := false
+		for {
+			target := ""
+			for _, record := range records {
+				if record.Type == DNSRecordTypeCNAME && record.Name == current {
+					target = record.Value
+					break
+				}
+			}
+			if target == "" {
+				break
+			}
+			if containsString(chain, target) || len(chain) > maxCNAMEChainLength {
+				return nil, fmt.Errorf("CNAME loop or chain longer than %d names resolving %s: %s", maxCNAMEChainLength, hostname, strings.Join(chain, " -> "))
+			}
+			chain = append(chain, target)
+			current = target
+			followed = true
+		}
+		if !followed || dnsRecordsContain(records, current) {
+			return chain, nil
+		}
+	}
+}
+
+// AssertDNSRecords fails the test when the records of a given type of hostname resolved through server are not
+// exactly the expected values, in any order. No expected values asserts that hostname has no record of the type.
+func AssertDNSRecords(t *testing.T, server string, hostname string, recordType DNSRecordType, expected ...string) {
+	t.Helper()
+	values, err := LookupDNSWithContextE(NewTestContext(t), server, hostname, recordType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recordType == DNSRecordTypeCNAME {
+		names := make([]string, 0, len(expected))
+		for _, name := range expected {
+			names = append(names, dnsName(name))
+		}
+		expected = names
+	}
+	if !sameStrings(values, expected) {
+		t.Errorf("%s records of %s resolved through %s are [%s], expected [%s]", recordType, hostname, server, strings.Join(values, ", "), strings.Join(expected, ", "))
+	}
+}
+
+// AssertDNSResolvesTo fails the test when the IPv4 and IPv6 addresses of hostname resolved through server are not
+// exactly the expected addresses, in any order, e.g. the private endpoint IP address of a storage account
+func AssertDNSResolvesTo(t *testing.T, server string, hostname string, addresses ...string) {
+	t.Helper()
+	values, err := LookupHostAddressesWithContextE(NewTestContext(t), server, hostname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if ip := net.ParseIP(address); ip != nil {
+			address = ip.String()
+		}
+		expected = append(expected, address)
+	}
+	if !sameStrings(values, expected) {
+		t.Errorf("%s resolved through %s to [%s], expected [%s]", hostname, server, strings.Join(values, ", "), strings.Join(expected, ", "))
+	}
+}
+
+// AssertDNSCNAMEChain fails the test when the names hostname goes through when resolved through server, after hostname
+// itself, do not match patterns one by one. Patterns are case insensitive as in path.Match,
+// e.g. "mystorage.privatelink.blob.core.windows.net", "*.store.core.windows.net".
+func AssertDNSCNAMEChain(t *testing.T, server string, hostname string, patterns ...string) {
+	t.Helper()
+	chain, err := ResolveCNAMEChainWithContextE(NewTestContext(t), server, hostname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := len(chain)-1 == len(patterns)
+	for i := 0; matches && i < len(patterns); i++ {
+		matched, err := path.Match(dnsName(patterns[i]), chain[i+1])
+		if err != nil {
+			t.Fatalf("Invalid CNAME pattern %s: %s", patterns[i], err)
+		}
+		matches = matched
+	}
+	if !matches {
+		t.Errorf("%s resolved through %s goes through [%s], expected [%s]", hostname, server, strings.Join(chain[1:], ", "), strings.Join(patterns, ", "))
+	}
+}
+
+// AssertDNSResolvesThroughPrivateLink fails the test when hostname resolved through server does not go through a
+// privatelink.* zone (e.g. mystorage.blob.core.windows.net to mystorage.privatelink.blob.core.windows.net) or does
+// not resolve to exactly the given addresses, usually the private endpoint IP address
+func AssertDNSResolvesThroughPrivateLink(t *testing.T, server string, hostname string, addresses ...string) {
+	t.Helper()
+	ctx := NewTestContext(t)
+	chain, err := ResolveCNAMEChainWithContextE(ctx, server, hostname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	throughPrivateLink := false
+	for _, name := range chain {
+		for _, label := range strings.Split(name, ".") {
+			throughPrivateLink = throughPrivateLink || label == "privatelink"
+		}
+	}
+	if !throughPrivateLink {
+		t.Errorf("%s resolved through %s does not go through a privatelink zone: %s", hostname, server, strings.Join(chain, " -> "))
+	}
+	AssertDNSResolvesTo(t, server, hostname, addresses...)
+}
+
+// exchangeDNS sends a query to a DNS server and parses the answer section of its response
+func exchangeDNS(ctx context.Context, network string, address string, id uint16, query []byte) (dnsmessage.Header, []DNSRecord, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DNSTimeout)
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return dnsmessage.Header{}, nil, err
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	if err = conn.SetDeadline(deadline); err != nil {
+		return dnsmessage.Header{}, nil, err
+	}
+
+	if network == "tcp" {
+		if err = writeDNSMessage(conn, query); err != nil {
+			return dnsmessage.Header{}, nil, err
+		}
+		response, err := readDNSMessage(conn)
+		if err != nil {
+			return dnsmessage.Header{}, nil, err
+		}
+		return parseDNSResponse(response, id)
+	}
+
+	if _, err = conn.Write(query); err != nil {
+		return dnsmessage.Header{}, nil, err
+	}
+	buffer := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return dnsmessage.Header{}, nil, err
+		}
+		header, records, err := parseDNSResponse(buffer[:n], id)
+		if err == errDNSUnexpectedID {
+			// late answer to an earlier query
+			continue
+		}
+		return header, records, err
+	}
+}
+
+var errDNSUnexpectedID = fmt.Errorf("unexpected DNS message ID")
+
+func parseDNSResponse(response []byte, id uint16) (dnsmessage.Header, []DNSRecord, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(response)
+	if err != nil {
+		return header, nil, fmt.Errorf("invalid DNS response: %s", err)
+	}
+	if !header.Response || header.ID != id {
+		return header, nil, errDNSUnexpectedID
+	}
+	if err = parser.SkipAllQuestions(); err != nil {
+		return header, nil, fmt.Errorf("invalid DNS response: %s", err)
+	}
+	records := make([]DNSRecord, 0)
+	for {
+		resourceHeader, err := parser.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			return header, records, nil
+		}
+		if err != nil {
+			return header, nil, fmt.Errorf("invalid DNS response: %s", err)
+		}
+		record := DNSRecord{Name: dnsName(resourceHeader.Name.String()), TTL: resourceHeader.TTL}
+		switch resourceHeader.Type {
+		case dnsmessage.TypeA:
+			var body dnsmessage.AResource
+			if body, err = parser.AResource(); err == nil {
+				record.Type, record.Value = DNSRecordTypeA, net.IP(body.A[:]).String()
+			}
+		case dnsmessage.TypeAAAA:
+			var body dnsmessage.AAAAResource
+			if body, err = parser.AAAAResource(); err == nil {
+				record.Type, record.Value = DNSRecordTypeAAAA, net.IP(body.AAAA[:]).String()
+			}
+		case dnsmessage.TypeCNAME:
+			var body dnsmessage.CNAMEResource
+			if body, err = parser.CNAMEResource(); err == nil {
+				record.Type, record.Value = DNSRecordTypeCNAME, dnsName(body.CNAME.String())
+			}
+		case dnsmessage.TypeTXT:
+			var body dnsmessage.TXTResource
+			if body, err = parser.TXTResource(); err == nil {
+				record.Type, record.Value = DNSRecordTypeTXT, strings.Join(body.TXT, "")
+			}
+		case dnsmessage.TypeSRV:
+			var body dnsmessage.SRVResource
+			if body, err = parser.SRVResource(); err == nil {
+				record.Type = DNSRecordTypeSRV
+				record.Value = fmt.Sprintf("%d %d %d %s", body.Priority, body.Weight, body.Port, dnsName(body.Target.String()))
+			}
+		default:
+			err = parser.SkipAnswer()
+		}
+		if err != nil {
+			return header, nil, fmt.Errorf("invalid DNS response: %s", err)
+		}
+		if record.Type != "" {
+			records = append(records, record)
+		}
+	}
+}
+
+// writeDNSMessage writes a message over TCP, prefixed with its length
+func writeDNSMessage(w io.Writer, message []byte) error {
+	prefixed := make([]byte, 2+len(message))
+	binary.BigEndian.PutUint16(prefixed, uint16(len(message)))
+	copy(prefixed[2:], message)
+	_, err := w.Write(prefixed)
+	return err
+}
+
+// readDNSMessage reads a message written by writeDNSMessage
+func readDNSMessage(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	message := make([]byte, binary.BigEndian.Uint16(length[:]))
+	_, err := io.ReadFull(r, message)
+	return message, err
+}
+
+// dnsServerAddress adds the default DNS port to a server address without port
+func dnsServerAddress(server string) (string, error) {
+	if server == "" {
+		return "", fmt.Errorf("No DNS server set")
+	}
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server, nil
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), "53"), nil
+}
+
+// dnsName returns a host name in lower case without the trailing dot
+func dnsName(hostname string) string {
+	return strings.TrimSuffix(strings.ToLower(hostname), ".")
+}
+
+func dnsFQDN(hostname string) string {
+	return dnsName(hostname) + "."
+}
+
+// dnsRecordsContain tells whether there is an address record for name
+func dnsRecordsContain(records []DNSRecord, name string) bool {
+	for _, record := range records {
+		if record.Name == name && record.Type != DNSRecordTypeCNAME {
+			return true
+		}
+	}
+	return false
+}
+
+func sameStrings(actual []string, expected []string) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+	sortedExpected := append([]string(nil), expected...)
+	sort.Strings(sortedExpected)
+	sortedActual := append([]string(nil), actual...)
+	sort.Strings(sortedActual)
+	for i := range sortedActual {
+		if sortedActual[i] != sortedExpected[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/********************************
+		Fake DNS server
+*********************************/
+
+// FakeDNSServer is an in-process DNS stand-in listening on UDP and TCP on a local port, seeded with records.
+// Like a recursive resolver it follows its own CNAME records, and it answers NXDOMAIN for names without records.
+// UDP answers longer than 512 bytes are truncated, so clients retry over TCP. It is safe for concurrent use.
+type FakeDNSServer struct {
+	udp     net.PacketConn
+	tcp     net.Listener
+	mu      sync.Mutex
+	records map[string][]DNSRecord
+	queries []string
+	wg      sync.WaitGroup
+}
+
+// fakeDNSTTL is the TTL of the records of a FakeDNSServer
+const fakeDNSTTL = 300
+
+// maxUDPDNSMessageSize is the size of a DNS message over UDP without EDNS
+const maxUDPDNSMessageSize = 512
+
+// NewFakeDNSServerE starts an empty FakeDNSServer on 127.0.0.1, call Close when done
+func NewFakeDNSServerE() (*FakeDNSServer, error) {
+	s := &FakeDNSServer{records: make(map[string][]DNSRecord)}
+	var err error
+	// the UDP and TCP listeners share a port, retry when the port picked for UDP is taken for TCP
+	for attempt := 0; attempt < 10; attempt++ {
+		if s.udp, err = net.ListenPacket("udp", "127.0.0.1:0"); err != nil {
+			return nil, err
+		}
+		if s.tcp, err = net.Listen("tcp", s.udp.LocalAddr().String()); err == nil {
+			break
+		}
+		s.udp.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Can not start the fake DNS server: %s", err)
+	}
+	s.wg.Add(2)
+	go s.serveUDP()
+	go s.serveTCP()
+	return s, nil
+}
+
+// SetupFakeDNSServer starts a FakeDNSServer that is closed when the test finishes
+func SetupFakeDNSServer(t *testing.T) *FakeDNSServer {
+	s, err := NewFakeDNSServerE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Address returns the address of the server, to use as the server of the DNS helpers
+func (s *FakeDNSServer) Address() string {
+	return s.udp.LocalAddr().String()
+}
+
+// Close stops the server
+func (s *FakeDNSServer) Close() {
+	s.udp.Close()
+	s.tcp.Close()
+	s.wg.Wait()
+}
+
+// AddRecordE adds a record to the server. value is formatted as DNSRecord.Value, e.g. 10.0.1.4 for an A record,
+// mystorage.privatelink.blob.core.windows.net for a CNAME record or "0 5 443 host.contoso.com" for an SRV record.
+func (s *FakeDNSServer) AddRecordE(hostname string, recordType DNSRecordType, value string) error {
+	record := DNSRecord{Name: dnsName(hostname), Type: recordType, Value: value, TTL: fakeDNSTTL}
+	if _, err := dnsmessage.NewName(dnsFQDN(hostname)); err != nil || record.Name == "" {
+		return fmt.Errorf("Invalid host name %s", hostname)
+	}
+	body, err := fakeDNSResource(record)
+	if err != nil {
+		return fmt.Errorf("Invalid %s record %s for %s: %s", recordType, value, hostname, err)
+	}
+	// store the value as it is resolved
+	switch body := body.(type) {
+	case *dnsmessage.AResource, *dnsmessage.AAAAResource:
+		record.Value = net.ParseIP(value).String()
+	case *dnsmessage.CNAMEResource:
+		record.Value = dnsName(value)
+	case *dnsmessage.SRVResource:
+		record.Value = fmt.Sprintf("%d %d %d %s", body.Priority, body.Weight, body.Port, dnsName(body.Target.String()))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.Name] = append(s.records[record.Name], record)
+	return nil
+}
+
+// AddRecord adds a record to the server and fails the test when it is invalid, see AddRecordE
+func (s *FakeDNSServer) AddRecord(t *testing.T, hostname string, recordType DNSRecordType, value string) {
+	t.Helper()
+	if err := s.AddRecordE(hostname, recordType, value); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Queries returns the queries received so far, as "TYPE name", e.g. "A mystorage.blob.core.windows.net"
+func (s *FakeDNSServer) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+func (s *FakeDNSServer) serveUDP() {
+	defer s.wg.Done()
+	buffer := make([]byte, 65535)
+	for {
+		n, address, err := s.udp.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		if response := s.answer(buffer[:n], maxUDPDNSMessageSize); response != nil {
+			s.udp.WriteTo(response, address)
+		}
+	}
+}
+
+func (s *FakeDNSServer) serveTCP() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(DNSTimeout))
+			query, err := readDNSMessage(conn)
+			if err != nil {
+				return
+			}
+			if response := s.answer(query, 0); response != nil {
+				writeDNSMessage(conn, response)
+			}
+		}()
+	}
+}
+
+// answer builds the response to a query, truncated when longer than maxSize unless maxSize is 0
+func (s *FakeDNSServer) answer(query []byte, maxSize int) []byte {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil || header.Response {
+		return nil
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil
+	}
+	responseHeader := dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+	}
+
+	s.mu.Lock()
+	s.queries = append(s.queries, strings.TrimPrefix(question.Type.String(), "Type")+" "+dnsName(question.Name.String()))
+	answers, found := s.lookupLocked(dnsName(question.Name.String()), question.Type)
+	s.mu.Unlock()
+	if !found {
+		responseHeader.RCode = dnsmessage.RCodeNameError
+	}
+
+	response, err := buildFakeDNSResponse(responseHeader, question, answers)
+	if err != nil {
+		responseHeader.RCode = dnsmessage.RCodeServerFailure
+		response, _ = buildFakeDNSResponse(responseHeader, question, nil)
+	} else if maxSize > 0 && len(response) > maxSize {
+		responseHeader.Truncated = true
+		response, _ = buildFakeDNSResponse(responseHeader, question, nil)
+	}
+	return response
+}
+
+// lookupLocked returns the records of a type of name, following CNAME records, and whether name exists
+func (s *FakeDNSServer) lookupLocked(name string, qtype dnsmessage.Type) ([]DNSRecord, bool) {
+	answers := make([]DNSRecord, 0)
+	_, found := s.records[name]
+	for i := 0; i <= maxCNAMEChainLength; i++ {
+		var cname *DNSRecord
+		matched := false
+		for j, record := range s.records[name] {
+			if dnsRecordTypes[record.Type] == qtype {
+				answers = append(answers, record)
+				matched = true
+			} else if record.Type == DNSRecordTypeCNAME {
+				cname = &s.records[name][j]
+			}
+		}
+		if matched || cname == nil {
+			break
+		}
+		answers = append(answers, *cname)
+		name = cname.Value
+	}
+	return answers, found
+}
+
+func buildFakeDNSResponse(header dnsmessage.Header, question dnsmessage.Question, answers []DNSRecord) ([]byte, error) {
+	builder := dnsmessage.NewBuilder(nil, header)
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(question); err != nil {
+		return nil, err
+	}
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+	for _, record := range answers {
+		name, err := dnsmessage.NewName(dnsFQDN(record.Name))
+		if err != nil {
+			return nil, err
+		}
+		resourceHeader := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: record.TTL}
+		body, err := fakeDNSResource(record)
+		if err != nil {
+			return nil, err
+		}
+		switch body := body.(type) {
+		case *dnsmessage.AResource:
+			err = builder.AResource(resourceHeader, *body)
+		case *dnsmessage.AAAAResource:
+			err = builder.AAAAResource(resourceHeader, *body)
+		case *dnsmessage.CNAMEResource:
+			err = builder.CNAMEResource(resourceHeader, *body)
+		case *dnsmessage.TXTResource:
+			err = builder.TXTResource(resourceHeader, *body)
+		case *dnsmessage.SRVResource:
+			err = builder.SRVResource(resourceHeader, *body)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return builder.Finish()
+}
+
+// fakeDNSResource converts a record to its dnsmessage resource body
+func fakeDNSResource(record DNSRecord) (dnsmessage.ResourceBody, error) {
+	switch record.Type {
+	case DNSRecordTypeA, DNSRecordTypeAAAA:
+		ip := net.ParseIP(record.Value)
+		if ip == nil || (ip.To4() != nil) != (record.Type == DNSRecordTypeA) {
+			return nil, fmt.Errorf("not an IPv%s address", map[DNSRecordType]string{DNSRecordTypeA: "4", DNSRecordTypeAAAA: "6"}[record.Type])
+		}
+		if record.Type == DNSRecordTypeA {
+			body := &dnsmessage.AResource{}
+			copy(body.A[:], ip.To4())
+			return body, nil
+		}
+		body := &dnsmessage.AAAAResource{}
+		copy(body.AAAA[:], ip.To16())
+		return body, nil
+	case DNSRecordTypeCNAME:
+		name, err := dnsmessage.NewName(dnsFQDN(record.Value))
+		if err != nil || dnsName(record.Value) == "" {
+			return nil, fmt.Errorf("not a host name")
+		}
+		return &dnsmessage.CNAMEResource{CNAME: name}, nil
+	case DNSRecordTypeTXT:
+		// character strings are at most 255 bytes long
+		body := &dnsmessage.TXTResource{TXT: []string{}}
+		text := record.Value
+		for len(text) > 255 {
+			body.TXT = append(body.TXT, text[:255])
+			text = text[255:]
+		}
+		body.TXT = append(body.TXT, text)
+		return body, nil
+	case DNSRecordTypeSRV:
+		fields := strings.Fields(record.Value)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("expected priority weight port target")
+		}
+		numbers := make([]uint16, 3)
+		for i := range numbers {
+			n, err := strconv.ParseUint(fields[i], 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %s", fields[i])
+			}
+			numbers[i] = uint16(n)
+		}
+		target, err := dnsmessage.NewName(dnsFQDN(fields[3]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %s", fields[3])
+		}
+		return &dnsmessage.SRVResource{Priority: numbers[0], Weight: numbers[1], Port: numbers[2], Target: target}, nil
+	}
+	return nil, fmt.Errorf("unsupported record type")
+}
@@ -0,0 +1,183 @@
+package helper
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPrivateLinkDNS seeds the records of a storage account behind a private endpoint, as resolved inside the virtual network
+func setupPrivateLinkDNS(t *testing.T) *FakeDNSServer {
+	s := SetupFakeDNSServer(t)
+	s.AddRecord(t, "MyStorage.blob.core.windows.net.", DNSRecordTypeCNAME, "mystorage.privatelink.blob.core.windows.net")
+	s.AddRecord(t, "mystorage.privatelink.blob.core.windows.net", DNSRecordTypeA, "10.0.1.4")
+	s.AddRecord(t, "public.blob.core.windows.net", DNSRecordTypeCNAME, "blob.ams.store.core.windows.net")
+	s.AddRecord(t, "blob.ams.store.core.windows.net", DNSRecordTypeA, "20.38.100.1")
+	return s
+}
+
+func TestResolveDNSE(t *testing.T) {
+	s := setupPrivateLinkDNS(t)
+
+	// the CNAME records followed by the server come first
+	records, err := ResolveDNSE(s.Address(), "mystorage.blob.core.windows.net", DNSRecordTypeA)
+	require.NoError(t, err)
+	assert.Equal(t, []DNSRecord{
+		{Name: "mystorage.blob.core.windows.net", Type: DNSRecordTypeCNAME, Value: "mystorage.privatelink.blob.core.windows.net", TTL: fakeDNSTTL},
+		{Name: "mystorage.privatelink.blob.core.windows.net", Type: DNSRecordTypeA, Value: "10.0.1.4", TTL: fakeDNSTTL},
+	}, records)
+	assert.Equal(t, "mystorage.privatelink.blob.core.windows.net 300 A 10.0.1.4", records[1].String())
+
+	// NXDOMAIN
+	records, err = ResolveDNSE(s.Address(), "missing.blob.core.windows.net", DNSRecordTypeA)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	// a name without records of the type
+	values, err := LookupDNSE(s.Address(), "mystorage.privatelink.blob.core.windows.net", DNSRecordTypeAAAA)
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	assert.Equal(t, []string{
+		"A mystorage.blob.core.windows.net",
+		"A missing.blob.core.windows.net",
+		"AAAA mystorage.privatelink.blob.core.windows.net",
+	}, s.Queries())
+}
+
+func TestResolveDNSEErrors(t *testing.T) {
+	s := SetupFakeDNSServer(t)
+
+	_, err := ResolveDNSE(s.Address(), "host.contoso.com", DNSRecordType("MX"))
+	assert.EqualError(t, err, "Unsupported DNS record type MX")
+
+	_, err = ResolveDNSE("", "host.contoso.com", DNSRecordTypeA)
+	assert.EqualError(t, err, "No DNS server set")
+
+	_, err = ResolveDNSE(s.Address(), strings.Repeat("a.", 128)+"contoso.com", DNSRecordTypeA)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Invalid host name")
+	}
+}
+
+func TestResolveDNSETruncated(t *testing.T) {
+	s := SetupFakeDNSServer(t)
+	expected := make([]string, 0)
+	for i := 1; i <= 40; i++ {
+		address := fmt.Sprintf("10.0.1.%d", i)
+		s.AddRecord(t, "many.contoso.com", DNSRecordTypeA, address)
+		expected = append(expected, address)
+	}
+
+	values, err := LookupDNSE(s.Address(), "many.contoso.com", DNSRecordTypeA)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, values)
+	// the truncated answer over UDP is queried again over TCP
+	assert.Equal(t, []string{"A many.contoso.com", "A many.contoso.com"}, s.Queries())
+}
+
+func TestLookupDNSERecordTypes(t *testing.T) {
+	s := SetupFakeDNSServer(t)
+	s.AddRecord(t, "_sip._tcp.contoso.com", DNSRecordTypeSRV, "10 60 5060 SIP.Contoso.com.")
+	s.AddRecord(t, "_sip._tcp.contoso.com", DNSRecordTypeSRV, "0 5 5061 sip2.contoso.com")
+	// TXT values longer than a character string are split and joined back
+	long := strings.Repeat("v=spf1 include:contoso.com ", 12)
+	s.AddRecord(t, "contoso.com", DNSRecordTypeTXT, long)
+	s.AddRecord(t, "contoso.com", DNSRecordTypeTXT, "google-site-verification=abc")
+	s.AddRecord(t, "host.contoso.com", DNSRecordTypeAAAA, "fd00:0db8::0004")
+
+	values, err := LookupDNSE(s.Address(), "_sip._tcp.contoso.com", DNSRecordTypeSRV)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0 5 5061 sip2.contoso.com", "10 60 5060 sip.contoso.com"}, values)
+
+	values, err = LookupDNSE(s.Address(), "contoso.com", DNSRecordTypeTXT)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"google-site-verification=abc", long}, values)
+
+	AssertDNSRecords(t, s.Address(), "host.contoso.com", DNSRecordTypeAAAA, "fd00:db8::4")
+	AssertDNSRecords(t, s.Address(), "missing.contoso.com", DNSRecordTypeA)
+	AssertDNSResolvesTo(t, s.Address(), "host.contoso.com", "fd00:0db8::4")
+}
+
+func TestFakeDNSServerAddRecordE(t *testing.T) {
+	s := SetupFakeDNSServer(t)
+	cases := []struct {
+		hostname   string
+		recordType DNSRecordType
+		value      string
+	}{
+		{"host.contoso.com", DNSRecordTypeA, "fd00::4"},
+		{"host.contoso.com", DNSRecordTypeAAAA, "10.0.1.4"},
+		{"host.contoso.com", DNSRecordTypeA, "host"},
+		{"host.contoso.com", DNSRecordTypeCNAME, ""},
+		{"_sip._tcp.contoso.com", DNSRecordTypeSRV, "0 5 sip.contoso.com"},
+		{"_sip._tcp.contoso.com", DNSRecordTypeSRV, "0 5 65536 sip.contoso.com"},
+		{"", DNSRecordTypeA, "10.0.1.4"},
+		{"host.contoso.com", DNSRecordType("MX"), "10 mail.contoso.com"},
+	}
+	for _, c := range cases {
+		assert.Error(t, s.AddRecordE(c.hostname, c.recordType, c.value), "%s %s %s", c.hostname, c.recordType, c.value)
+	}
+}
+
+func TestResolveCNAMEChainE(t *testing.T) {
+	s := setupPrivateLinkDNS(t)
+	s.AddRecord(t, "app.contoso.com", DNSRecordTypeCNAME, "MyStorage.blob.core.windows.net")
+
+	chain, err := ResolveCNAMEChainE(s.Address(), "App.Contoso.com.")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.contoso.com", "mystorage.blob.core.windows.net", "mystorage.privatelink.blob.core.windows.net"}, chain)
+
+	chain, err = ResolveCNAMEChainE(s.Address(), "mystorage.privatelink.blob.core.windows.net")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mystorage.privatelink.blob.core.windows.net"}, chain)
+
+	// a CNAME to a name without records ends the chain
+	s.AddRecord(t, "dangling.contoso.com", DNSRecordTypeCNAME, "gone.azurewebsites.net")
+	chain, err = ResolveCNAMEChainE(s.Address(), "dangling.contoso.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dangling.contoso.com", "gone.azurewebsites.net"}, chain)
+
+	AssertDNSCNAMEChain(t, s.Address(), "app.contoso.com", "mystorage.blob.core.windows.net", "*.PrivateLink.blob.core.windows.net")
+	AssertDNSCNAMEChain(t, s.Address(), "public.blob.core.windows.net", "*.store.core.windows.net")
+}
+
+func TestResolveCNAMEChainELoop(t *testing.T) {
+	s := SetupFakeDNSServer(t)
+	s.AddRecord(t, "a.contoso.com", DNSRecordTypeCNAME, "b.contoso.com")
+	s.AddRecord(t, "b.contoso.com", DNSRecordTypeCNAME, "a.contoso.com")
+
+	_, err := ResolveCNAMEChainE(s.Address(), "a.contoso.com")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "CNAME loop or chain longer than 10 names resolving a.contoso.com: a.contoso.com -> b.contoso.com")
+	}
+}
+
+func TestAssertDNSResolvesThroughPrivateLink(t *testing.T) {
+	s := setupPrivateLinkDNS(t)
+
+	AssertDNSResolvesThroughPrivateLink(t, s.Address(), "mystorage.blob.core.windows.net", "10.0.1.4")
+	AssertDNSResolvesTo(t, s.Address(), "public.blob.core.windows.net", "20.38.100.1")
+	AssertDNSRecords(t, s.Address(), "mystorage.blob.core.windows.net", DNSRecordTypeCNAME, "MyStorage.PrivateLink.blob.core.windows.net.")
+}
+
+func TestDNSServerAddress(t *testing.T) {
+	cases := []struct {
+		server   string
+		expected string
+	}{
+		{"168.63.129.16", "168.63.129.16:53"},
+		{"10.0.0.4:5353", "10.0.0.4:5353"},
+		{"fd00::4", "[fd00::4]:53"},
+		{"[fd00::4]", "[fd00::4]:53"},
+		{"[fd00::4]:5353", "[fd00::4]:5353"},
+	}
+	for _, c := range cases {
+		address, err := dnsServerAddress(c.server)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, address, c.server)
+	}
+}
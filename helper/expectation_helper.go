@@ -0,0 +1,557 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ExpectationFile describes expected infrastructure in YAML, e.g.
+//
+//	resourceGroup: rg-hub
+//	resources:
+//	  - type: Microsoft.Network/virtualNetworks
+//	    name: vnet-hub
+//	    properties:
+//	      location: westeurope
+//	      properties.addressSpace.addressPrefixes[0]: 10.0.0.0/16
+//	      tags.environment:
+//	        regex: ^(dev|test|prod)$
+//	      properties.subnets:
+//	        count: 3
+//
+// ${VAR} references are replaced by environment variables before the file is parsed.
+type ExpectationFile struct {
+	// SubscriptionID is the default subscription of the resources, ARM_SUBSCRIPTION_ID if empty
+	SubscriptionID string `mapstructure:"subscriptionId"`
+	// ResourceGroup is the default resource group of the resources
+	ResourceGroup string `mapstructure:"resourceGroup"`
+	// Resources are the expected resources
+	Resources []ResourceExpectation `mapstructure:"resources"`
+}
+
+// ResourceExpectation describes an expected resource, given by type and name or by ID
+type ResourceExpectation struct {
+	// Type is the resource type, e.g. Microsoft.Network/virtualNetworks/subnets
+	Type string `mapstructure:"type"`
+	// Name is the resource name, with the parent names for child resources, e.g. vnet-hub/snet-app
+	Name           string `mapstructure:"name"`
+	ResourceGroup  string `mapstructure:"resourceGroup"`
+	SubscriptionID string `mapstructure:"subscriptionId"`
+	// ID is the resource ID, used instead of type and name
+	ID string `mapstructure:"id"`
+	// Exists is false for resources that must not exist
+	Exists *bool `mapstructure:"exists"`
+	// Properties maps property paths (see GetPropertyByPathE) to their expected value: either a value
+	// (a list is compared item by item) or a map with one or more of equals, notEquals, regex, oneOf,
+	// contains, count and exists
+	Properties map[string]interface{} `mapstructure:"properties"`
+}
+
+// ExpectationMismatch is a difference between an expectation and the deployed infrastructure
+type ExpectationMismatch struct {
+	// Resource is the resource ID
+	Resource string
+	// Property is the property path, empty when the resource itself is in error
+	Property string
+	Message  string
+}
+
+func (m ExpectationMismatch) String() string {
+	if m.Property == "" {
+		return fmt.Sprintf("%s: %s", m.Resource, m.Message)
+	}
+	return fmt.Sprintf("%s: %s %s", m.Resource, m.Property, m.Message)
+}
+
+// ResourceGetter returns a resource from its resource ID
+type ResourceGetter func(ctx context.Context, resourceID string) (interface{}, error)
+
+// resourceGetters are the getters used by the expectations, by lower case resource type.
+// Other types are read with GetResourceByIDWithContextE.
+var resourceGetters = struct {
+	mu      sync.Mutex
+	getters map[string]ResourceGetter
+}{getters: map[string]ResourceGetter{
+	"microsoft.resources/resourcegroups": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetResourceGroupByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.compute/virtualmachines": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetVirtualMachineByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.compute/disks": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetDiskByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/networkinterfaces": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetInterfaceByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.storage/storageaccounts": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetStorageAccountByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/virtualnetworks": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetVirtualNetworkByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/virtualnetworks/virtualnetworkpeerings": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetVirtualNetworkPeeringByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/virtualnetworks/subnets": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetSubnetByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.keyvault/vaults": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetKeyVaultByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/privateendpoints": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetPrivateEndpointByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/privateendpoints/privatednszonegroups": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetPrivateDNSZoneGroupByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/privatednszones": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetPrivateDNSZoneByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/privatednszones/virtualnetworklinks": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetVirtualNetworkLinkByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/routetables": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetRouteTableByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/networksecuritygroups": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetSecurityGroupByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.containerregistry/registries": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetRegistryByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/bastionhosts": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetBastionHostByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/applicationgateways": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetApplicationGatewayByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/publicipaddresses": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetPublicIPAddressByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.managedidentity/userassignedidentities": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetUserAssignedIdentityByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.containerservice/managedclusters": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetManagedClusterByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.compute/availabilitysets": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetAvailabilitySetByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.sql/managedinstances": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetManagedInstanceByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.operationalinsights/workspaces": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetLogAnalyticsWorkspaceByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.operationsmanagement/solutions": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetSolutionByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/azurefirewalls": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetFirewallByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/ddosprotectionplans": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetDdosProtectionPlanByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.recoveryservices/vaults": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetRecoveryServicesVaultByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.recoveryservices/vaults/backupfabrics/protectioncontainers/protecteditems": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetProtectedItemByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.cache/redis": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetRedisByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/virtualnetworkgateways": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetVirtualNetworkGatewayByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.dbformysql/servers": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetMySQLServerByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.dbformysql/servers/databases": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetMySQLDatabaseByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.documentdb/databaseaccounts": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetCosmosDatabaseAccountByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.documentdb/databaseaccounts/cassandrakeyspaces": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetCassandraKeySpaceByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.eventhub/namespaces": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetEventHubNamespaceByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.eventhub/namespaces/eventhubs": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetEventHubByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.web/serverfarms": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetAppServicePlanByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.web/sites": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetSiteByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.web/sites/functions": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetFunctionByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.sql/servers": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetSQLServerByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.compute/virtualmachinescalesets": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetVMScaleSetByIDWithContextE(ctx, resourceID)
+	},
+	"microsoft.network/frontdoors": func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetFrontDoorByIDWithContextE(ctx, resourceID)
+	},
+}}
+
+// RegisterResourceGetter makes the expectations read resources of resourceType with getter
+func RegisterResourceGetter(resourceType string, getter ResourceGetter) {
+	resourceGetters.mu.Lock()
+	defer resourceGetters.mu.Unlock()
+	resourceGetters.getters[strings.ToLower(resourceType)] = getter
+}
+
+func getResourceGetter(resourceType string) ResourceGetter {
+	resourceGetters.mu.Lock()
+	defer resourceGetters.mu.Unlock()
+	if getter, ok := resourceGetters.getters[strings.ToLower(resourceType)]; ok {
+		return getter
+	}
+	return func(ctx context.Context, resourceID string) (interface{}, error) {
+		return GetResourceByIDWithContextE(ctx, resourceID)
+	}
+}
+
+// LoadExpectationsE reads an expectation file, see ExpectationFile
+func LoadExpectationsE(filePath string) (*ExpectationFile, error) {
+	expectations := ExpectationFile{}
+	if err := decodeYamlFileE(filePath, &expectations); err != nil {
+		return nil, err
+	}
+	for i, resource := range expectations.Resources {
+		if _, err := expectations.resourceID(resource); err != nil {
+			return nil, fmt.Errorf("Invalid expectation file %s: resource %d: %s", filePath, i+1, err)
+		}
+	}
+	return &expectations, nil
+}
+
+// resourceID builds the resource ID of an expected resource
+func (f *ExpectationFile) resourceID(resource ResourceExpectation) (string, error) {
+	if resource.ID != "" {
+		_, err := ParseResourceIDE(resource.ID)
+		return resource.ID, err
+	}
+	if resource.Type == "" || resource.Name == "" {
+		return "", fmt.Errorf("either id or type and name must be set")
+	}
+	subscriptionID := resource.SubscriptionID
+	if subscriptionID == "" {
+		subscriptionID = getTargetSubscription(f.SubscriptionID)
+	}
+	resourceGroup := resource.ResourceGroup
+	if resourceGroup == "" {
+		resourceGroup = f.ResourceGroup
+	}
+	if strings.EqualFold(resource.Type, "Microsoft.Resources/resourceGroups") {
+		return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", subscriptionID, resource.Name), nil
+	}
+	if resourceGroup == "" {
+		return "", fmt.Errorf("no resource group set for %s %s", resource.Type, resource.Name)
+	}
+	typeParts := strings.Split(resource.Type, "/")
+	names := strings.Split(resource.Name, "/")
+	if len(typeParts) < 2 || len(typeParts)-1 != len(names) {
+		return "", fmt.Errorf("name %s does not match resource type %s", resource.Name, resource.Type)
+	}
+	id := ResourceID{
+		SubscriptionID: subscriptionID,
+		ResourceGroup:  resourceGroup,
+		Provider:       typeParts[0],
+		Types:          typeParts[1:],
+		Names:          names,
+	}
+	return id.String(), nil
+}
+
+// CheckExpectationsE fetches every resource of an expectation file and returns all the mismatches.
+// An error is only returned when the file is invalid.
+func CheckExpectationsE(filePath string) ([]ExpectationMismatch, error) {
+	return CheckExpectationsWithContextE(context.Background(), filePath)
+}
+
+// CheckExpectationsWithContextE fetches every resource of an expectation file and returns all the mismatches.
+// An error is only returned when the file is invalid.
+func CheckExpectationsWithContextE(ctx context.Context, filePath string) ([]ExpectationMismatch, error) {
+	expectations, err := LoadExpectationsE(filePath)
+	if err != nil {
+		return nil, err
+	}
+	mismatches := make([]ExpectationMismatch, 0)
+	for _, resource := range expectations.Resources {
+		resourceID, _ := expectations.resourceID(resource)
+		mismatches = append(mismatches, checkResourceExpectation(ctx, resourceID, resource)...)
+	}
+	return mismatches, nil
+}
+
+// AssertExpectations checks an expectation file and reports every mismatch as a test error
+func AssertExpectations(t *testing.T, filePath string) {
+	t.Helper()
+	mismatches, err := CheckExpectationsWithContextE(NewTestContext(t), filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mismatch := range mismatches {
+		t.Error(mismatch.String())
+	}
+}
+
+func checkResourceExpectation(ctx context.Context, resourceID string, expectation ResourceExpectation) []ExpectationMismatch {
+	id, err := ParseResourceIDE(resourceID)
+	if err != nil {
+		return []ExpectationMismatch{{Resource: resourceID, Message: err.Error()}}
+	}
+	resource, err := getResourceGetter(id.ResourceType())(ctx, resourceID)
+	mustExist := expectation.Exists == nil || *expectation.Exists
+	if !mustExist {
+		if err == nil {
+			return []ExpectationMismatch{{Resource: resourceID, Message: "exists but is expected not to"}}
+		}
+		if isNotFoundError(err) {
+			return nil
+		}
+	}
+	if err != nil {
+		message := "can not be read"
+		if isNotFoundError(err) {
+			message = "does not exist"
+		}
+		return []ExpectationMismatch{{Resource: resourceID, Message: fmt.Sprintf("%s: %s", message, err)}}
+	}
+
+	paths := make([]string, 0, len(expectation.Properties))
+	for path := range expectation.Properties {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	mismatches := make([]ExpectationMismatch, 0)
+	for _, path := range paths {
+		actual, err := GetPropertyByPathE(resource, path)
+		found := err == nil
+		for _, message := range checkPropertyExpectation(actual, found, expectation.Properties[path]) {
+			mismatches = append(mismatches, ExpectationMismatch{Resource: resourceID, Property: path, Message: message})
+		}
+	}
+	return mismatches
+}
+
+// isNotFoundError tells whether err is a 404 from Azure Resource Manager
+func isNotFoundError(err error) bool {
+	switch GetARMErrorCode(err) {
+	case "ResourceNotFound", "ResourceGroupNotFound", "NotFound", "ParentResourceNotFound":
+		return true
+	}
+	return strings.Contains(err.Error(), "StatusCode=404")
+}
+
+// propertyOperators are the keys of a map expectation
+var propertyOperators = map[string]bool{
+	"equals": true, "notEquals": true, "regex": true, "oneOf": true, "contains": true, "count": true, "exists": true,
+}
+
+// checkPropertyExpectation returns a message for each failed expectation on a property
+func checkPropertyExpectation(actual interface{}, found bool, expected interface{}) []string {
+	operators, ok := toStringMap(expected)
+	if !ok || len(operators) == 0 {
+		operators = map[string]interface{}{"equals": expected}
+	}
+	for name := range operators {
+		if !propertyOperators[name] {
+			// a map value that isn't made of operators is compared as is
+			operators = map[string]interface{}{"equals": expected}
+			break
+		}
+	}
+	if exists, ok := operators["exists"].(bool); ok {
+		if exists != (found && actual != nil) {
+			if exists {
+				return []string{"is missing"}
+			}
+			return []string{fmt.Sprintf("is %s, expected to be missing", formatValue(actual))}
+		}
+		if !exists {
+			return nil
+		}
+	}
+	if !found {
+		return []string{"is missing"}
+	}
+
+	messages := make([]string, 0)
+	names := make([]string, 0, len(operators))
+	for name := range operators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := operators[name]
+		switch name {
+		case "equals":
+			if !valuesEqual(actual, value) {
+				messages = append(messages, fmt.Sprintf("is %s, expected %s", formatValue(actual), formatValue(value)))
+			}
+		case "notEquals":
+			if valuesEqual(actual, value) {
+				messages = append(messages, fmt.Sprintf("is %s, expected anything else", formatValue(actual)))
+			}
+		case "regex":
+			pattern, err := regexp.Compile(fmt.Sprint(value))
+			if err != nil {
+				messages = append(messages, fmt.Sprintf("has an invalid regex %s: %s", value, err))
+			} else if !pattern.MatchString(scalarString(actual)) {
+				messages = append(messages, fmt.Sprintf("is %s, expected to match %s", formatValue(actual), value))
+			}
+		case "oneOf":
+			options, ok := value.([]interface{})
+			if !ok {
+				messages = append(messages, "has a oneOf expectation that is not a list")
+				break
+			}
+			match := false
+			for _, option := range options {
+				match = match || valuesEqual(actual, option)
+			}
+			if !match {
+				messages = append(messages, fmt.Sprintf("is %s, expected one of %s", formatValue(actual), formatValue(value)))
+			}
+		case "contains":
+			if !valueContains(actual, value) {
+				messages = append(messages, fmt.Sprintf("is %s, expected to contain %s", formatValue(actual), formatValue(value)))
+			}
+		case "count":
+			count := -1
+			if v := indirectValue(reflect.ValueOf(actual)); v.IsValid() && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array || v.Kind() == reflect.Map) {
+				count = v.Len()
+			} else if actual == nil {
+				count = 0
+			}
+			if fmt.Sprint(count) != fmt.Sprint(value) {
+				messages = append(messages, fmt.Sprintf("has %d items, expected %v", count, value))
+			}
+		}
+	}
+	return messages
+}
+
+// toStringMap converts the maps decoded from YAML
+func toStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			converted[fmt.Sprint(k)] = v
+		}
+		return converted, true
+	}
+	return nil, false
+}
+
+// scalarString formats a value for comparison, so that 3, "3" and int32(3) are equal
+func scalarString(value interface{}) string {
+	v := indirectValue(reflect.ValueOf(value))
+	if !v.IsValid() {
+		return ""
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// valuesEqual compares an actual value to an expected one, lists item by item
+func valuesEqual(actual interface{}, expected interface{}) bool {
+	expectedList, isList := expected.([]interface{})
+	if !isList {
+		if _, isMap := toStringMap(expected); isMap {
+			return reflect.DeepEqual(normalizeValue(actual), normalizeValue(expected))
+		}
+		return scalarString(actual) == scalarString(expected)
+	}
+	v := indirectValue(reflect.ValueOf(actual))
+	if !v.IsValid() || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || v.Len() != len(expectedList) {
+		return false
+	}
+	for i := range expectedList {
+		if !valuesEqual(v.Index(i).Interface(), expectedList[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// valueContains tells whether a list contains an item or a string contains a substring
+func valueContains(actual interface{}, expected interface{}) bool {
+	v := indirectValue(reflect.ValueOf(actual))
+	if !v.IsValid() {
+		return false
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			if valuesEqual(v.Index(i).Interface(), expected) {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(scalarString(actual), scalarString(expected))
+}
+
+// normalizeValue turns nested values into maps of strings so they can be compared
+func normalizeValue(value interface{}) interface{} {
+	if m, ok := toStringMap(value); ok {
+		normalized := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			normalized[k] = normalizeValue(v)
+		}
+		return normalized
+	}
+	v := indirectValue(reflect.ValueOf(value))
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		normalized := make([]interface{}, v.Len())
+		for i := range normalized {
+			normalized[i] = normalizeValue(v.Index(i).Interface())
+		}
+		return normalized
+	case reflect.Map:
+		normalized := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			normalized[fmt.Sprint(key.Interface())] = normalizeValue(v.MapIndex(key).Interface())
+		}
+		return normalized
+	}
+	return scalarString(value)
+}
+
+// formatValue formats a value for a mismatch message
+func formatValue(value interface{}) string {
+	normalized := normalizeValue(value)
+	if normalized == nil {
+		return "null"
+	}
+	if s, ok := normalized.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(normalized)
+}
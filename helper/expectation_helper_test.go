@@ -0,0 +1,138 @@
+package helper
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupExpectationResources seeds the resources checked by testdata/expectations
+func setupExpectationResources(t *testing.T) *FakeARMServer {
+	t.Setenv("TEST_EXPECTATION_SUBSCRIPTION", testSubscriptionID)
+	s := setupTestARMServer(t)
+	s.AddResource(t, testVnetID, `{
+		"location": "westeurope",
+		"tags": {"environment": "test"},
+		"properties": {
+			"addressSpace": {"addressPrefixes": ["10.0.0.0/16"]},
+			"subnets": [
+				{"id": "`+testVnetID+`/subnets/snet-a", "name": "snet-a", "properties": {"addressPrefix": "10.0.1.0/24"}},
+				{"id": "`+testVnetID+`/subnets/snet-b", "name": "snet-b", "properties": {"addressPrefix": "10.0.2.0/24"}}
+			]
+		}
+	}`)
+	s.AddResource(t, testResourceGroupID+"/providers/Microsoft.Storage/storageAccounts/sttest", `{
+		"location": "westeurope",
+		"properties": {"minimumTlsVersion": "TLS1_2", "networkAcls": {"defaultAction": "Deny"}}
+	}`)
+	return s
+}
+
+func TestLoadExpectationsE(t *testing.T) {
+	t.Setenv("TEST_EXPECTATION_SUBSCRIPTION", testSubscriptionID)
+	expectations, err := LoadExpectationsE(filepath.Join("testdata", "expectations", "vnet.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, testSubscriptionID, expectations.SubscriptionID)
+	require.Len(t, expectations.Resources, 4)
+
+	ids := make([]string, 0)
+	for _, resource := range expectations.Resources {
+		id, err := expectations.resourceID(resource)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	assert.Equal(t, []string{
+		testVnetID,
+		testVnetID + "/subnets/snet-a",
+		testResourceGroupID + "/providers/Microsoft.Storage/storageAccounts/sttest",
+		testResourceGroupID + "/providers/Microsoft.Network/publicIPAddresses/pip-forbidden",
+	}, ids)
+	assert.False(t, *expectations.Resources[3].Exists)
+	assert.Equal(t, []interface{}{"10.0.0.0/16"}, expectations.Resources[0].Properties["properties.addressSpace.addressPrefixes"])
+}
+
+func TestLoadExpectationsEInvalid(t *testing.T) {
+	for file, message := range map[string]string{
+		"invalid_name.yaml": "does not match resource type",
+		"unknown_key.yaml":  "propertes",
+		"missing.yaml":      "Can not read",
+	} {
+		_, err := LoadExpectationsE(filepath.Join("testdata", "expectations", file))
+		if assert.Error(t, err, file) {
+			assert.Contains(t, err.Error(), message, file)
+		}
+	}
+}
+
+func TestCheckExpectationsE(t *testing.T) {
+	setupExpectationResources(t)
+	mismatches, err := CheckExpectationsE(filepath.Join("testdata", "expectations", "vnet.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
+
+func TestCheckExpectationsEMismatches(t *testing.T) {
+	setupExpectationResources(t)
+	mismatches, err := CheckExpectationsE(filepath.Join("testdata", "expectations", "mismatches.yaml"))
+	require.NoError(t, err)
+
+	messages := make([]string, 0, len(mismatches))
+	for _, mismatch := range mismatches {
+		message := strings.TrimPrefix(mismatch.String(), testResourceGroupID+"/providers/Microsoft.Network/")
+		// keep the reason of read errors only
+		if i := strings.Index(message, "does not exist:"); i >= 0 {
+			message = message[:i+len("does not exist")]
+		}
+		messages = append(messages, message)
+	}
+	assert.Equal(t, []string{
+		`virtualNetworks/vnet-test: location is "westeurope", expected "northeurope"`,
+		`virtualNetworks/vnet-test: properties.dhcpOptions.dnsServers is missing`,
+		`virtualNetworks/vnet-test: properties.subnets has 2 items, expected 3`,
+		`virtualNetworks/vnet-test: tags.environment is "test", expected anything else`,
+		`virtualNetworks/vnet-missing: does not exist`,
+		`virtualNetworks/vnet-test/subnets/snet-b: exists but is expected not to`,
+	}, messages)
+}
+
+func TestCheckPropertyExpectation(t *testing.T) {
+	cases := []struct {
+		name     string
+		actual   interface{}
+		found    bool
+		expected interface{}
+		messages []string
+	}{
+		{"equal scalars of different types", int32(3), true, "3", nil},
+		{"different scalars", "Standard", true, "Premium", []string{`is "Standard", expected "Premium"`}},
+		{"list item by item", []string{"a", "b"}, true, []interface{}{"a", "b"}, nil},
+		{"list of another length", []string{"a"}, true, []interface{}{"a", "b"}, []string{`is [a], expected [a b]`}},
+		{"map as is", map[string]interface{}{"env": "dev"}, true, map[interface{}]interface{}{"env": "dev"}, nil},
+		{"missing", nil, false, "x", []string{"is missing"}},
+		{"exists", "x", true, map[interface{}]interface{}{"exists": true}, nil},
+		{"null is missing", nil, true, map[interface{}]interface{}{"exists": true}, []string{"is missing"}},
+		{"expected missing", "x", true, map[interface{}]interface{}{"exists": false}, []string{`is "x", expected to be missing`}},
+		{"regex", "prod", true, map[interface{}]interface{}{"regex": "^(dev|prod)$"}, nil},
+		{"invalid regex", "prod", true, map[interface{}]interface{}{"regex": "("}, []string{"has an invalid regex (: error parsing regexp: missing closing ): `(`"}},
+		{"one of", "B2", true, map[interface{}]interface{}{"oneOf": []interface{}{"B1", "B2"}}, nil},
+		{"one of not a list", "B2", true, map[interface{}]interface{}{"oneOf": "B2"}, []string{"has a oneOf expectation that is not a list"}},
+		{"list contains", []string{"10.0.0.4", "10.0.0.5"}, true, map[interface{}]interface{}{"contains": "10.0.0.5"}, nil},
+		{"string contains", "Standard_LRS", true, map[interface{}]interface{}{"contains": "GRS"}, []string{`is "Standard_LRS", expected to contain "GRS"`}},
+		{"count of null", nil, true, map[interface{}]interface{}{"count": 0}, nil},
+		{"several operators", "dev", true, map[interface{}]interface{}{"notEquals": "dev", "regex": "^p"},
+			[]string{`is "dev", expected anything else`, `is "dev", expected to match ^p`}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			messages := checkPropertyExpectation(c.actual, c.found, c.expected)
+			if len(c.messages) == 0 {
+				assert.Empty(t, messages)
+			} else {
+				assert.Equal(t, c.messages, messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+/********************************
+		Public exposure
+*********************************/
+
+// PublicExposure is a way a resource can be reached from the Internet
+type PublicExposure struct {
+	ResourceID   string
+	ResourceType string
+	Name         string
+	// PublicIPAddressID is the public IP address the exposure goes through, for NICs and load balancer frontends
+	PublicIPAddressID string
+	Description       string
+}
+
+func (e PublicExposure) String() string {
+	return fmt.Sprintf("%s %s: %s", e.ResourceType, e.Name, e.Description)
+}
+
+// allowed tells whether an entry of an allow list matches the resource or its public IP address. Entries are case
+// insensitive patterns as in path.Match of a resource name (pip-appgw, app-*) or type and name (Microsoft.Web/sites/app-*).
+// The patterns are validated by AuditPublicExposureWithContextE, so their errors are ignored.
+func (e PublicExposure) allowed(allowList []string) bool {
+	candidates := []string{e.ResourceID}
+	if e.PublicIPAddressID != "" {
+		candidates = append(candidates, e.PublicIPAddressID)
+	}
+	for _, candidate := range candidates {
+		id, err := ParseResourceIDE(candidate)
+		if err != nil {
+			continue
+		}
+		for _, pattern := range allowList {
+			pattern = strings.ToLower(pattern)
+			for _, name := range []string{id.Name(), id.ResourceType() + "/" + id.Name()} {
+				if matched, _ := path.Match(pattern, strings.ToLower(name)); matched {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// publicExposureCheck returns the exposures of a resource of a given type read as a generic map
+type publicExposureCheck func(resource map[string]interface{}) []string
+
+// publicExposureChecks are the checks of the resource types that can be reached from the Internet, by lower case type.
+// NICs and load balancers are handled apart as their exposures go through public IP addresses.
+var publicExposureChecks = map[string]publicExposureCheck{
+	"microsoft.network/publicipaddresses": func(resource map[string]interface{}) []string {
+		description := "public IP address"
+		if address := genericString(resource, "properties.ipAddress"); address != "" {
+			description += " " + address
+		}
+		if attachedTo := genericString(resource, "properties.ipConfiguration.id"); attachedTo != "" {
+			description += " attached to " + attachedTo
+		}
+		return []string{description}
+	},
+	"microsoft.storage/storageaccounts": func(resource map[string]interface{}) []string {
+		exposures := networkAclExposures(resource, "properties.networkAcls", "value")
+		if genericBool(resource, "properties.allowBlobPublicAccess") {
+			exposures = append(exposures, "anonymous access to blobs allowed")
+		}
+		return exposures
+	},
+	"microsoft.keyvault/vaults": func(resource map[string]interface{}) []string {
+		return networkAclExposures(resource, "properties.networkAcls", "value")
+	},
+	"microsoft.containerregistry/registries": func(resource map[string]interface{}) []string {
+		return networkAclExposures(resource, "properties.networkRuleSet", "value")
+	},
+	"microsoft.documentdb/databaseaccounts": func(resource map[string]interface{}) []string {
+		if publicNetworkAccessDisabled(resource) {
+			return nil
+		}
+		ipRules := genericStrings(resource, "properties.ipRules", "ipAddressOrRange")
+		if filter := genericString(resource, "properties.ipRangeFilter"); filter != "" {
+			for _, ipRule := range strings.Split(filter, ",") {
+				ipRules = append(ipRules, strings.TrimSpace(ipRule))
+			}
+		}
+		if len(ipRules) > 0 {
+			return []string{"network access allowed from " + strings.Join(ipRules, ", ")}
+		}
+		if !genericBool(resource, "properties.isVirtualNetworkFilterEnabled") {
+			return []string{"network access allowed from all networks"}
+		}
+		return nil
+	},
+	"microsoft.sql/servers":        publicNetworkAccessExposures,
+	"microsoft.cache/redis":        publicNetworkAccessExposures,
+	"microsoft.web/sites":          publicNetworkAccessExposures,
+	"microsoft.dbformysql/servers": publicNetworkAccessExposures,
+}
+
+// GetPublicExposuresE returns every way the resources of a resource group can be reached from the Internet:
+// public IP addresses, NIC IP configurations and load balancer or application gateway frontends using them,
+// and storage accounts, key vaults, SQL and MySQL servers, Cosmos DB accounts, Redis caches, container registries
+// and web apps whose public network access is enabled (and not restricted to private networks by their network rules).
+// Resources are read with the latest API version of their type, so the newest network access properties are known.
+func GetPublicExposuresE(resourceGroupName string, subscriptionID ...string) ([]PublicExposure, error) {
+	return GetPublicExposuresWithContextE(context.Background(), resourceGroupName, subscriptionID...)
+}
+
+// GetPublicExposuresWithContextE returns every way the resources of a resource group can be reached from the Internet,
+// see GetPublicExposuresE
+func GetPublicExposuresWithContextE(ctx context.Context, resourceGroupName string, subscriptionID ...string) ([]PublicExposure, error) {
+	list, err := ListResourcesInResourceGroupWithContextE(ctx, resourceGroupName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+	exposures := make([]PublicExposure, 0)
+	for _, item := range list {
+		if item.ID == nil || item.Type == nil {
+			continue
+		}
+		resourceType := strings.ToLower(*item.Type)
+		check, ok := publicExposureChecks[resourceType]
+		frontends := ""
+		switch resourceType {
+		case "microsoft.network/networkinterfaces":
+			frontends = "properties.ipConfigurations"
+		case "microsoft.network/loadbalancers", "microsoft.network/applicationgateways":
+			frontends = "properties.frontendIPConfigurations"
+		}
+		if !ok && frontends == "" {
+			continue
+		}
+
+		resource, err := GetResourceByIDWithContextE(ctx, *item.ID)
+		if err != nil {
+			return nil, err
+		}
+		exposure := PublicExposure{ResourceID: *item.ID, ResourceType: *item.Type, Name: stringValue(item.Name)}
+		if ok {
+			for _, description := range check(resource) {
+				exposure.Description = description
+				exposures = append(exposures, exposure)
+			}
+			continue
+		}
+		for _, configuration := range genericList(resource, frontends) {
+			publicIPID := genericString(configuration, "properties.publicIPAddress.id")
+			if publicIPID == "" {
+				continue
+			}
+			exposure.PublicIPAddressID = publicIPID
+			exposure.Description = fmt.Sprintf("IP configuration %s uses public IP address %s", genericString(configuration, "name"), publicIPID)
+			exposures = append(exposures, exposure)
+		}
+	}
+	sort.SliceStable(exposures, func(i, j int) bool {
+		return strings.ToLower(exposures[i].ResourceID) < strings.ToLower(exposures[j].ResourceID)
+	})
+	return exposures, nil
+}
+
+// AuditPublicExposureE returns the public exposures of a resource group not allowed by allowList, whose entries are
+// case insensitive patterns as in path.Match of a resource name (pip-appgw) or of its type and name (Microsoft.Web/sites/app-*).
+// The exposures of NICs and load balancer frontends are also allowed by allowing their public IP address.
+func AuditPublicExposureE(resourceGroupName string, allowList []string, subscriptionID ...string) ([]PublicExposure, error) {
+	return AuditPublicExposureWithContextE(context.Background(), resourceGroupName, allowList, subscriptionID...)
+}
+
+// AuditPublicExposureWithContextE returns the public exposures of a resource group not allowed by allowList, see AuditPublicExposureE
+func AuditPublicExposureWithContextE(ctx context.Context, resourceGroupName string, allowList []string, subscriptionID ...string) ([]PublicExposure, error) {
+	for _, pattern := range allowList {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("Invalid pattern %q in the public exposure allow list: %s", pattern, err)
+		}
+	}
+	exposures, err := GetPublicExposuresWithContextE(ctx, resourceGroupName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+	unexpected := make([]PublicExposure, 0)
+	for _, exposure := range exposures {
+		if !exposure.allowed(allowList) {
+			unexpected = append(unexpected, exposure)
+		}
+	}
+	return unexpected, nil
+}
+
+// AuditPublicExposure fails the test with every public exposure of a resource group not allowed by allowList, see AuditPublicExposureE
+func AuditPublicExposure(t *testing.T, resourceGroupName string, allowList []string, subscriptionID ...string) {
+	t.Helper()
+	unexpected, err := AuditPublicExposureWithContextE(NewTestContext(t), resourceGroupName, allowList, subscriptionID...)
+	failOnAzureError(t, err, "audit public exposure of", "Microsoft.Resources/resourceGroups", resourceGroupName, "")
+	if len(unexpected) == 0 {
+		return
+	}
+	lines := make([]string, 0, len(unexpected))
+	for _, exposure := range unexpected {
+		lines = append(lines, exposure.String())
+	}
+	t.Errorf("Resource group %s has %d public exposures not allowed:\n\t%s", resourceGroupName, len(unexpected), strings.Join(lines, "\n\t"))
+}
+
+// networkAclExposures checks the public network access flag and the network rules at aclPath, whose IP rules have
+// their address in ipRuleField
+func networkAclExposures(resource map[string]interface{}, aclPath string, ipRuleField string) []string {
+	if publicNetworkAccessDisabled(resource) {
+		return nil
+	}
+	if !strings.EqualFold(genericString(resource, aclPath+".defaultAction"), "Deny") {
+		return []string{"network access allowed from all networks"}
+	}
+	if ipRules := genericStrings(resource, aclPath+".ipRules", ipRuleField); len(ipRules) > 0 {
+		return []string{"network access allowed from " + strings.Join(ipRules, ", ")}
+	}
+	return nil
+}
+
+func publicNetworkAccessExposures(resource map[string]interface{}) []string {
+	if publicNetworkAccessDisabled(resource) {
+		return nil
+	}
+	return []string{"public network access enabled"}
+}
+
+func publicNetworkAccessDisabled(resource map[string]interface{}) bool {
+	return strings.EqualFold(genericString(resource, "properties.publicNetworkAccess"), "Disabled")
+}
+
+// genericString returns the string at path of a generic resource, or an empty string when it is missing
+func genericString(resource interface{}, path string) string {
+	value, err := GetPropertyByPathE(resource, path)
+	if err != nil || value == nil {
+		return ""
+	}
+	return scalarString(value)
+}
+
+func genericBool(resource interface{}, path string) bool {
+	value, err := GetPropertyByPathE(resource, path)
+	if err != nil {
+		return false
+	}
+	b, ok := value.(bool)
+	return ok && b
+}
+
+func genericList(resource interface{}, path string) []interface{} {
+	value, err := GetPropertyByPathE(resource, path)
+	if err != nil {
+		return nil
+	}
+	list, _ := value.([]interface{})
+	return list
+}
+
+// genericStrings returns field of each item of the list at path of a generic resource
+func genericStrings(resource interface{}, path string, field string) []string {
+	values := make([]string, 0)
+	for _, item := range genericList(resource, path) {
+		if value := genericString(item, field); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,172 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testResourceID returns the ID of a resource of rg-test
+func testResourceID(resourceType string, name string) string {
+	return testResourceGroupID + "/providers/" + resourceType + "/" + name
+}
+
+// exposureStrings returns the exposures as type name: description
+func exposureStrings(exposures []PublicExposure) []string {
+	lines := make([]string, 0, len(exposures))
+	for _, exposure := range exposures {
+		lines = append(lines, exposure.String())
+	}
+	return lines
+}
+
+func TestGetPublicExposuresE(t *testing.T) {
+	pipID := testResourceID("Microsoft.Network/publicIPAddresses", "pip-test")
+	cases := []struct {
+		name         string
+		resourceType string
+		resource     string
+		exposures    []string
+	}{
+		// public IP addresses and the frontends using them
+		{"public IP address", "Microsoft.Network/publicIPAddresses",
+			`{"properties": {"ipAddress": "20.1.2.3", "ipConfiguration": {"id": "` + testResourceID("Microsoft.Network/networkInterfaces", "nic") + `/ipConfigurations/ipconfig1"}}}`,
+			[]string{"public IP address 20.1.2.3 attached to " + testResourceID("Microsoft.Network/networkInterfaces", "nic") + "/ipConfigurations/ipconfig1"}},
+		{"unassigned public IP address", "Microsoft.Network/publicIPAddresses", `{"properties": {}}`,
+			[]string{"public IP address"}},
+		{"NIC with public IP", "Microsoft.Network/networkInterfaces", `{"properties": {"ipConfigurations": [
+			{"name": "ipconfig1", "properties": {"privateIPAddress": "10.0.1.4", "publicIPAddress": {"id": "` + pipID + `"}}},
+			{"name": "ipconfig2", "properties": {"privateIPAddress": "10.0.1.5"}}
+		]}}`, []string{"IP configuration ipconfig1 uses public IP address " + pipID}},
+		{"NIC without public IP", "Microsoft.Network/networkInterfaces",
+			`{"properties": {"ipConfigurations": [{"name": "ipconfig1", "properties": {"privateIPAddress": "10.0.1.4"}}]}}`, nil},
+		{"public load balancer", "Microsoft.Network/loadBalancers",
+			`{"properties": {"frontendIPConfigurations": [{"name": "fe-public", "properties": {"publicIPAddress": {"id": "` + pipID + `"}}}]}}`,
+			[]string{"IP configuration fe-public uses public IP address " + pipID}},
+		{"internal load balancer", "Microsoft.Network/loadBalancers",
+			`{"properties": {"frontendIPConfigurations": [{"name": "fe-private", "properties": {"privateIPAddress": "10.0.1.10"}}]}}`, nil},
+		{"public application gateway", "Microsoft.Network/applicationGateways", `{"properties": {"frontendIPConfigurations": [
+			{"name": "appGwPublicFrontendIp", "properties": {"publicIPAddress": {"id": "` + pipID + `"}}},
+			{"name": "appGwPrivateFrontendIp", "properties": {"privateIPAddress": "10.0.1.20"}}
+		]}}`, []string{"IP configuration appGwPublicFrontendIp uses public IP address " + pipID}},
+		{"private application gateway", "Microsoft.Network/applicationGateways",
+			`{"properties": {"frontendIPConfigurations": [{"name": "appGwPrivateFrontendIp", "properties": {"privateIPAddress": "10.0.1.20"}}]}}`, nil},
+
+		// storage accounts
+		{"storage default allow", "Microsoft.Storage/storageAccounts",
+			`{"properties": {"networkAcls": {"defaultAction": "Allow"}}}`,
+			[]string{"network access allowed from all networks"}},
+		{"storage without network rules", "Microsoft.Storage/storageAccounts", `{"properties": {}}`,
+			[]string{"network access allowed from all networks"}},
+		{"storage default deny", "Microsoft.Storage/storageAccounts",
+			`{"properties": {"networkAcls": {"defaultAction": "Deny", "ipRules": []}}}`, nil},
+		{"storage default deny with IP rules", "Microsoft.Storage/storageAccounts",
+			`{"properties": {"networkAcls": {"defaultAction": "Deny", "ipRules": [{"value": "203.0.113.0/24", "action": "Allow"}, {"value": "198.51.100.7"}]}}}`,
+			[]string{"network access allowed from 203.0.113.0/24, 198.51.100.7"}},
+		{"storage default allow with IP rules", "Microsoft.Storage/storageAccounts",
+			`{"properties": {"networkAcls": {"defaultAction": "Allow", "ipRules": [{"value": "203.0.113.0/24"}]}}}`,
+			[]string{"network access allowed from all networks"}},
+		{"storage blob public access", "Microsoft.Storage/storageAccounts",
+			`{"properties": {"allowBlobPublicAccess": true, "networkAcls": {"defaultAction": "Deny"}}}`,
+			[]string{"anonymous access to blobs allowed"}},
+		{"storage blob public access disabled", "Microsoft.Storage/storageAccounts",
+			`{"properties": {"allowBlobPublicAccess": false, "networkAcls": {"defaultAction": "Deny"}}}`, nil},
+		{"storage public network access disabled", "Microsoft.Storage/storageAccounts",
+			`{"properties": {"publicNetworkAccess": "Disabled", "networkAcls": {"defaultAction": "Allow"}}}`, nil},
+
+		// Cosmos DB accounts
+		{"cosmos IP range filter", "Microsoft.DocumentDB/databaseAccounts",
+			`{"properties": {"ipRangeFilter": "203.0.113.4, 198.51.100.0/24", "isVirtualNetworkFilterEnabled": true}}`,
+			[]string{"network access allowed from 203.0.113.4, 198.51.100.0/24"}},
+		{"cosmos IP rules", "Microsoft.DocumentDB/databaseAccounts",
+			`{"properties": {"ipRules": [{"ipAddressOrRange": "203.0.113.4"}]}}`,
+			[]string{"network access allowed from 203.0.113.4"}},
+		{"cosmos virtual network filter", "Microsoft.DocumentDB/databaseAccounts",
+			`{"properties": {"ipRangeFilter": "", "isVirtualNetworkFilterEnabled": true}}`, nil},
+		{"cosmos open", "Microsoft.DocumentDB/databaseAccounts",
+			`{"properties": {"isVirtualNetworkFilterEnabled": false}}`,
+			[]string{"network access allowed from all networks"}},
+		{"cosmos public network access disabled", "Microsoft.DocumentDB/databaseAccounts",
+			`{"properties": {"publicNetworkAccess": "Disabled", "ipRangeFilter": "203.0.113.4"}}`, nil},
+
+		// public network access flag
+		{"SQL server", "Microsoft.Sql/servers", `{"properties": {"publicNetworkAccess": "Enabled"}}`,
+			[]string{"public network access enabled"}},
+		{"SQL server disabled", "Microsoft.Sql/servers", `{"properties": {"publicNetworkAccess": "Disabled"}}`, nil},
+		{"Redis cache", "Microsoft.Cache/redis", `{"properties": {}}`,
+			[]string{"public network access enabled"}},
+		{"Redis cache disabled", "Microsoft.Cache/redis", `{"properties": {"publicNetworkAccess": "disabled"}}`, nil},
+		{"web app", "Microsoft.Web/sites", `{"properties": {"publicNetworkAccess": "Enabled"}}`,
+			[]string{"public network access enabled"}},
+		{"web app disabled", "Microsoft.Web/sites", `{"properties": {"publicNetworkAccess": "Disabled"}}`, nil},
+
+		// other types are not checked
+		{"virtual network", "Microsoft.Network/virtualNetworks", `{"properties": {}}`, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := setupTestARMServer(t)
+			s.AddResource(t, testResourceID(c.resourceType, "res-test"), c.resource)
+
+			exposures, err := GetPublicExposuresE("rg-test")
+			require.NoError(t, err)
+			expected := make([]string, 0)
+			for _, description := range c.exposures {
+				expected = append(expected, c.resourceType+" res-test: "+description)
+			}
+			assert.Equal(t, expected, exposureStrings(exposures))
+		})
+	}
+}
+
+func TestAuditPublicExposureE(t *testing.T) {
+	pipID := testResourceID("Microsoft.Network/publicIPAddresses", "pip-vm")
+	nicID := testResourceID("Microsoft.Network/networkInterfaces", "nic-vm")
+	appID := testResourceID("Microsoft.Web/sites", "app-web")
+	storageID := testResourceID("Microsoft.Storage/storageAccounts", "stopen")
+
+	s := setupTestARMServer(t)
+	s.AddResource(t, pipID, `{"properties": {"ipAddress": "20.1.2.3"}}`)
+	s.AddResource(t, nicID, `{"properties": {"ipConfigurations": [{"name": "ipconfig1", "properties": {"publicIPAddress": {"id": "`+pipID+`"}}}]}}`)
+	s.AddResource(t, appID, `{"properties": {}}`)
+	s.AddResource(t, storageID, `{"properties": {"networkAcls": {"defaultAction": "Allow"}}}`)
+
+	cases := []struct {
+		name       string
+		allowList  []string
+		unexpected []string
+	}{
+		{"no allow list", nil, []string{nicID, pipID, storageID, appID}},
+		{"by name", []string{"app-web"}, []string{nicID, pipID, storageID}},
+		{"by name pattern", []string{"APP-*", "st*"}, []string{nicID, pipID}},
+		{"by type and name", []string{"Microsoft.Web/sites/app-*"}, []string{nicID, pipID, storageID}},
+		{"by type and name of another type", []string{"Microsoft.Web/sites/stopen", "microsoft.storage/*/app-web"}, []string{nicID, pipID, storageID, appID}},
+		{"through the public IP", []string{"pip-vm"}, []string{storageID, appID}},
+		{"through the public IP type and name", []string{"microsoft.network/publicipaddresses/pip-*"}, []string{storageID, appID}},
+		{"NIC only", []string{"nic-vm"}, []string{pipID, storageID, appID}},
+		{"everything", []string{"*"}, []string{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			unexpected, err := AuditPublicExposureE("rg-test", c.allowList)
+			require.NoError(t, err)
+			ids := make([]string, 0, len(unexpected))
+			for _, exposure := range unexpected {
+				ids = append(ids, exposure.ResourceID)
+			}
+			assert.Equal(t, c.unexpected, ids)
+		})
+	}
+
+	AuditPublicExposure(t, "rg-test", []string{"pip-vm", "app-web", "stopen"})
+}
+
+func TestAuditPublicExposureEBadPattern(t *testing.T) {
+	s := setupTestARMServer(t)
+
+	_, err := AuditPublicExposureE("rg-test", []string{"app-web", "pip-["})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `Invalid pattern "pip-[" in the public exposure allow list: syntax error in pattern`)
+	assert.Empty(t, s.Requests(), "the patterns are checked before listing the resources")
+}
@@ -0,0 +1,275 @@
+package helper
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.0/keyvault"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+// ClientFactory creates SDK clients and caches them together with their authorizers.
+// Authorizers are cached per token audience and clients per subscription and API version,
+// so credentials are only acquired once per test run. It is safe for concurrent use.
+type ClientFactory struct {
+	mu          sync.Mutex
+	environment *azure.Environment
+	retryPolicy *RetryPolicy
+	decorators  []autorest.SendDecorator
+	authorizer  autorest.Authorizer
+	authorizers map[string]autorest.Authorizer
+	clients     map[string]interface{}
+}
+
+// NewClientFactory creates an empty ClientFactory
+func NewClientFactory() *ClientFactory {
+	return &ClientFactory{
+		authorizers: make(map[string]autorest.Authorizer),
+		clients:     make(map[string]interface{}),
+	}
+}
+
+// defaultClientFactory is used by all the Get*ClientE helpers
+var defaultClientFactory = NewClientFactory()
+
+// DefaultClientFactory returns the ClientFactory used by all the Get*ClientE helpers
+func DefaultClientFactory() *ClientFactory {
+	return defaultClientFactory
+}
+
+// SetEnvironment sets the cloud used by the factory instead of the one in AZURE_ENVIRONMENT.
+// Cached authorizers and clients are dropped.
+func (f *ClientFactory) SetEnvironment(env azure.Environment) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.environment = &env
+	f.resetLocked()
+}
+
+// SetRetryPolicy sets the retry policy of the clients created by the factory instead of DefaultRetryPolicy.
+// Cached clients are dropped.
+func (f *ClientFactory) SetRetryPolicy(policy RetryPolicy) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.retryPolicy = &policy
+	f.clients = make(map[string]interface{})
+}
+
+// SetSendDecorators wraps the senders of the clients created by the factory, outside of the retries,
+// e.g. to record or replay the calls. Cached clients are dropped.
+func (f *ClientFactory) SetSendDecorators(decorators ...autorest.SendDecorator) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.decorators = decorators
+	f.clients = make(map[string]interface{})
+}
+
+// SetAuthorizer makes the factory use authorizer for every resource instead of the credential chain,
+// e.g. autorest.NullAuthorizer{} when no call reaches Azure. Pass nil to go back to the credential chain.
+// Cached clients are dropped.
+func (f *ClientFactory) SetAuthorizer(authorizer autorest.Authorizer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.authorizer = authorizer
+	f.clients = make(map[string]interface{})
+}
+
+// SendDecorators returns the decorators set with SetSendDecorators
+func (f *ClientFactory) SendDecorators() []autorest.SendDecorator {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.decorators
+}
+
+// Authorizer returns the authorizer set with SetAuthorizer, nil when the credential chain is used
+func (f *ClientFactory) Authorizer() autorest.Authorizer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.authorizer
+}
+
+// EnvironmentE returns the cloud used by the factory
+func (f *ClientFactory) EnvironmentE() (azure.Environment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.environmentLocked()
+}
+
+func (f *ClientFactory) environmentLocked() (azure.Environment, error) {
+	if f.environment == nil {
+		env, err := GetCloudEnvironmentE()
+		if err != nil {
+			return env, err
+		}
+		f.environment = &env
+	}
+	return *f.environment, nil
+}
+
+// AuthorizerE returns the Authorizer for resource, creating it on first use
+func (f *ClientFactory) AuthorizerE(resource string) (autorest.Authorizer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.authorizerLocked(resource)
+}
+
+// ResourceManagerAuthorizerE returns the Authorizer for Azure Resource Manager in the factory cloud
+func (f *ClientFactory) ResourceManagerAuthorizerE() (autorest.Authorizer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	env, err := f.environmentLocked()
+	if err != nil {
+		return nil, err
+	}
+	return f.authorizerLocked(resourceManagerAudience(env))
+}
+
+// KeyVaultAuthorizerE returns the Authorizer for Key Vault in the factory cloud
+func (f *ClientFactory) KeyVaultAuthorizerE() (autorest.Authorizer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	env, err := f.environmentLocked()
+	if err != nil {
+		return nil, err
+	}
+	return f.authorizerLocked(env.ResourceIdentifiers.KeyVault)
+}
+
+func (f *ClientFactory) authorizerLocked(resource string) (autorest.Authorizer, error) {
+	if f.authorizer != nil {
+		return f.authorizer, nil
+	}
+	if authorizer, ok := f.authorizers[resource]; ok {
+		return authorizer, nil
+	}
+	env, err := f.environmentLocked()
+	if err != nil {
+		return nil, err
+	}
+	authorizer, _, err := NewAuthorizerForEnvironmentE(env, resource)
+	if err != nil {
+		return nil, err
+	}
+	f.authorizers[resource] = authorizer
+	return authorizer, nil
+}
+
+// ResourceManagerClientE returns a copy of the cached client created by create for subscriptionID.
+// create must return a pointer to an Azure Resource Manager SDK client.
+// The client is pointed at the Resource Manager endpoint of the factory cloud.
+func (f *ClientFactory) ResourceManagerClientE(subscriptionID string, create func(subscriptionID string) interface{}) (interface{}, error) {
+	return f.clientE(subscriptionID, true, func(env azure.Environment) string {
+		return resourceManagerAudience(env)
+	}, func() interface{} {
+		return create(subscriptionID)
+	})
+}
+
+// KeyVaultClientE returns a copy of the cached Key Vault data plane client
+func (f *ClientFactory) KeyVaultClientE() (*keyvault.BaseClient, error) {
+	client, err := f.clientE("", false, func(env azure.Environment) string {
+		return env.ResourceIdentifiers.KeyVault
+	}, func() interface{} {
+		client := keyvault.New()
+		return &client
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(*keyvault.BaseClient), nil
+}
+
+// Reset drops every cached authorizer and client
+func (f *ClientFactory) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.resetLocked()
+}
+
+func (f *ClientFactory) resetLocked() {
+	f.authorizers = make(map[string]autorest.Authorizer)
+	f.clients = make(map[string]interface{})
+}
+
+func (f *ClientFactory) clientE(subscriptionID string, resourceManager bool, audience func(env azure.Environment) string, create func() interface{}) (interface{}, error) {
+	client := create()
+	clientType := reflect.TypeOf(client)
+	if clientType.Kind() != reflect.Ptr || clientType.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Client factory expects a pointer to a client struct, got %s", clientType)
+	}
+	// the package path contains the API version, e.g. .../network/mgmt/2020-04-01/network
+	key := fmt.Sprintf("%s|%s.%s", subscriptionID, clientType.Elem().PkgPath(), clientType.Elem().Name())
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if cached, ok := f.clients[key]; ok {
+		return copyClient(cached), nil
+	}
+	env, err := f.environmentLocked()
+	if err != nil {
+		return nil, err
+	}
+	authorizer, err := f.authorizerLocked(audience(env))
+	if err != nil {
+		return nil, err
+	}
+	value := reflect.ValueOf(client).Elem()
+	authorizerField := value.FieldByName("Authorizer")
+	if !authorizerField.IsValid() || !authorizerField.CanSet() {
+		return nil, fmt.Errorf("Client %s has no Authorizer field", clientType)
+	}
+	authorizerField.Set(reflect.ValueOf(&authorizer).Elem())
+	if senderField := value.FieldByName("Sender"); senderField.IsValid() && senderField.CanSet() {
+		sender := f.senderLocked()
+		senderField.Set(reflect.ValueOf(&sender).Elem())
+		// a non nil list replaces the retry decorators the SDK passes on every call, so the calls are only
+		// retried by the shared sender. Setting RetryAttempts to 0 instead would make the registration
+		// decorator of the management clients return without sending anything.
+		if decoratorsField := value.FieldByName("SendDecorators"); decoratorsField.IsValid() && decoratorsField.CanSet() {
+			decoratorsField.Set(reflect.ValueOf([]autorest.SendDecorator{}))
+		}
+	}
+	if resourceManager {
+		baseURIField := value.FieldByName("BaseURI")
+		if !baseURIField.IsValid() || baseURIField.Kind() != reflect.String {
+			return nil, fmt.Errorf("Client %s has no BaseURI field", clientType)
+		}
+		baseURIField.SetString(env.ResourceManagerEndpoint)
+	}
+	f.clients[key] = client
+	return copyClient(client), nil
+}
+
+// senderLocked returns the autorest.Sender shared by the clients, which applies the retry policy
+// and then the send decorators
+func (f *ClientFactory) senderLocked() autorest.Sender {
+	if f.retryPolicy == nil {
+		policy := DefaultRetryPolicy()
+		f.retryPolicy = &policy
+	}
+	sender := &retrySender{
+		policy: *f.retryPolicy,
+		next:   &http.Client{Transport: defaultTransport},
+	}
+	return autorest.DecorateSender(sender, f.decorators...)
+}
+
+// defaultTransport behaves like http.DefaultTransport but requires TLS 1.2, as autorest does
+var defaultTransport = func() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	return transport
+}()
+
+// copyClient returns a shallow copy so callers can't change the cached client
+func copyClient(client interface{}) interface{} {
+	value := reflect.ValueOf(client).Elem()
+	clone := reflect.New(value.Type())
+	clone.Elem().Set(value)
+	return clone.Interface()
+}
@@ -0,0 +1,157 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	fwpolicy "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-05-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClientFactory returns a ClientFactory for the public cloud which doesn't acquire any credential
+func newTestClientFactory() *ClientFactory {
+	f := NewClientFactory()
+	f.SetEnvironment(azure.PublicCloud)
+	f.SetAuthorizer(autorest.NullAuthorizer{})
+	return f
+}
+
+func newVirtualNetworksClient(subscriptionID string) interface{} {
+	client := network.NewVirtualNetworksClient(subscriptionID)
+	return &client
+}
+
+func newPolicyVirtualNetworksClient(subscriptionID string) interface{} {
+	client := fwpolicy.NewVirtualNetworksClient(subscriptionID)
+	return &client
+}
+
+func TestClientFactoryResourceManagerClientE(t *testing.T) {
+	f := newTestClientFactory()
+
+	client, err := f.ResourceManagerClientE(testSubscriptionID, newVirtualNetworksClient)
+	require.NoError(t, err)
+	vnetClient := client.(*network.VirtualNetworksClient)
+	assert.Equal(t, testSubscriptionID, vnetClient.SubscriptionID)
+	assert.Equal(t, azure.PublicCloud.ResourceManagerEndpoint, vnetClient.BaseURI)
+	assert.Equal(t, autorest.NullAuthorizer{}, vnetClient.Authorizer)
+	assert.IsType(t, &retrySender{}, vnetClient.Sender, "the shared sender retries the calls")
+	assert.NotNil(t, vnetClient.SendDecorators, "an empty list replaces the SDK retry decorators")
+	assert.Empty(t, vnetClient.SendDecorators)
+
+	// callers get a copy of the cached client
+	vnetClient.BaseURI = "https://changed.example.com"
+	client, err = f.ResourceManagerClientE(testSubscriptionID, newVirtualNetworksClient)
+	require.NoError(t, err)
+	assert.Equal(t, azure.PublicCloud.ResourceManagerEndpoint, client.(*network.VirtualNetworksClient).BaseURI)
+	assert.Len(t, f.clients, 1)
+
+	// SetEnvironment drops the cached clients
+	f.SetEnvironment(azure.ChinaCloud)
+	client, err = f.ResourceManagerClientE(testSubscriptionID, newVirtualNetworksClient)
+	require.NoError(t, err)
+	assert.Equal(t, azure.ChinaCloud.ResourceManagerEndpoint, client.(*network.VirtualNetworksClient).BaseURI)
+}
+
+func TestClientFactoryCacheKey(t *testing.T) {
+	f := newTestClientFactory()
+	otherSubscriptionID := "11111111-1111-1111-1111-111111111111"
+
+	cases := []struct {
+		name           string
+		subscriptionID string
+		create         func(subscriptionID string) interface{}
+		cached         int
+	}{
+		{"first client", testSubscriptionID, newVirtualNetworksClient, 1},
+		{"same subscription and package", testSubscriptionID, newVirtualNetworksClient, 1},
+		{"same subscription, other API version", testSubscriptionID, newPolicyVirtualNetworksClient, 2},
+		{"other subscription", otherSubscriptionID, newVirtualNetworksClient, 3},
+		{"other client type", testSubscriptionID, func(subscriptionID string) interface{} {
+			client := network.NewSubnetsClient(subscriptionID)
+			return &client
+		}, 4},
+	}
+	for _, c := range cases {
+		client, err := f.ResourceManagerClientE(c.subscriptionID, c.create)
+		require.NoError(t, err, c.name)
+		assert.IsType(t, c.create(c.subscriptionID), client, c.name)
+		assert.Len(t, f.clients, c.cached, c.name)
+	}
+	assert.Contains(t, f.clients, testSubscriptionID+"|github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-05-01/network.VirtualNetworksClient")
+
+	// the other setters drop the clients but keep the configuration
+	f.SetRetryPolicy(RetryPolicy{})
+	assert.Empty(t, f.clients)
+	_, err := f.ResourceManagerClientE(testSubscriptionID, newVirtualNetworksClient)
+	require.NoError(t, err)
+	f.SetSendDecorators(autorest.DoCloseIfError())
+	assert.Empty(t, f.clients)
+	assert.Len(t, f.decorators, 1)
+	_, err = f.ResourceManagerClientE(testSubscriptionID, newVirtualNetworksClient)
+	require.NoError(t, err)
+	f.SetAuthorizer(autorest.NullAuthorizer{})
+	assert.Empty(t, f.clients)
+}
+
+func TestClientFactoryClientErrors(t *testing.T) {
+	type noAuthorizer struct {
+		BaseURI string
+	}
+	type noBaseURI struct {
+		Authorizer autorest.Authorizer
+	}
+
+	cases := []struct {
+		name   string
+		client interface{}
+		err    string
+	}{
+		{"not a pointer", network.NewVirtualNetworksClient(testSubscriptionID), "Client factory expects a pointer to a client struct"},
+		{"pointer to a string", new(string), "Client factory expects a pointer to a client struct"},
+		{"no Authorizer", &noAuthorizer{}, "has no Authorizer field"},
+		{"no BaseURI", &noBaseURI{}, "has no BaseURI field"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f := newTestClientFactory()
+			_, err := f.ResourceManagerClientE(testSubscriptionID, func(string) interface{} { return c.client })
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), c.err)
+			assert.Empty(t, f.clients)
+		})
+	}
+}
+
+func TestClientFactoryAuthorizerE(t *testing.T) {
+	f := NewClientFactory()
+	f.SetEnvironment(azure.PublicCloud)
+	cached := autorest.NewAPIKeyAuthorizerWithHeaders(map[string]interface{}{"key": "value"})
+	f.authorizers[azure.PublicCloud.ResourceManagerEndpoint] = cached
+
+	// authorizers are cached per token audience
+	authorizer, err := f.ResourceManagerAuthorizerE()
+	require.NoError(t, err)
+	assert.Same(t, cached, authorizer)
+
+	// SetAuthorizer takes precedence over the cache, nil goes back to it
+	f.SetAuthorizer(autorest.NullAuthorizer{})
+	authorizer, err = f.AuthorizerE(azure.PublicCloud.ResourceManagerEndpoint)
+	require.NoError(t, err)
+	assert.Equal(t, autorest.NullAuthorizer{}, authorizer)
+	f.SetAuthorizer(nil)
+	authorizer, err = f.AuthorizerE(azure.PublicCloud.ResourceManagerEndpoint)
+	require.NoError(t, err)
+	assert.Same(t, cached, authorizer)
+
+	// Reset drops the cached authorizers
+	f.Reset()
+	assert.Empty(t, f.authorizers)
+
+	client, err := newTestClientFactory().KeyVaultClientE()
+	require.NoError(t, err)
+	assert.Equal(t, autorest.NullAuthorizer{}, client.Authorizer)
+}
@@ -0,0 +1,354 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+// FakeARMServer is an in-process Azure Resource Manager stand-in seeded with resource JSON.
+// GET on a resource ID returns the resource, GET on a collection (e.g. .../virtualNetworks or
+// .../virtualNetworks/{name}/subnets) lists the seeded resources it contains, anything else returns an ARM error.
+// The api-version parameter is ignored. It is safe for concurrent use.
+type FakeARMServer struct {
+	server    *httptest.Server
+	mu        sync.Mutex
+	resources map[string]map[string]interface{}
+	responses map[string][]fakeResponse
+	requests  []string
+}
+
+type fakeResponse struct {
+	statusCode int
+	body       []byte
+}
+
+// NewFakeARMServer starts an empty FakeARMServer, call Close when done
+func NewFakeARMServer() *FakeARMServer {
+	s := &FakeARMServer{
+		resources: make(map[string]map[string]interface{}),
+		responses: make(map[string][]fakeResponse),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// SetupFakeARMServer starts a FakeARMServer and points the helper clients at it until the test finishes.
+// Every call, including Key Vault data plane calls, goes to the fake server and no credentials are needed.
+// It changes the default client factory until the test finishes, so tests using it must not run in parallel.
+func SetupFakeARMServer(t *testing.T) *FakeARMServer {
+	s := NewFakeARMServer()
+	decorators := defaultClientFactory.SendDecorators()
+	authorizer := defaultClientFactory.Authorizer()
+	defaultClientFactory.SetSendDecorators(s.SendDecorator())
+	defaultClientFactory.SetAuthorizer(autorest.NullAuthorizer{})
+	t.Cleanup(func() {
+		defaultClientFactory.SetSendDecorators(decorators...)
+		defaultClientFactory.SetAuthorizer(authorizer)
+		s.Close()
+	})
+	return s
+}
+
+// URL returns the base URL of the server
+func (s *FakeARMServer) URL() string {
+	return s.server.URL
+}
+
+// Close stops the server
+func (s *FakeARMServer) Close() {
+	s.server.Close()
+}
+
+// SendDecorator returns an autorest.SendDecorator that sends every request to the server, keeping its path and query
+func (s *FakeARMServer) SendDecorator() autorest.SendDecorator {
+	target, _ := url.Parse(s.server.URL)
+	return func(next autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			redirected := *req.URL
+			redirected.Scheme = target.Scheme
+			redirected.Host = target.Host
+			req.URL = &redirected
+			req.Host = ""
+			return next.Do(req)
+		})
+	}
+}
+
+// AddResourceE seeds the resource with resourceID. resource is either a JSON string, JSON bytes or a value
+// marshalled to JSON, such as an SDK struct (SDK structs leave out read-only properties, use JSON to set them).
+// id, name and type are filled from the resource ID when missing. Child resources embedded in the properties
+// with their own id, such as the subnets of a virtual network, are seeded too unless they already are.
+func (s *FakeARMServer) AddResourceE(resourceID string, resource interface{}) error {
+	id, err := ParseResourceIDE(resourceID)
+	if err != nil {
+		return err
+	}
+	var content []byte
+	switch r := resource.(type) {
+	case string:
+		content = []byte(r)
+	case []byte:
+		content = r
+	default:
+		if content, err = json.Marshal(resource); err != nil {
+			return fmt.Errorf("Can not marshal resource %s: %s", resourceID, err)
+		}
+	}
+	properties := map[string]interface{}{}
+	if err := json.Unmarshal(content, &properties); err != nil {
+		return fmt.Errorf("Invalid JSON for resource %s: %s", resourceID, err)
+	}
+	setDefault(properties, "id", id.String())
+	setDefault(properties, "name", id.Name())
+	setDefault(properties, "type", id.ResourceType())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resources[strings.ToLower(id.String())] = properties
+	s.addEmbeddedLocked(id.String(), properties)
+	return nil
+}
+
+// AddResource seeds a resource and fails the test on error, see AddResourceE
+func (s *FakeARMServer) AddResource(t *testing.T, resourceID string, resource interface{}) {
+	t.Helper()
+	if err := s.AddResourceE(resourceID, resource); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AddResponseE makes the server answer method on path (without query) with statusCode and body, for calls that
+// are not a resource GET, e.g. a POST to .../storageAccounts/{name}/listKeys. body is marshalled like in AddResourceE.
+func (s *FakeARMServer) AddResponseE(method string, path string, statusCode int, body interface{}) error {
+	return s.AddResponsesE(method, path, statusCode, body)
+}
+
+// AddResponsesE is AddResponseE for a call made several times, e.g. to page through a list: the n-th call
+// gets the n-th body and the calls after the last one get the last body
+func (s *FakeARMServer) AddResponsesE(method string, path string, statusCode int, bodies ...interface{}) error {
+	if len(bodies) == 0 {
+		return fmt.Errorf("No response body for %s %s", method, path)
+	}
+	responses := make([]fakeResponse, 0, len(bodies))
+	for _, body := range bodies {
+		var content []byte
+		switch b := body.(type) {
+		case string:
+			content = []byte(b)
+		case []byte:
+			content = b
+		default:
+			var err error
+			if content, err = json.Marshal(body); err != nil {
+				return fmt.Errorf("Can not marshal response for %s %s: %s", method, path, err)
+			}
+		}
+		responses = append(responses, fakeResponse{statusCode: statusCode, body: content})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[fakeResponseKey(method, path)] = responses
+	return nil
+}
+
+// Requests returns the requests received so far, e.g. "GET /subscriptions/.../virtualNetworks/vnet"
+func (s *FakeARMServer) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *FakeARMServer) addEmbeddedLocked(parentID string, resource map[string]interface{}) {
+	properties, ok := resource["properties"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	prefix := strings.ToLower(parentID) + "/"
+	for _, value := range properties {
+		children, ok := value.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, child := range children {
+			childResource, ok := child.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			childID, ok := childResource["id"].(string)
+			if !ok || !strings.HasPrefix(strings.ToLower(childID), prefix) {
+				continue
+			}
+			if _, exists := s.resources[strings.ToLower(childID)]; !exists {
+				s.resources[strings.ToLower(childID)] = childResource
+			}
+		}
+	}
+}
+
+func (s *FakeARMServer) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	// like ARM, accept //subscriptions/... as sent by the generic resources client
+	path := "/" + strings.Trim(req.URL.Path, "/")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, fmt.Sprintf("%s %s", req.Method, path))
+
+	key := fakeResponseKey(req.Method, path)
+	if responses, ok := s.responses[key]; ok {
+		if len(responses) > 1 {
+			s.responses[key] = responses[1:]
+		}
+		writeJSON(w, responses[0].statusCode, responses[0].body)
+		return
+	}
+	if req.Method != http.MethodGet {
+		writeARMError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", fmt.Sprintf("The fake server does not support %s %s.", req.Method, path))
+		return
+	}
+	if resource, ok := s.resources[strings.ToLower(path)]; ok {
+		content, _ := json.Marshal(resource)
+		writeJSON(w, http.StatusOK, content)
+		return
+	}
+	if provider, ok := s.providerLocked(path); ok {
+		content, _ := json.Marshal(provider)
+		writeJSON(w, http.StatusOK, content)
+		return
+	}
+	if items, ok := s.listLocked(path); ok {
+		content, _ := json.Marshal(map[string]interface{}{"value": items})
+		writeJSON(w, http.StatusOK, content)
+		return
+	}
+	writeARMError(w, http.StatusNotFound, "ResourceNotFound", fmt.Sprintf("The resource '%s' was not found.", path))
+}
+
+// providerLocked answers /subscriptions/{id}/providers/{namespace} with the resource types seeded in the namespace,
+// so the API version lookup of GetResourceByIDE works against the fake server
+func (s *FakeARMServer) providerLocked(path string) (map[string]interface{}, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 4 || !strings.EqualFold(parts[0], "subscriptions") || !strings.EqualFold(parts[2], "providers") {
+		return nil, false
+	}
+	namespace := parts[3]
+	types := make(map[string]bool)
+	for id := range s.resources {
+		resourceID, err := ParseResourceIDE(id)
+		if err != nil {
+			continue
+		}
+		resourceType := strings.SplitN(resourceID.ResourceType(), "/", 2)
+		if strings.EqualFold(resourceType[0], namespace) {
+			types[resourceType[1]] = true
+		}
+	}
+	resourceTypes := make([]interface{}, 0, len(types))
+	for resourceType := range types {
+		resourceTypes = append(resourceTypes, map[string]interface{}{
+			"resourceType": resourceType,
+			"apiVersions":  []string{fakeAPIVersion},
+		})
+	}
+	return map[string]interface{}{
+		"id":                fmt.Sprintf("/subscriptions/%s/providers/%s", parts[1], namespace),
+		"namespace":         namespace,
+		"registrationState": "Registered",
+		"resourceTypes":     resourceTypes,
+	}, true
+}
+
+// fakeAPIVersion is the API version advertised by the fake server, which ignores it
+const fakeAPIVersion = "2020-01-01"
+
+// listLocked returns the resources of the collection at path, either the children of a parent
+// (e.g. .../resourceGroups/{rg}/providers/{namespace}/{type}), a type in a whole subscription
+// (/subscriptions/{id}/providers/{namespace}/{type}) or every top level resource of a resource group or subscription (.../resources)
+func (s *FakeARMServer) listLocked(path string) ([]interface{}, bool) {
+	collection := strings.ToLower(path)
+	index := strings.LastIndex(collection, "/")
+	if index <= 0 {
+		return nil, false
+	}
+	parts := strings.Split(strings.Trim(collection, "/"), "/")
+	subscriptionWide := len(parts) == 5 && parts[0] == "subscriptions" && parts[2] == "providers"
+	allResources := parts[len(parts)-1] == "resources" && (len(parts) == 3 || (len(parts) == 5 && parts[2] == "resourcegroups"))
+
+	ids := make([]string, 0)
+	for id := range s.resources {
+		if subscriptionWide {
+			resourceID, err := ParseResourceIDE(id)
+			if err == nil && strings.EqualFold(resourceID.SubscriptionID, parts[1]) && len(resourceID.Types) == 1 &&
+				strings.ToLower(resourceID.ResourceType()) == parts[3]+"/"+parts[4] {
+				ids = append(ids, id)
+			}
+			continue
+		}
+		if allResources {
+			resourceID, err := ParseResourceIDE(id)
+			if err == nil && len(resourceID.Types) == 1 && strings.HasPrefix(id, collection[:index]+"/") {
+				ids = append(ids, id)
+			}
+			continue
+		}
+		if i := strings.LastIndex(id, "/"); i > 0 && id[:i] == collection {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 && !s.hasParentLocked(collection[:index]) {
+		return nil, false
+	}
+	sort.Strings(ids)
+	items := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		items = append(items, s.resources[id])
+	}
+	return items, true
+}
+
+// hasParentLocked tells whether the parent of a collection exists, so an empty list is returned instead of a 404
+func (s *FakeARMServer) hasParentLocked(parent string) bool {
+	// .../providers/{namespace}/{type} belongs to the resource group or subscription before it
+	if parts := strings.Split(parent, "/"); len(parts) >= 2 && parts[len(parts)-2] == "providers" {
+		parent = strings.Join(parts[:len(parts)-2], "/")
+	}
+	if _, ok := s.resources[parent]; ok {
+		return true
+	}
+	parts := strings.Split(strings.Trim(parent, "/"), "/")
+	return len(parts) == 2 && parts[0] == "subscriptions"
+}
+
+func fakeResponseKey(method string, path string) string {
+	return strings.ToUpper(method) + " " + strings.ToLower(strings.TrimRight(path, "/"))
+}
+
+func setDefault(properties map[string]interface{}, name string, value string) {
+	if v, ok := properties[name].(string); !ok || v == "" {
+		properties[name] = value
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body []byte) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+// writeARMError writes an error in the format returned by Azure Resource Manager
+func writeARMError(w http.ResponseWriter, statusCode int, code string, message string) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]string{
+			"code":    code,
+			"message": message,
+		},
+	})
+	writeJSON(w, statusCode, body)
+}
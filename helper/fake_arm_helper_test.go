@@ -0,0 +1,248 @@
+package helper
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSubscriptionID = "00000000-0000-0000-0000-000000000000"
+	testVnetID         = testResourceGroupID + "/providers/Microsoft.Network/virtualNetworks/vnet-test"
+)
+
+// testVnet is a virtual network with two subnets embedded in its properties
+const testVnet = `{
+	"location": "westeurope",
+	"properties": {
+		"addressSpace": {"addressPrefixes": ["10.0.0.0/16"]},
+		"subnets": [
+			{"id": "` + testVnetID + `/subnets/snet-b", "name": "snet-b", "properties": {"addressPrefix": "10.0.2.0/24"}},
+			{"id": "` + testVnetID + `/subnets/snet-a", "name": "snet-a", "properties": {"addressPrefix": "10.0.1.0/24"}}
+		]
+	}
+}`
+
+// setupTestARMServer starts a FakeARMServer for the helpers of the test, with testSubscriptionID as the default
+// subscription and the resource group rg-test in westeurope
+func setupTestARMServer(t *testing.T) *FakeARMServer {
+	t.Setenv(SubscriptionIDEnvName, testSubscriptionID)
+	s := SetupFakeARMServer(t)
+	s.AddResource(t, testResourceGroupID, `{"location": "westeurope"}`)
+	return s
+}
+
+// fakeGet sends a GET to the fake server and returns the status code and the decoded body
+func fakeGet(t *testing.T, s *FakeARMServer, method string, path string) (int, map[string]interface{}) {
+	t.Helper()
+	req, err := http.NewRequest(method, s.URL()+path+"?api-version="+fakeAPIVersion, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	body := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(content, &body), string(content))
+	return resp.StatusCode, body
+}
+
+// bodyProperty returns the value at path of a decoded body, see GetPropertyByPathE
+func bodyProperty(t *testing.T, body interface{}, path string) interface{} {
+	t.Helper()
+	value, err := GetPropertyByPathE(body, path)
+	require.NoError(t, err)
+	return value
+}
+
+// listNames returns the names of the items of a list response
+func listNames(t *testing.T, body map[string]interface{}) []string {
+	t.Helper()
+	names := make([]string, 0)
+	for _, item := range bodyProperty(t, body, "value").([]interface{}) {
+		names = append(names, bodyProperty(t, item, "name").(string))
+	}
+	return names
+}
+
+func newTestFakeARMServer(t *testing.T) *FakeARMServer {
+	s := NewFakeARMServer()
+	t.Cleanup(s.Close)
+	s.AddResource(t, testResourceGroupID, `{"location": "westeurope"}`)
+	s.AddResource(t, testVnetID, testVnet)
+	return s
+}
+
+func TestFakeARMServerResource(t *testing.T) {
+	s := newTestFakeARMServer(t)
+
+	status, body := fakeGet(t, s, http.MethodGet, strings.ToUpper(testVnetID))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, testVnetID, body["id"])
+	assert.Equal(t, "vnet-test", body["name"])
+	assert.Equal(t, "Microsoft.Network/virtualNetworks", body["type"])
+
+	// embedded child resources are seeded too
+	status, body = fakeGet(t, s, http.MethodGet, testVnetID+"/subnets/snet-a")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "10.0.1.0/24", bodyProperty(t, body, "properties.addressPrefix"))
+
+	// SDK structs are marshalled
+	nsgID := testResourceGroupID + "/providers/Microsoft.Network/networkSecurityGroups/nsg-test"
+	s.AddResource(t, nsgID, network.SecurityGroup{Location: to.StringPtr("northeurope")})
+	_, body = fakeGet(t, s, http.MethodGet, nsgID)
+	assert.Equal(t, "northeurope", body["location"])
+	assert.Equal(t, "nsg-test", body["name"])
+
+	assert.Error(t, s.AddResourceE(testVnetID, "{not json"))
+	assert.Error(t, s.AddResourceE("/resourceGroups/rg", "{}"))
+}
+
+func TestFakeARMServerCollections(t *testing.T) {
+	s := newTestFakeARMServer(t)
+	otherVnetID := "/subscriptions/" + testSubscriptionID + "/resourceGroups/rg-other/providers/Microsoft.Network/virtualNetworks/vnet-other"
+	s.AddResource(t, otherVnetID, `{"properties": {}}`)
+	s.AddResource(t, "/subscriptions/"+testSubscriptionID+"/resourceGroups/rg-empty", `{}`)
+
+	cases := []struct {
+		name  string
+		path  string
+		names []string
+	}{
+		{"children", testVnetID + "/subnets", []string{"snet-a", "snet-b"}},
+		{"type in resource group", testResourceGroupID + "/providers/Microsoft.Network/virtualNetworks", []string{"vnet-test"}},
+		{"type in subscription", "/subscriptions/" + testSubscriptionID + "/providers/Microsoft.Network/virtualNetworks", []string{"vnet-other", "vnet-test"}},
+		{"resources of resource group", testResourceGroupID + "/resources", []string{"vnet-test"}},
+		{"resources of subscription", "/subscriptions/" + testSubscriptionID + "/resources", []string{"vnet-other", "vnet-test"}},
+		{"empty resource group", "/subscriptions/" + testSubscriptionID + "/resourceGroups/rg-empty/providers/Microsoft.Network/virtualNetworks", []string{}},
+		{"no child", otherVnetID + "/subnets", []string{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			status, body := fakeGet(t, s, http.MethodGet, c.path)
+			require.Equal(t, http.StatusOK, status)
+			assert.Equal(t, c.names, listNames(t, body))
+		})
+	}
+}
+
+func TestFakeARMServerProvider(t *testing.T) {
+	s := newTestFakeARMServer(t)
+
+	status, body := fakeGet(t, s, http.MethodGet, "/subscriptions/"+testSubscriptionID+"/providers/microsoft.network")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Registered", body["registrationState"])
+	types := make([]string, 0)
+	for _, resourceType := range bodyProperty(t, body, "resourceTypes").([]interface{}) {
+		types = append(types, bodyProperty(t, resourceType, "resourceType").(string))
+		assert.Equal(t, []interface{}{fakeAPIVersion}, bodyProperty(t, resourceType, "apiVersions"))
+	}
+	assert.ElementsMatch(t, []string{"virtualnetworks", "virtualnetworks/subnets"}, types, "types are lower case like the resource keys")
+
+	_, body = fakeGet(t, s, http.MethodGet, "/subscriptions/"+testSubscriptionID+"/providers/Microsoft.Storage")
+	assert.Empty(t, bodyProperty(t, body, "resourceTypes"))
+}
+
+func TestFakeARMServerAddResponse(t *testing.T) {
+	s := newTestFakeARMServer(t)
+	listKeysPath := testResourceGroupID + "/providers/Microsoft.Storage/storageAccounts/st/listKeys"
+	require.NoError(t, s.AddResponseE("post", listKeysPath+"/", http.StatusOK, `{"keys": [{"keyName": "key1"}]}`))
+	require.NoError(t, s.AddResponseE(http.MethodGet, testVnetID, http.StatusConflict, map[string]string{"status": "busy"}))
+
+	status, body := fakeGet(t, s, http.MethodPost, strings.ToLower(listKeysPath))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "key1", bodyProperty(t, body, "keys[0].keyName"))
+
+	// responses take precedence over seeded resources
+	status, body = fakeGet(t, s, http.MethodGet, testVnetID)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "busy", body["status"])
+
+	assert.Error(t, s.AddResponseE(http.MethodGet, testVnetID, http.StatusOK, func() {}))
+}
+
+func TestFakeARMServerAddResponses(t *testing.T) {
+	s := newTestFakeARMServer(t)
+	listPath := testResourceGroupID + "/providers/Microsoft.Network/privateDnsZones/zone/ALL"
+	require.NoError(t, s.AddResponsesE(http.MethodGet, listPath, http.StatusOK, `{"value": [{"name": "a"}]}`, `{"value": [{"name": "b"}]}`))
+
+	// the bodies are served in order, then the last one is repeated
+	for _, name := range []string{"a", "b", "b"} {
+		status, body := fakeGet(t, s, http.MethodGet, listPath)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{name}, listNames(t, body))
+	}
+
+	assert.Error(t, s.AddResponsesE(http.MethodGet, listPath, http.StatusOK))
+}
+
+func TestFakeARMServerErrors(t *testing.T) {
+	s := newTestFakeARMServer(t)
+
+	status, body := fakeGet(t, s, http.MethodGet, testResourceGroupID+"/providers/Microsoft.Network/virtualNetworks/vnet-missing")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "ResourceNotFound", bodyProperty(t, body, "error.code"))
+
+	// a collection of a missing parent is not found either
+	status, _ = fakeGet(t, s, http.MethodGet, "/subscriptions/"+testSubscriptionID+"/resourceGroups/rg-missing/providers/Microsoft.Network/virtualNetworks")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	for _, method := range []string{http.MethodPut, http.MethodPost, http.MethodDelete} {
+		status, body = fakeGet(t, s, method, testVnetID)
+		assert.Equal(t, http.StatusMethodNotAllowed, status)
+		assert.Equal(t, "MethodNotAllowed", bodyProperty(t, body, "error.code"))
+	}
+
+	assert.Equal(t, []string{
+		"GET " + testResourceGroupID + "/providers/Microsoft.Network/virtualNetworks/vnet-missing",
+		"GET /subscriptions/" + testSubscriptionID + "/resourceGroups/rg-missing/providers/Microsoft.Network/virtualNetworks",
+		"PUT " + testVnetID,
+		"POST " + testVnetID,
+		"DELETE " + testVnetID,
+	}, s.Requests())
+}
+
+// TestSetupFakeARMServer checks the helper clients reach the fake server
+func TestSetupFakeARMServer(t *testing.T) {
+	s := setupTestARMServer(t)
+	s.AddResource(t, testVnetID, testVnet)
+
+	vnet, err := GetVirtualNetworkE("rg-test", "vnet-test")
+	require.NoError(t, err)
+	assert.Equal(t, "westeurope", *vnet.Location)
+
+	subnet, err := GetSubnetE("rg-test", "vnet-test", "snet-b")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.2.0/24", *subnet.AddressPrefix)
+
+	resource, err := GetResourceByIDE(testVnetID + "/subnets/snet-a")
+	require.NoError(t, err)
+	assert.Equal(t, "snet-a", resource["name"])
+
+	_, err = GetVirtualNetworkE("rg-test", "vnet-missing")
+	require.Error(t, err)
+	assert.True(t, isNotFoundError(err))
+}
+
+func TestSetupFakeARMServerRestoresTheClientFactory(t *testing.T) {
+	outer := setupTestARMServer(t)
+	outer.AddResource(t, testVnetID, testVnet)
+
+	t.Run("inner", func(t *testing.T) {
+		setupTestARMServer(t)
+		_, err := GetVirtualNetworkE("rg-test", "vnet-test")
+		require.Error(t, err, "the inner server has no virtual network")
+	})
+
+	_, err := GetVirtualNetworkE("rg-test", "vnet-test")
+	require.NoError(t, err)
+	assert.Equal(t, autorest.NullAuthorizer{}, defaultClientFactory.Authorizer())
+}
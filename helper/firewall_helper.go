@@ -0,0 +1,642 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	fwpolicy "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-05-01/network"
+)
+
+/********************************
+		Firewall rules
+*********************************/
+
+// maxFirewallPolicyDepth bounds the chain of base policies followed from a firewall policy
+const maxFirewallPolicyDepth = 10
+
+// FirewallRuleType is the kind of a firewall rule, rules are processed NAT first, then network, then application
+type FirewallRuleType string
+
+const (
+	// FirewallRuleTypeNat is a DNAT rule
+	FirewallRuleTypeNat FirewallRuleType = "Nat"
+	// FirewallRuleTypeNetwork is a network rule
+	FirewallRuleTypeNetwork FirewallRuleType = "Network"
+	// FirewallRuleTypeApplication is an application rule
+	FirewallRuleTypeApplication FirewallRuleType = "Application"
+)
+
+// firewallRuleTypeOrder is the processing order of the rule types
+var firewallRuleTypeOrder = map[FirewallRuleType]int{FirewallRuleTypeNat: 0, FirewallRuleTypeNetwork: 1, FirewallRuleTypeApplication: 2}
+
+// applicationProtocolPorts are the default ports of the protocols of application rules
+var applicationProtocolPorts = map[string]int{"http": 80, "https": 443, "mssql": 1433}
+
+// FirewallFlowDecision is the outcome of evaluating a flow against the rules of an Azure Firewall or a firewall policy
+type FirewallFlowDecision struct {
+	Allowed bool
+	// RuleType is empty when no rule matches and the flow is denied by default
+	RuleType FirewallRuleType
+	// RuleCollection is the name of the rule collection, classic or of a rule collection group of a firewall policy
+	RuleCollection string
+	RuleName       string
+	// Priority is the priority of the rule collection
+	Priority int32
+	// TranslatedAddress and TranslatedPort are the destination of a flow allowed by a DNAT rule
+	TranslatedAddress string
+	TranslatedPort    string
+}
+
+func (d FirewallFlowDecision) String() string {
+	if d.RuleType == "" {
+		return "Deny, no rule matches"
+	}
+	action := "Deny"
+	if d.Allowed {
+		action = "Allow"
+	}
+	description := fmt.Sprintf("%s by %s rule %s in %s (priority %d)", action, strings.ToLower(string(d.RuleType)), d.RuleName, d.RuleCollection, d.Priority)
+	if d.TranslatedAddress != "" {
+		description += fmt.Sprintf(" to %s", net.JoinHostPort(d.TranslatedAddress, d.TranslatedPort))
+	}
+	return description
+}
+
+// firewallRule is a rule of an Azure Firewall or a firewall policy with every list filled
+type firewallRule struct {
+	ruleType   FirewallRuleType
+	collection string
+	priority   int32
+	// order sorts the rules of the same type: base policy depth, rule collection group priority, collection priority, rule index
+	order []int32
+	name  string
+	allow bool
+	// protocols are TCP, UDP, ICMP or Any for NAT and network rules, and Http:80, Https:443... for application rules
+	protocols            []string
+	sourceAddresses      []string
+	sourceIPGroups       []string
+	destinationAddresses []string
+	destinationIPGroups  []string
+	destinationPorts     []string
+	// destinationFQDNs are the FQDNs of network rules, or the target FQDNs of application rules
+	destinationFQDNs  []string
+	translatedAddress string
+	translatedPort    string
+}
+
+// GetFirewallFlowDecisionE evaluates whether an Azure Firewall allows a flow, with the rules of its firewall policy (and of the
+// base policies) when it has one, or its classic rule collections otherwise. See EvaluateFirewallFlowE and EvaluateFirewallPolicyFlowE.
+func GetFirewallFlowDecisionE(resourceGroupName, firewallName string, flow NetworkFlow, serviceTags ServiceTagPrefixes, subscriptionID ...string) (*FirewallFlowDecision, error) {
+	return GetFirewallFlowDecisionWithContextE(context.Background(), resourceGroupName, firewallName, flow, serviceTags, subscriptionID...)
+}
+
+// GetFirewallFlowDecisionWithContextE evaluates whether an Azure Firewall allows a flow, see GetFirewallFlowDecisionE
+func GetFirewallFlowDecisionWithContextE(ctx context.Context, resourceGroupName, firewallName string, flow NetworkFlow, serviceTags ServiceTagPrefixes, subscriptionID ...string) (*FirewallFlowDecision, error) {
+	firewall, err := GetFirewallWithContextE(ctx, resourceGroupName, firewallName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+	if firewall.AzureFirewallPropertiesFormat == nil || firewall.FirewallPolicy == nil || firewall.FirewallPolicy.ID == nil {
+		return EvaluateFirewallFlowE(firewall, flow, serviceTags)
+	}
+
+	// rule collection groups of the policy and of its base policies, the topmost base policy first
+	ruleCollectionGroups := make([][]fwpolicy.FirewallPolicyRuleCollectionGroup, 0)
+	policyID := *firewall.FirewallPolicy.ID
+	for policyID != "" {
+		if len(ruleCollectionGroups) == maxFirewallPolicyDepth {
+			return nil, fmt.Errorf("Firewall policy %s has more than %d levels of base policies", *firewall.FirewallPolicy.ID, maxFirewallPolicyDepth)
+		}
+		id, err := ParseResourceIDOfTypeE(policyID, "Microsoft.Network/firewallPolicies")
+		if err != nil {
+			return nil, err
+		}
+		policy, err := GetFirewallPolicyWithContextE(ctx, id.ResourceGroup, id.Name(), id.SubscriptionID)
+		if err != nil {
+			return nil, err
+		}
+		groups, err := ListFirewallPolicyRuleCollectionGroupsWithContextE(ctx, id.ResourceGroup, id.Name(), id.SubscriptionID)
+		if err != nil {
+			return nil, err
+		}
+		ruleCollectionGroups = append([][]fwpolicy.FirewallPolicyRuleCollectionGroup{groups}, ruleCollectionGroups...)
+		policyID = ""
+		if policy.FirewallPolicyPropertiesFormat != nil && policy.BasePolicy != nil {
+			policyID = stringValue(policy.BasePolicy.ID)
+		}
+	}
+	return EvaluateFirewallPolicyFlowE(ruleCollectionGroups, flow, serviceTags)
+}
+
+// EvaluateFirewallFlowE evaluates offline whether the classic rule collections of an Azure Firewall, as returned by GetFirewallE,
+// allow a flow. Like Azure, NAT rule collections are processed first, then network rule collections, then application rule
+// collections, each by priority, and the first matching rule decides; a flow matching no rule is denied.
+//
+// Addresses can be IP addresses, prefixes, ranges such as 10.0.0.1-10.0.0.9 or service tags resolved with serviceTags, which can
+// be nil (see GetServiceTagPrefixesE); IP groups are resolved with serviceTags too, by their resource ID. Network rules match
+// the DestinationFQDN of the flow against their destination FQDNs. Application rules only match flows with a DestinationFQDN,
+// on the protocol (Http, Https, Mssql, or TCP for any of them) and port; their FQDN tags are not known offline and never match.
+func EvaluateFirewallFlowE(firewall *network.AzureFirewall, flow NetworkFlow, serviceTags ServiceTagPrefixes) (*FirewallFlowDecision, error) {
+	if firewall == nil {
+		return nil, fmt.Errorf("Firewall is nil")
+	}
+	rules := make([]firewallRule, 0)
+	if firewall.AzureFirewallPropertiesFormat != nil {
+		rules = append(rules, classicNatRules(firewall.NatRuleCollections)...)
+		rules = append(rules, classicNetworkRules(firewall.NetworkRuleCollections)...)
+		rules = append(rules, classicApplicationRules(firewall.ApplicationRuleCollections)...)
+	}
+	return evaluateFirewallRules(rules, flow, serviceTags)
+}
+
+// EvaluateFirewallPolicyFlowE evaluates offline whether a firewall policy allows a flow, like EvaluateFirewallFlowE does for the
+// classic rule collections. ruleCollectionGroups holds the rule collection groups of each policy of the chain, the topmost base
+// policy first, as the rules of a base policy take precedence over the rules of the policies inheriting from it. Rules are
+// processed by type (NAT, network, application), then base policy first, then by rule collection group priority, rule
+// collection priority and order in the collection.
+func EvaluateFirewallPolicyFlowE(ruleCollectionGroups [][]fwpolicy.FirewallPolicyRuleCollectionGroup, flow NetworkFlow, serviceTags ServiceTagPrefixes) (*FirewallFlowDecision, error) {
+	rules := make([]firewallRule, 0)
+	for depth, groups := range ruleCollectionGroups {
+		for _, group := range groups {
+			if group.FirewallPolicyRuleCollectionGroupProperties == nil || group.RuleCollections == nil {
+				continue
+			}
+			for _, collection := range *group.RuleCollections {
+				rules = append(rules, policyRules(int32(depth), int32Value(group.Priority), collection)...)
+			}
+		}
+	}
+	return evaluateFirewallRules(rules, flow, serviceTags)
+}
+
+// AssertFirewallAllowsFlow fails the test unless an Azure Firewall allows the flow, written as for ParseNetworkFlowE,
+// e.g. AssertFirewallAllowsFlow(t, rg, "afw-hub", "Https 10.1.0.4 -> fqdn:login.microsoftonline.com:443", nil)
+func AssertFirewallAllowsFlow(t *testing.T, resourceGroupName, firewallName string, flow string, serviceTags ServiceTagPrefixes, subscriptionID ...string) {
+	t.Helper()
+	assertFirewallFlow(t, resourceGroupName, firewallName, flow, serviceTags, true, subscriptionID...)
+}
+
+// AssertFirewallDeniesFlow fails the test unless an Azure Firewall denies the flow, written as for ParseNetworkFlowE
+func AssertFirewallDeniesFlow(t *testing.T, resourceGroupName, firewallName string, flow string, serviceTags ServiceTagPrefixes, subscriptionID ...string) {
+	t.Helper()
+	assertFirewallFlow(t, resourceGroupName, firewallName, flow, serviceTags, false, subscriptionID...)
+}
+
+func assertFirewallFlow(t *testing.T, resourceGroupName, firewallName string, flow string, serviceTags ServiceTagPrefixes, allowed bool, subscriptionID ...string) {
+	t.Helper()
+	parsed, err := ParseNetworkFlowE(flow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decision, err := GetFirewallFlowDecisionE(resourceGroupName, firewallName, parsed, serviceTags, subscriptionID...)
+	failOnAzureError(t, err, "evaluate flow through", "Microsoft.Network/azureFirewalls", firewallName, resourceGroupName)
+	if decision.Allowed != allowed {
+		expected := "denied"
+		if allowed {
+			expected = "allowed"
+		}
+		t.Errorf("Flow %s through firewall %s: %s, expected %s", parsed, firewallName, decision, expected)
+	}
+}
+
+// evaluateFirewallRules sorts rules in processing order and returns the decision of the first one matching the flow
+func evaluateFirewallRules(rules []firewallRule, flow NetworkFlow, serviceTags ServiceTagPrefixes) (*FirewallFlowDecision, error) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].ruleType != rules[j].ruleType {
+			return firewallRuleTypeOrder[rules[i].ruleType] < firewallRuleTypeOrder[rules[j].ruleType]
+		}
+		for k := range rules[i].order {
+			if rules[i].order[k] != rules[j].order[k] {
+				return rules[i].order[k] < rules[j].order[k]
+			}
+		}
+		return false
+	})
+	for _, rule := range rules {
+		matched, err := rule.matches(flow, serviceTags)
+		if err != nil {
+			return nil, fmt.Errorf("%s rule %s in %s: %s", rule.ruleType, rule.name, rule.collection, err)
+		}
+		if matched {
+			return &FirewallFlowDecision{
+				Allowed:           rule.allow,
+				RuleType:          rule.ruleType,
+				RuleCollection:    rule.collection,
+				RuleName:          rule.name,
+				Priority:          rule.priority,
+				TranslatedAddress: rule.translatedAddress,
+				TranslatedPort:    rule.translatedPort,
+			}, nil
+		}
+	}
+	return &FirewallFlowDecision{}, nil
+}
+
+func (r firewallRule) matches(flow NetworkFlow, serviceTags ServiceTagPrefixes) (bool, error) {
+	if matched, err := firewallAddressMatches(r.sourceAddresses, r.sourceIPGroups, flow.SourceAddress, serviceTags); err != nil || !matched {
+		return false, err
+	}
+	if r.ruleType == FirewallRuleTypeApplication {
+		if flow.DestinationFQDN == "" || !r.applicationProtocolMatches(flow) {
+			return false, nil
+		}
+		for _, fqdn := range r.destinationFQDNs {
+			if fqdnMatches(fqdn, flow.DestinationFQDN) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	protocol := flow.Protocol
+	if _, isApplicationProtocol := applicationProtocolPorts[strings.ToLower(protocol)]; isApplicationProtocol {
+		protocol = "TCP"
+	}
+	protocolMatched := false
+	for _, ruleProtocol := range r.protocols {
+		protocolMatched = protocolMatched || strings.EqualFold(ruleProtocol, "Any") || strings.EqualFold(ruleProtocol, protocol)
+	}
+	if !protocolMatched {
+		return false, nil
+	}
+
+	if flow.DestinationFQDN != "" {
+		fqdnMatched := containsString(r.destinationAddresses, "*")
+		for _, fqdn := range r.destinationFQDNs {
+			fqdnMatched = fqdnMatched || strings.EqualFold(fqdn, flow.DestinationFQDN)
+		}
+		if !fqdnMatched {
+			return false, nil
+		}
+	} else if matched, err := firewallAddressMatches(r.destinationAddresses, r.destinationIPGroups, flow.DestinationAddress, serviceTags); err != nil || !matched {
+		return false, err
+	}
+	if flow.DestinationPort == 0 {
+		// ICMP
+		return true, nil
+	}
+	return anyPortMatches(r.destinationPorts, flow.DestinationPort)
+}
+
+// applicationProtocolMatches matches the Http:80, Https:443... protocols of an application rule
+func (r firewallRule) applicationProtocolMatches(flow NetworkFlow) bool {
+	for _, protocol := range r.protocols {
+		parts := strings.SplitN(protocol, ":", 2)
+		if !strings.EqualFold(flow.Protocol, "TCP") && !strings.EqualFold(flow.Protocol, parts[0]) {
+			continue
+		}
+		port := applicationProtocolPorts[strings.ToLower(parts[0])]
+		if len(parts) == 2 {
+			if number, err := strconv.Atoi(parts[1]); err == nil {
+				port = number
+			}
+		}
+		if flow.DestinationPort == 0 || flow.DestinationPort == port {
+			return true
+		}
+	}
+	return false
+}
+
+// firewallAddressMatches matches an address against the addresses and IP groups of a rule; IP groups are looked up by ID in serviceTags
+func firewallAddressMatches(addresses []string, ipGroups []string, address string, serviceTags ServiceTagPrefixes) (bool, error) {
+	for _, prefix := range addresses {
+		if bounds := strings.SplitN(prefix, "-", 2); len(bounds) == 2 && net.ParseIP(strings.TrimSpace(bounds[0])) != nil {
+			if ip := net.ParseIP(address); ip != nil && ipInRange(ip, net.ParseIP(strings.TrimSpace(bounds[0])), net.ParseIP(strings.TrimSpace(bounds[1]))) {
+				return true, nil
+			}
+			continue
+		}
+		matched, err := serviceTags.addressMatches(prefix, address)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	for _, id := range ipGroups {
+		prefixes, ok := serviceTags.lookup(id)
+		if !ok {
+			return false, fmt.Errorf("the prefixes of IP group %s are unknown, add them to the service tags by resource ID", id)
+		}
+		if matched, err := firewallAddressMatches(prefixes, nil, address, serviceTags); err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+// ipInRange tells whether ip is between low and high, included
+func ipInRange(ip net.IP, low net.IP, high net.IP) bool {
+	if high == nil {
+		return false
+	}
+	return bytes.Compare(ip.To16(), low.To16()) >= 0 && bytes.Compare(ip.To16(), high.To16()) <= 0
+}
+
+// fqdnMatches matches a host name against an FQDN of an application rule, * or a wildcard such as *.microsoft.com
+func fqdnMatches(pattern string, fqdn string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	fqdn = strings.ToLower(fqdn)
+	if pattern == "*" || pattern == fqdn {
+		return true
+	}
+	return strings.HasPrefix(pattern, "*.") && strings.HasSuffix(fqdn, pattern[1:])
+}
+
+func classicNatRules(collections *[]network.AzureFirewallNatRuleCollection) []firewallRule {
+	rules := make([]firewallRule, 0)
+	if collections == nil {
+		return rules
+	}
+	for _, collection := range *collections {
+		properties := collection.AzureFirewallNatRuleCollectionProperties
+		if properties == nil || properties.Rules == nil {
+			continue
+		}
+		if properties.Action != nil && properties.Action.Type != "" && properties.Action.Type != network.Dnat {
+			log.Printf("Warning: NAT rule collection %s is ignored, only DNAT rules are evaluated\n", stringValue(collection.Name))
+			continue
+		}
+		for _, rule := range *properties.Rules {
+			rules = append(rules, firewallRule{
+				ruleType:             FirewallRuleTypeNat,
+				collection:           stringValue(collection.Name),
+				priority:             int32Value(properties.Priority),
+				order:                []int32{0, 0, int32Value(properties.Priority), 0},
+				name:                 stringValue(rule.Name),
+				allow:                true,
+				protocols:            networkRuleProtocols(rule.Protocols),
+				sourceAddresses:      stringList(nil, rule.SourceAddresses),
+				sourceIPGroups:       stringList(nil, rule.SourceIPGroups),
+				destinationAddresses: stringList(nil, rule.DestinationAddresses),
+				destinationPorts:     stringList(nil, rule.DestinationPorts),
+				translatedAddress:    stringValue(rule.TranslatedAddress),
+				translatedPort:       stringValue(rule.TranslatedPort),
+			})
+		}
+	}
+	return rules
+}
+
+func classicNetworkRules(collections *[]network.AzureFirewallNetworkRuleCollection) []firewallRule {
+	rules := make([]firewallRule, 0)
+	if collections == nil {
+		return rules
+	}
+	for _, collection := range *collections {
+		properties := collection.AzureFirewallNetworkRuleCollectionPropertiesFormat
+		if properties == nil || properties.Rules == nil {
+			continue
+		}
+		for _, rule := range *properties.Rules {
+			rules = append(rules, firewallRule{
+				ruleType:             FirewallRuleTypeNetwork,
+				collection:           stringValue(collection.Name),
+				priority:             int32Value(properties.Priority),
+				order:                []int32{0, 0, int32Value(properties.Priority), 0},
+				name:                 stringValue(rule.Name),
+				allow:                properties.Action == nil || properties.Action.Type != network.AzureFirewallRCActionTypeDeny,
+				protocols:            networkRuleProtocols(rule.Protocols),
+				sourceAddresses:      stringList(nil, rule.SourceAddresses),
+				sourceIPGroups:       stringList(nil, rule.SourceIPGroups),
+				destinationAddresses: stringList(nil, rule.DestinationAddresses),
+				destinationIPGroups:  stringList(nil, rule.DestinationIPGroups),
+				destinationPorts:     stringList(nil, rule.DestinationPorts),
+				destinationFQDNs:     stringList(nil, rule.DestinationFqdns),
+			})
+		}
+	}
+	return rules
+}
+
+func classicApplicationRules(collections *[]network.AzureFirewallApplicationRuleCollection) []firewallRule {
+	rules := make([]firewallRule, 0)
+	if collections == nil {
+		return rules
+	}
+	for _, collection := range *collections {
+		properties := collection.AzureFirewallApplicationRuleCollectionPropertiesFormat
+		if properties == nil || properties.Rules == nil {
+			continue
+		}
+		for _, rule := range *properties.Rules {
+			protocols := make([]string, 0)
+			if rule.Protocols != nil {
+				for _, protocol := range *rule.Protocols {
+					protocols = append(protocols, applicationRuleProtocol(string(protocol.ProtocolType), protocol.Port))
+				}
+			}
+			rules = append(rules, firewallRule{
+				ruleType:         FirewallRuleTypeApplication,
+				collection:       stringValue(collection.Name),
+				priority:         int32Value(properties.Priority),
+				order:            []int32{0, 0, int32Value(properties.Priority), 0},
+				name:             stringValue(rule.Name),
+				allow:            properties.Action == nil || properties.Action.Type != network.AzureFirewallRCActionTypeDeny,
+				protocols:        protocols,
+				sourceAddresses:  stringList(nil, rule.SourceAddresses),
+				sourceIPGroups:   stringList(nil, rule.SourceIPGroups),
+				destinationFQDNs: stringList(nil, rule.TargetFqdns),
+			})
+		}
+	}
+	return rules
+}
+
+// policyRules converts the rules of a rule collection of a firewall policy, a DNAT collection or a filter collection of network
+// and application rules
+func policyRules(depth int32, groupPriority int32, basicCollection fwpolicy.BasicFirewallPolicyRuleCollection) []firewallRule {
+	rules := make([]firewallRule, 0)
+	if collection, ok := basicCollection.AsFirewallPolicyNatRuleCollection(); ok {
+		if collection.Rules == nil {
+			return rules
+		}
+		if collection.Action != nil && collection.Action.Type != "" && collection.Action.Type != fwpolicy.DNAT {
+			log.Printf("Warning: NAT rule collection %s is ignored, only DNAT rules are evaluated\n", stringValue(collection.Name))
+			return rules
+		}
+		for index, basicRule := range *collection.Rules {
+			natRule, ok := basicRule.AsNatRule()
+			if !ok {
+				continue
+			}
+			rules = append(rules, firewallRule{
+				ruleType:             FirewallRuleTypeNat,
+				collection:           stringValue(collection.Name),
+				priority:             int32Value(collection.Priority),
+				order:                []int32{depth, groupPriority, int32Value(collection.Priority), int32(index)},
+				name:                 stringValue(natRule.Name),
+				allow:                true,
+				protocols:            policyRuleProtocols(natRule.IPProtocols),
+				sourceAddresses:      stringList(nil, natRule.SourceAddresses),
+				sourceIPGroups:       stringList(nil, natRule.SourceIPGroups),
+				destinationAddresses: stringList(nil, natRule.DestinationAddresses),
+				destinationPorts:     stringList(nil, natRule.DestinationPorts),
+				translatedAddress:    stringValue(natRule.TranslatedAddress),
+				translatedPort:       stringValue(natRule.TranslatedPort),
+			})
+		}
+		return rules
+	}
+
+	collection, ok := basicCollection.AsFirewallPolicyFilterRuleCollection()
+	if !ok || collection.Rules == nil {
+		return rules
+	}
+	allow := collection.Action == nil || collection.Action.Type != fwpolicy.FirewallPolicyFilterRuleCollectionActionTypeDeny
+	for index, basicRule := range *collection.Rules {
+		rule := firewallRule{
+			collection: stringValue(collection.Name),
+			priority:   int32Value(collection.Priority),
+			order:      []int32{depth, groupPriority, int32Value(collection.Priority), int32(index)},
+			allow:      allow,
+		}
+		if networkRule, ok := basicRule.AsRule(); ok {
+			rule.ruleType = FirewallRuleTypeNetwork
+			rule.name = stringValue(networkRule.Name)
+			rule.protocols = policyRuleProtocols(networkRule.IPProtocols)
+			rule.sourceAddresses = stringList(nil, networkRule.SourceAddresses)
+			rule.sourceIPGroups = stringList(nil, networkRule.SourceIPGroups)
+			rule.destinationAddresses = stringList(nil, networkRule.DestinationAddresses)
+			rule.destinationIPGroups = stringList(nil, networkRule.DestinationIPGroups)
+			rule.destinationPorts = stringList(nil, networkRule.DestinationPorts)
+			rule.destinationFQDNs = stringList(nil, networkRule.DestinationFqdns)
+		} else if applicationRule, ok := basicRule.AsApplicationRule(); ok {
+			rule.ruleType = FirewallRuleTypeApplication
+			rule.name = stringValue(applicationRule.Name)
+			if applicationRule.Protocols != nil {
+				for _, protocol := range *applicationRule.Protocols {
+					rule.protocols = append(rule.protocols, applicationRuleProtocol(string(protocol.ProtocolType), protocol.Port))
+				}
+			}
+			rule.sourceAddresses = stringList(nil, applicationRule.SourceAddresses)
+			rule.sourceIPGroups = stringList(nil, applicationRule.SourceIPGroups)
+			rule.destinationFQDNs = stringList(nil, applicationRule.TargetFqdns)
+		} else {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func policyRuleProtocols(protocols *[]fwpolicy.FirewallPolicyRuleNetworkProtocol) []string {
+	list := make([]string, 0)
+	if protocols != nil {
+		for _, protocol := range *protocols {
+			list = append(list, string(protocol))
+		}
+	}
+	return list
+}
+
+func networkRuleProtocols(protocols *[]network.AzureFirewallNetworkRuleProtocol) []string {
+	list := make([]string, 0)
+	if protocols != nil {
+		for _, protocol := range *protocols {
+			list = append(list, string(protocol))
+		}
+	}
+	return list
+}
+
+func applicationRuleProtocol(protocolType string, port *int32) string {
+	if port == nil {
+		return protocolType
+	}
+	return fmt.Sprintf("%s:%d", protocolType, *port)
+}
+
+/********************************
+		Firewall policies
+*********************************/
+
+// GetFirewallPolicy is the same as GetFirewallPolicyE but fails the test on error
+func GetFirewallPolicy(t *testing.T, resourceGroupName string, policyName string, subscriptionID ...string) *network.FirewallPolicy {
+	t.Helper()
+	policy, err := GetFirewallPolicyE(resourceGroupName, policyName, subscriptionID...)
+	failOnAzureError(t, err, "get", "Microsoft.Network/firewallPolicies", policyName, resourceGroupName)
+	return policy
+}
+
+// GetFirewallPolicyE will return FirewallPolicy object and an error object
+func GetFirewallPolicyE(resourceGroupName string, policyName string, subscriptionID ...string) (*network.FirewallPolicy, error) {
+	return GetFirewallPolicyWithContextE(context.Background(), resourceGroupName, policyName, subscriptionID...)
+}
+
+// GetFirewallPolicyWithContextE will return FirewallPolicy object and an error object
+func GetFirewallPolicyWithContextE(ctx context.Context, resourceGroupName string, policyName string, subscriptionID ...string) (*network.FirewallPolicy, error) {
+	client, err := GetFirewallPoliciesClientE(getTargetSubscription(subscriptionID...))
+	if err != nil {
+		return nil, err
+	}
+	policy, err := client.Get(ctx, resourceGroupName, policyName, "")
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ListFirewallPolicyRuleCollectionGroups is the same as ListFirewallPolicyRuleCollectionGroupsE but fails the test on error
+func ListFirewallPolicyRuleCollectionGroups(t *testing.T, resourceGroupName string, policyName string, subscriptionID ...string) []fwpolicy.FirewallPolicyRuleCollectionGroup {
+	t.Helper()
+	groups, err := ListFirewallPolicyRuleCollectionGroupsE(resourceGroupName, policyName, subscriptionID...)
+	failOnAzureError(t, err, "list rule collection groups of", "Microsoft.Network/firewallPolicies", policyName, resourceGroupName)
+	return groups
+}
+
+// ListFirewallPolicyRuleCollectionGroupsE returns the rule collection groups of a firewall policy
+func ListFirewallPolicyRuleCollectionGroupsE(resourceGroupName string, policyName string, subscriptionID ...string) ([]fwpolicy.FirewallPolicyRuleCollectionGroup, error) {
+	return ListFirewallPolicyRuleCollectionGroupsWithContextE(context.Background(), resourceGroupName, policyName, subscriptionID...)
+}
+
+// ListFirewallPolicyRuleCollectionGroupsWithContextE returns the rule collection groups of a firewall policy
+func ListFirewallPolicyRuleCollectionGroupsWithContextE(ctx context.Context, resourceGroupName string, policyName string, subscriptionID ...string) ([]fwpolicy.FirewallPolicyRuleCollectionGroup, error) {
+	client, err := GetFirewallPolicyRuleCollectionGroupsClientE(getTargetSubscription(subscriptionID...))
+	if err != nil {
+		return nil, err
+	}
+	iterator, err := client.ListComplete(ctx, resourceGroupName, policyName)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]fwpolicy.FirewallPolicyRuleCollectionGroup, 0)
+	for iterator.NotDone() {
+		groups = append(groups, iterator.Value())
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+// GetFirewallPoliciesClientE creates a FirewallPoliciesClient
+func GetFirewallPoliciesClientE(subscriptionID string) (*network.FirewallPoliciesClient, error) {
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewFirewallPoliciesClient(subscriptionID)
+		return &client
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(*network.FirewallPoliciesClient), nil
+}
+
+// GetFirewallPolicyRuleCollectionGroupsClientE creates a FirewallPolicyRuleCollectionGroupsClient
+func GetFirewallPolicyRuleCollectionGroupsClientE(subscriptionID string) (*fwpolicy.FirewallPolicyRuleCollectionGroupsClient, error) {
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := fwpolicy.NewFirewallPolicyRuleCollectionGroupsClient(subscriptionID)
+		return &client
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(*fwpolicy.FirewallPolicyRuleCollectionGroupsClient), nil
+}
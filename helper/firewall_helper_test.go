@@ -0,0 +1,168 @@
+package helper
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	fwpolicy "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-05-01/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIPGroupID            = testResourceGroupID + "/providers/Microsoft.Network/ipGroups/ipg-spokes"
+	testBaseFirewallPolicyID = testResourceGroupID + "/providers/Microsoft.Network/firewallPolicies/afwp-base"
+	testFirewallPolicyID     = testResourceGroupID + "/providers/Microsoft.Network/firewallPolicies/afwp-spoke"
+)
+
+func TestEvaluateFirewallFlowE(t *testing.T) {
+	var firewall network.AzureFirewall
+	loadTestNetworkResource(t, filepath.Join("firewall", "afw-classic.json"), &firewall)
+	serviceTags := ServiceTagPrefixes{"AzureCloud": {"20.38.96.0/19"}, testIPGroupID: {"10.3.0.0/16"}}
+
+	cases := []struct {
+		name     string
+		flow     NetworkFlow
+		expected FirewallFlowDecision
+	}{
+		// the DNAT rule wins over the deny of the source range
+		{"DNAT before network rules", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.15", DestinationAddress: "20.1.1.1", DestinationPort: 443},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeNat, RuleCollection: "dnat-web", RuleName: "web", Priority: 100, TranslatedAddress: "10.1.2.4", TranslatedPort: "8443"}},
+		{"IP range", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.15", DestinationAddress: "10.2.0.4", DestinationPort: 443},
+			FirewallFlowDecision{RuleType: FirewallRuleTypeNetwork, RuleCollection: "deny-quarantine", RuleName: "quarantine", Priority: 100}},
+		{"IP range bounds included", NetworkFlow{Protocol: "Udp", SourceAddress: "10.1.0.20", DestinationAddress: "10.2.0.4", DestinationPort: 53},
+			FirewallFlowDecision{RuleType: FirewallRuleTypeNetwork, RuleCollection: "deny-quarantine", RuleName: "quarantine", Priority: 100}},
+		{"outside IP range", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.21", DestinationAddress: "10.2.0.4", DestinationPort: 8080},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeNetwork, RuleCollection: "allow-spokes", RuleName: "spoke1-to-spoke2", Priority: 200}},
+		{"port outside the rule", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "10.2.0.4", DestinationPort: 22},
+			FirewallFlowDecision{}},
+		{"IP group and service tag", NetworkFlow{Protocol: "Udp", SourceAddress: "10.3.0.4", DestinationAddress: "20.38.100.1", DestinationPort: 53},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeNetwork, RuleCollection: "allow-spokes", RuleName: "dns", Priority: 200}},
+		{"network rule FQDN", NetworkFlow{Protocol: "Udp", SourceAddress: "10.1.0.4", DestinationFQDN: "time.windows.com", DestinationPort: 123},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeNetwork, RuleCollection: "allow-spokes", RuleName: "ntp", Priority: 200}},
+		// network rules are processed before application rules whatever the priorities
+		{"network before application rules", NetworkFlow{Protocol: "Https", SourceAddress: "10.1.0.15", DestinationFQDN: "www.contoso.com", DestinationPort: 443},
+			FirewallFlowDecision{RuleType: FirewallRuleTypeNetwork, RuleCollection: "deny-quarantine", RuleName: "quarantine", Priority: 100}},
+		{"application collection priority", NetworkFlow{Protocol: "Https", SourceAddress: "10.1.0.4", DestinationFQDN: "www.contoso.com", DestinationPort: 443},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeApplication, RuleCollection: "allow-contoso", RuleName: "contoso", Priority: 50}},
+		{"FQDN wildcard", NetworkFlow{Protocol: "Https", SourceAddress: "10.1.0.4", DestinationFQDN: "Learn.Microsoft.com", DestinationPort: 443},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeApplication, RuleCollection: "allow-web", RuleName: "microsoft", Priority: 300}},
+		{"FQDN wildcard excludes the domain", NetworkFlow{Protocol: "Https", SourceAddress: "10.1.0.4", DestinationFQDN: "microsoft.com", DestinationPort: 443},
+			FirewallFlowDecision{RuleType: FirewallRuleTypeApplication, RuleCollection: "deny-web", RuleName: "everything", Priority: 400}},
+		{"TCP matches application protocols", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationFQDN: "login.microsoftonline.com", DestinationPort: 80},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeApplication, RuleCollection: "allow-web", RuleName: "microsoft", Priority: 300}},
+		{"application protocol port", NetworkFlow{Protocol: "Http", SourceAddress: "10.1.0.4", DestinationFQDN: "learn.microsoft.com", DestinationPort: 8080},
+			FirewallFlowDecision{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			decision, err := EvaluateFirewallFlowE(&firewall, c.flow, serviceTags)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, *decision, decision.String())
+		})
+	}
+}
+
+func TestEvaluateFirewallFlowEErrors(t *testing.T) {
+	var firewall network.AzureFirewall
+	loadTestNetworkResource(t, filepath.Join("firewall", "afw-classic.json"), &firewall)
+
+	_, err := EvaluateFirewallFlowE(nil, NetworkFlow{}, nil)
+	assert.EqualError(t, err, "Firewall is nil")
+
+	// the prefixes of IP groups are only known from the service tags
+	_, err = EvaluateFirewallFlowE(&firewall, NetworkFlow{Protocol: "Udp", SourceAddress: "10.3.0.4", DestinationAddress: "20.38.100.1", DestinationPort: 53}, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Network rule dns in allow-spokes: the prefixes of IP group "+testIPGroupID+" are unknown")
+	}
+
+	decision, err := EvaluateFirewallFlowE(&network.AzureFirewall{}, NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "10.2.0.4", DestinationPort: 443}, nil)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, "Deny, no rule matches", decision.String())
+}
+
+func TestEvaluateFirewallPolicyFlowE(t *testing.T) {
+	var base, spoke []fwpolicy.FirewallPolicyRuleCollectionGroup
+	loadTestNetworkResource(t, filepath.Join("firewall", "policy-base.json"), &base)
+	loadTestNetworkResource(t, filepath.Join("firewall", "policy-spoke.json"), &spoke)
+
+	cases := []struct {
+		name     string
+		flow     NetworkFlow
+		expected FirewallFlowDecision
+	}{
+		// the rules of the base policy take precedence over higher priority rules of the policy
+		{"base policy DNAT", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "20.1.1.1", DestinationPort: 22},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeNat, RuleCollection: "dnat-ssh", RuleName: "ssh", Priority: 100, TranslatedAddress: "10.1.2.5", TranslatedPort: "22"}},
+		{"base policy network rule", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "10.2.0.4", DestinationPort: 445},
+			FirewallFlowDecision{RuleType: FirewallRuleTypeNetwork, RuleCollection: "deny-smb", RuleName: "smb", Priority: 100}},
+		// spoke-high is listed last but has the higher priority
+		{"rule collection group priority", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "10.2.0.4", DestinationPort: 443},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeNetwork, RuleCollection: "allow-spokes", RuleName: "web", Priority: 100}},
+		{"rule collection priority", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.99", DestinationAddress: "10.2.0.4", DestinationPort: 443},
+			FirewallFlowDecision{RuleType: FirewallRuleTypeNetwork, RuleCollection: "deny-quarantine", RuleName: "quarantine", Priority: 50}},
+		{"lower priority rule collection group", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.3.0.4", DestinationAddress: "10.2.0.4", DestinationPort: 443},
+			FirewallFlowDecision{RuleType: FirewallRuleTypeNetwork, RuleCollection: "deny-web", RuleName: "web", Priority: 100}},
+		{"FQDN wildcard", NetworkFlow{Protocol: "Https", SourceAddress: "10.1.0.4", DestinationFQDN: "api.github.com", DestinationPort: 443},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeApplication, RuleCollection: "allow-github", RuleName: "github", Priority: 200}},
+		{"FQDN", NetworkFlow{Protocol: "Https", SourceAddress: "10.1.0.4", DestinationFQDN: "github.com", DestinationPort: 443},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeApplication, RuleCollection: "allow-github", RuleName: "github", Priority: 200}},
+		{"application collection priority", NetworkFlow{Protocol: "Https", SourceAddress: "10.1.0.4", DestinationFQDN: "www.github.com", DestinationPort: 443},
+			FirewallFlowDecision{Allowed: true, RuleType: FirewallRuleTypeApplication, RuleCollection: "allow-github", RuleName: "github", Priority: 200}},
+		{"application rule source", NetworkFlow{Protocol: "Https", SourceAddress: "10.3.0.4", DestinationFQDN: "www.github.com", DestinationPort: 443},
+			FirewallFlowDecision{RuleType: FirewallRuleTypeApplication, RuleCollection: "deny-github", RuleName: "www", Priority: 300}},
+		{"no rule", NetworkFlow{Protocol: "Udp", SourceAddress: "10.1.0.4", DestinationAddress: "10.2.0.4", DestinationPort: 53},
+			FirewallFlowDecision{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			decision, err := EvaluateFirewallPolicyFlowE([][]fwpolicy.FirewallPolicyRuleCollectionGroup{base, spoke}, c.flow, nil)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, *decision, decision.String())
+		})
+	}
+
+	// without the base policy
+	decision, err := EvaluateFirewallPolicyFlowE([][]fwpolicy.FirewallPolicyRuleCollectionGroup{spoke}, NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "20.1.1.1", DestinationPort: 22}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Allow by nat rule ssh-override in dnat-ssh (priority 50) to 10.9.9.9:22", decision.String())
+}
+
+func TestGetFirewallFlowDecisionE(t *testing.T) {
+	s := setupTestARMServer(t)
+	firewallID := testResourceGroupID + "/providers/Microsoft.Network/azureFirewalls/afw-hub"
+	s.AddResource(t, firewallID, `{"properties": {"firewallPolicy": {"id": "`+testFirewallPolicyID+`"}}}`)
+	s.AddResource(t, testFirewallPolicyID, `{"properties": {"basePolicy": {"id": "`+testBaseFirewallPolicyID+`"}}}`)
+	s.AddResource(t, testBaseFirewallPolicyID, `{"properties": {}}`)
+	for policyID, file := range map[string]string{testBaseFirewallPolicyID: "policy-base.json", testFirewallPolicyID: "policy-spoke.json"} {
+		var groups []map[string]interface{}
+		loadTestNetworkResource(t, filepath.Join("firewall", file), &groups)
+		for _, group := range groups {
+			s.AddResource(t, policyID+"/ruleCollectionGroups/"+group["name"].(string), group)
+		}
+	}
+
+	decision, err := GetFirewallFlowDecisionE("rg-test", "afw-hub", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "10.2.0.4", DestinationPort: 445}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Deny by network rule smb in deny-smb (priority 100)", decision.String())
+
+	AssertFirewallAllowsFlow(t, "rg-test", "afw-hub", "Tcp 10.1.0.4 -> 10.2.0.4:443", nil)
+	AssertFirewallDeniesFlow(t, "rg-test", "afw-hub", "Tcp 10.1.0.99 -> 10.2.0.4:443", nil)
+	AssertFirewallAllowsFlow(t, "rg-test", "afw-hub", "Https 10.1.0.4 -> fqdn:api.github.com:443", nil)
+
+	// a firewall without policy is evaluated with its classic rule collections
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "firewall", "afw-classic.json"))
+	require.NoError(t, err)
+	s.AddResource(t, firewallID, content)
+	AssertFirewallAllowsFlow(t, "rg-test", "afw-hub", "Tcp 10.1.0.4 -> 10.2.0.4:8080", nil)
+	AssertFirewallDeniesFlow(t, "rg-test", "afw-hub", "Tcp 10.1.0.15 -> 10.2.0.4:8080", nil)
+
+	// a base policy referring to itself
+	s.AddResource(t, testBaseFirewallPolicyID, `{"properties": {"basePolicy": {"id": "`+testBaseFirewallPolicyID+`"}}}`)
+	s.AddResource(t, firewallID, `{"properties": {"firewallPolicy": {"id": "`+testFirewallPolicyID+`"}}}`)
+	_, err = GetFirewallFlowDecisionE("rg-test", "afw-hub", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "10.2.0.4", DestinationPort: 445}, nil)
+	assert.EqualError(t, err, "Firewall policy "+testFirewallPolicyID+" has more than 10 levels of base policies")
+}
@@ -0,0 +1,285 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+/********************************
+		Generic resources
+*********************************/
+
+// GetResourceByID is the same as GetResourceByIDE but fails the test on error
+func GetResourceByID(t *testing.T, resourceID string) map[string]interface{} {
+	t.Helper()
+	resource, err := GetResourceByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "", resourceID)
+	return resource
+}
+
+// GetResourceByIDE returns any resource from its resource ID as a generic map, see GetPropertyByPathE to read it.
+// The latest stable API version of the resource type is looked up in the resource provider.
+func GetResourceByIDE(resourceID string) (map[string]interface{}, error) {
+	return GetResourceByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetResourceByIDWithContextE returns any resource from its resource ID as a generic map, see GetPropertyByPathE to read it.
+// The latest stable API version of the resource type is looked up in the resource provider.
+func GetResourceByIDWithContextE(ctx context.Context, resourceID string) (map[string]interface{}, error) {
+	id, err := ParseResourceIDE(resourceID)
+	if err != nil {
+		return nil, err
+	}
+	apiVersion, err := GetLatestAPIVersionWithContextE(ctx, id.ResourceType(), id.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetResourcesClientE(id.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	req, err := client.GetByIDPreparer(ctx, resourceID, apiVersion)
+	if err != nil {
+		return nil, autorest.NewErrorWithError(err, "helper", "GetResourceByIDE", nil, "Failure preparing request")
+	}
+	resp, err := client.GetByIDSender(req)
+	if err != nil {
+		return nil, autorest.NewErrorWithError(err, "helper", "GetResourceByIDE", resp, "Failure sending request")
+	}
+	resource := make(map[string]interface{})
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(&resource),
+		autorest.ByClosing())
+	if err != nil {
+		return nil, autorest.NewErrorWithError(err, "helper", "GetResourceByIDE", resp, "Failure responding to request")
+	}
+	return resource, nil
+}
+
+// GetResourcePropertyByIDE returns the property at path of any resource, e.g. properties.provisioningState
+func GetResourcePropertyByIDE(resourceID string, path string) (interface{}, error) {
+	resource, err := GetResourceByIDE(resourceID)
+	if err != nil {
+		return nil, err
+	}
+	return GetPropertyByPathE(resource, path)
+}
+
+// ListResourcesInResourceGroup is the same as ListResourcesInResourceGroupE but fails the test on error
+func ListResourcesInResourceGroup(t *testing.T, resourceGroupName string, subscriptionID ...string) []resources.GenericResourceExpanded {
+	t.Helper()
+	list, err := ListResourcesInResourceGroupE(resourceGroupName, subscriptionID...)
+	failOnAzureError(t, err, "list resources of", "Microsoft.Resources/resourceGroups", resourceGroupName, "")
+	return list
+}
+
+// ListResourcesInResourceGroupE returns every top level resource of a resource group
+func ListResourcesInResourceGroupE(resourceGroupName string, subscriptionID ...string) ([]resources.GenericResourceExpanded, error) {
+	return ListResourcesInResourceGroupWithContextE(context.Background(), resourceGroupName, subscriptionID...)
+}
+
+// ListResourcesInResourceGroupWithContextE returns every top level resource of a resource group
+func ListResourcesInResourceGroupWithContextE(ctx context.Context, resourceGroupName string, subscriptionID ...string) ([]resources.GenericResourceExpanded, error) {
+	client, err := GetResourcesClientE(getTargetSubscription(subscriptionID...))
+	if err != nil {
+		return nil, err
+	}
+	iterator, err := client.ListByResourceGroupComplete(ctx, resourceGroupName, "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]resources.GenericResourceExpanded, 0)
+	for iterator.NotDone() {
+		list = append(list, iterator.Value())
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// ListResourcesInSubscription is the same as ListResourcesInSubscriptionE but fails the test on error
+func ListResourcesInSubscription(t *testing.T, subscriptionID ...string) []resources.GenericResourceExpanded {
+	t.Helper()
+	list, err := ListResourcesInSubscriptionE(subscriptionID...)
+	failOnAzureError(t, err, "list resources of subscription", "", getTargetSubscription(subscriptionID...), "")
+	return list
+}
+
+// ListResourcesInSubscriptionE returns every top level resource of a subscription
+func ListResourcesInSubscriptionE(subscriptionID ...string) ([]resources.GenericResourceExpanded, error) {
+	return ListResourcesInSubscriptionWithContextE(context.Background(), subscriptionID...)
+}
+
+// ListResourcesInSubscriptionWithContextE returns every top level resource of a subscription
+func ListResourcesInSubscriptionWithContextE(ctx context.Context, subscriptionID ...string) ([]resources.GenericResourceExpanded, error) {
+	client, err := GetResourcesClientE(getTargetSubscription(subscriptionID...))
+	if err != nil {
+		return nil, err
+	}
+	iterator, err := client.ListComplete(ctx, "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]resources.GenericResourceExpanded, 0)
+	for iterator.NotDone() {
+		list = append(list, iterator.Value())
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// ListResourceGroups is the same as ListResourceGroupsE but fails the test on error
+func ListResourceGroups(t *testing.T, subscriptionID ...string) []resources.Group {
+	t.Helper()
+	list, err := ListResourceGroupsE(subscriptionID...)
+	failOnAzureError(t, err, "list resource groups of subscription", "", getTargetSubscription(subscriptionID...), "")
+	return list
+}
+
+// ListResourceGroupsE returns every resource group of a subscription
+func ListResourceGroupsE(subscriptionID ...string) ([]resources.Group, error) {
+	return ListResourceGroupsWithContextE(context.Background(), subscriptionID...)
+}
+
+// ListResourceGroupsWithContextE returns every resource group of a subscription
+func ListResourceGroupsWithContextE(ctx context.Context, subscriptionID ...string) ([]resources.Group, error) {
+	client, err := GetGroupsClientE(getTargetSubscription(subscriptionID...))
+	if err != nil {
+		return nil, err
+	}
+	iterator, err := client.ListComplete(ctx, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]resources.Group, 0)
+	for iterator.NotDone() {
+		list = append(list, iterator.Value())
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// GetResourcesClientE creates a generic resources Client
+func GetResourcesClientE(subscriptionID string) (*resources.Client, error) {
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := resources.NewClient(subscriptionID)
+		return &client
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(*resources.Client), nil
+}
+
+/********************************
+		API versions
+*********************************/
+
+// apiVersions caches the API version found for each subscription and resource type
+var apiVersions = struct {
+	mu       sync.Mutex
+	versions map[string]string
+}{versions: make(map[string]string)}
+
+// GetLatestAPIVersionE returns the latest stable API version of a resource type, e.g. Microsoft.Network/virtualNetworks/subnets,
+// or the latest preview when there is no stable one. The result is cached for the test run.
+func GetLatestAPIVersionE(resourceType string, subscriptionID ...string) (string, error) {
+	return GetLatestAPIVersionWithContextE(context.Background(), resourceType, subscriptionID...)
+}
+
+// GetLatestAPIVersionWithContextE returns the latest stable API version of a resource type, e.g. Microsoft.Network/virtualNetworks/subnets,
+// or the latest preview when there is no stable one. The result is cached for the test run.
+func GetLatestAPIVersionWithContextE(ctx context.Context, resourceType string, subscriptionID ...string) (string, error) {
+	target := getTargetSubscription(subscriptionID...)
+	parts := strings.SplitN(resourceType, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("Invalid resource type %s: expected {namespace}/{type}", resourceType)
+	}
+	key := strings.ToLower(target + "|" + resourceType)
+
+	apiVersions.mu.Lock()
+	version, ok := apiVersions.versions[key]
+	apiVersions.mu.Unlock()
+	if ok {
+		return version, nil
+	}
+
+	client, err := GetProvidersClientE(target)
+	if err != nil {
+		return "", err
+	}
+	provider, err := client.Get(ctx, parts[0], "")
+	if err != nil {
+		return "", err
+	}
+	if provider.ResourceTypes == nil {
+		return "", fmt.Errorf("Resource provider %s has no resource types", parts[0])
+	}
+	for _, providerType := range *provider.ResourceTypes {
+		if providerType.ResourceType == nil || !strings.EqualFold(*providerType.ResourceType, parts[1]) || providerType.APIVersions == nil {
+			continue
+		}
+		version = latestAPIVersion(*providerType.APIVersions)
+		break
+	}
+	if version == "" {
+		return "", fmt.Errorf("No API version found for resource type %s", resourceType)
+	}
+
+	apiVersions.mu.Lock()
+	apiVersions.versions[key] = version
+	apiVersions.mu.Unlock()
+	return version, nil
+}
+
+// latestAPIVersion returns the latest stable version, or the latest preview if there is no stable one.
+// Versions are dates (2020-04-01, 2020-04-01-preview or 2020-04-01-beta) so they sort as strings.
+func latestAPIVersion(versions []string) string {
+	sorted := append([]string(nil), versions...)
+	sort.Sort(sort.Reverse(sort.StringSlice(sorted)))
+	for _, version := range sorted {
+		if isStableAPIVersion(version) {
+			return version
+		}
+	}
+	if len(sorted) > 0 {
+		return sorted[0]
+	}
+	return ""
+}
+
+// isStableAPIVersion tells whether version is a bare YYYY-MM-DD date, without any suffix such as -preview
+// or -privatepreview
+func isStableAPIVersion(version string) bool {
+	_, err := time.Parse("2006-01-02", version)
+	return err == nil
+}
+
+// GetProvidersClientE creates a ProvidersClient
+func GetProvidersClientE(subscriptionID string) (*resources.ProvidersClient, error) {
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := resources.NewProvidersClient(subscriptionID)
+		return &client
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(*resources.ProvidersClient), nil
+}
@@ -0,0 +1,45 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestAPIVersion(t *testing.T) {
+	cases := []struct {
+		name     string
+		versions []string
+		latest   string
+	}{
+		{"stable", []string{"2019-11-01", "2020-04-01", "2020-03-01"}, "2020-04-01"},
+		{"newer preview", []string{"2020-04-01", "2021-02-01-preview"}, "2020-04-01"},
+		{"newer private preview", []string{"2020-04-01", "2021-02-01-privatepreview"}, "2020-04-01"},
+		{"newer alpha and beta", []string{"2020-04-01", "2021-01-01-alpha", "2021-02-01-beta"}, "2020-04-01"},
+		{"newer other suffix", []string{"2020-04-01", "2021-02-01-rc"}, "2020-04-01"},
+		{"only previews", []string{"2020-04-01-preview", "2021-02-01-privatepreview", "2020-12-01-preview"}, "2021-02-01-privatepreview"},
+		{"not a date", []string{"2015-06-15", "2016-99-01", "latest"}, "2015-06-15"},
+		{"empty", []string{}, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.latest, latestAPIVersion(c.versions))
+		})
+	}
+}
+
+func TestGetLatestAPIVersionE(t *testing.T) {
+	s := setupTestARMServer(t)
+	s.AddResource(t, testVnetID, testVnet)
+
+	version, err := GetLatestAPIVersionE("Microsoft.Network/virtualNetworks/subnets")
+	require.NoError(t, err)
+	assert.Equal(t, fakeAPIVersion, version)
+
+	// the versions are cached for the test run, so the missing type must not be seeded by any other test
+	_, err = GetLatestAPIVersionE("Microsoft.Network/unseededTypes")
+	assert.Error(t, err)
+	_, err = GetLatestAPIVersionE("Microsoft.Network")
+	assert.Error(t, err)
+}
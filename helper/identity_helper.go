@@ -2,7 +2,6 @@ package helper
 
 import (
 	"fmt"
-	"os"
 )
 
 const (
@@ -21,7 +20,7 @@ const (
 )
 
 // GetFullyQualifiedRoleDefinitionID returns a FQDN Role Definition ID
-func GetFullyQualifiedRoleDefinitionID(roleID string) string {
-	subscription := os.Getenv("ARM_SUBSCRIPTION_ID")
+func GetFullyQualifiedRoleDefinitionID(roleID string, subscriptionID ...string) string {
+	subscription := getTargetSubscription(subscriptionID...)
 	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", subscription, roleID)
 }
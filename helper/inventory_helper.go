@@ -0,0 +1,152 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// InventoryManifest lists the resources expected in a resource group, e.g. in YAML
+//
+//	resources:
+//	  - type: Microsoft.Network/virtualNetworks
+//	    name: vnet-hub
+//	  - type: Microsoft.Network/publicIPAddresses
+//	    name: pip-*
+//	    count: 2
+//	  - type: Microsoft.Insights/*
+//	    name: "*"
+//	    optional: true
+type InventoryManifest struct {
+	Resources []InventoryEntry `mapstructure:"resources"`
+}
+
+// InventoryEntry is an expected resource. Type and Name are case insensitive patterns as in path.Match,
+// e.g. Microsoft.Network/* or vnet-*.
+type InventoryEntry struct {
+	Type string `mapstructure:"type"`
+	Name string `mapstructure:"name"`
+	// Count is the number of resources expected to match, at least one when 0
+	Count int `mapstructure:"count"`
+	// Optional resources are allowed but not reported when missing
+	Optional bool `mapstructure:"optional"`
+}
+
+func (e InventoryEntry) String() string {
+	return fmt.Sprintf("%s %s", e.Type, e.Name)
+}
+
+// validate returns path.ErrBadPattern when the type or the name is not a valid pattern
+func (e InventoryEntry) validate() error {
+	for _, pattern := range []string{e.Type, e.Name} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("Invalid pattern %q in inventory entry %s: %s", pattern, e, err)
+		}
+	}
+	return nil
+}
+
+// matches ignores the pattern errors, the entries are validated before
+func (e InventoryEntry) matches(resourceType string, resourceName string) bool {
+	typeMatch, _ := path.Match(strings.ToLower(e.Type), strings.ToLower(resourceType))
+	nameMatch, _ := path.Match(strings.ToLower(e.Name), strings.ToLower(resourceName))
+	return typeMatch && nameMatch
+}
+
+// InventoryDiff is the difference between a resource group and its manifest
+type InventoryDiff struct {
+	// Missing describes the manifest entries without enough matching resources
+	Missing []string
+	// Unexpected holds the IDs of the resources that match no manifest entry
+	Unexpected []string
+}
+
+// IsEmpty tells whether the resource group matches its manifest
+func (d InventoryDiff) IsEmpty() bool {
+	return len(d.Missing) == 0 && len(d.Unexpected) == 0
+}
+
+// LoadInventoryManifestE reads an InventoryManifest from a YAML file, replacing ${VAR} references by environment variables
+func LoadInventoryManifestE(filePath string) (*InventoryManifest, error) {
+	manifest := InventoryManifest{}
+	if err := decodeYamlFileE(filePath, &manifest); err != nil {
+		return nil, err
+	}
+	for _, entry := range manifest.Resources {
+		if entry.Type == "" || entry.Name == "" {
+			return nil, fmt.Errorf("Invalid inventory manifest %s: every resource needs a type and a name", filePath)
+		}
+		if err := entry.validate(); err != nil {
+			return nil, fmt.Errorf("Invalid inventory manifest %s: %s", filePath, err)
+		}
+	}
+	return &manifest, nil
+}
+
+// CheckResourceGroupInventoryE lists the resources of a resource group and compares them to manifest
+func CheckResourceGroupInventoryE(resourceGroupName string, manifest InventoryManifest, subscriptionID ...string) (*InventoryDiff, error) {
+	return CheckResourceGroupInventoryWithContextE(context.Background(), resourceGroupName, manifest, subscriptionID...)
+}
+
+// CheckResourceGroupInventoryWithContextE lists the resources of a resource group and compares them to manifest
+func CheckResourceGroupInventoryWithContextE(ctx context.Context, resourceGroupName string, manifest InventoryManifest, subscriptionID ...string) (*InventoryDiff, error) {
+	for _, entry := range manifest.Resources {
+		if err := entry.validate(); err != nil {
+			return nil, err
+		}
+	}
+	list, err := ListResourcesInResourceGroupWithContextE(ctx, resourceGroupName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := InventoryDiff{Missing: make([]string, 0), Unexpected: make([]string, 0)}
+	matches := make([]int, len(manifest.Resources))
+	for _, resource := range list {
+		if resource.Type == nil || resource.Name == nil {
+			continue
+		}
+		matched := false
+		for i, entry := range manifest.Resources {
+			if entry.matches(*resource.Type, *resource.Name) {
+				matches[i]++
+				matched = true
+			}
+		}
+		if !matched {
+			diff.Unexpected = append(diff.Unexpected, *resource.ID)
+		}
+	}
+	for i, entry := range manifest.Resources {
+		switch {
+		case entry.Count > 0 && matches[i] != entry.Count:
+			diff.Missing = append(diff.Missing, fmt.Sprintf("%s: expected %d, found %d", entry, entry.Count, matches[i]))
+		case entry.Count == 0 && matches[i] == 0 && !entry.Optional:
+			diff.Missing = append(diff.Missing, entry.String())
+		}
+	}
+	sort.Strings(diff.Unexpected)
+	return &diff, nil
+}
+
+// AssertResourceGroupInventory fails the test when the resources of a resource group don't match manifest,
+// reporting every missing and unexpected resource at once
+func AssertResourceGroupInventory(t *testing.T, resourceGroupName string, manifest InventoryManifest, subscriptionID ...string) {
+	t.Helper()
+	diff, err := CheckResourceGroupInventoryWithContextE(NewTestContext(t), resourceGroupName, manifest, subscriptionID...)
+	failOnAzureError(t, err, "list resources of", "Microsoft.Resources/resourceGroups", resourceGroupName, "")
+	if diff.IsEmpty() {
+		return
+	}
+	message := fmt.Sprintf("Resource group %s does not match its inventory", resourceGroupName)
+	if len(diff.Missing) > 0 {
+		message += "\nMissing:\n\t" + strings.Join(diff.Missing, "\n\t")
+	}
+	if len(diff.Unexpected) > 0 {
+		message += "\nUnexpected:\n\t" + strings.Join(diff.Unexpected, "\n\t")
+	}
+	t.Error(message)
+}
@@ -0,0 +1,81 @@
+package helper
+
+import (
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupInventoryResources(t *testing.T) {
+	s := setupTestARMServer(t)
+	s.AddResource(t, testVnetID, testVnet)
+	s.AddResource(t, testResourceGroupID+"/providers/Microsoft.Network/publicIPAddresses/pip-appgw", `{}`)
+	s.AddResource(t, testResourceGroupID+"/providers/Microsoft.Network/publicIPAddresses/pip-bastion", `{}`)
+	s.AddResource(t, testResourceGroupID+"/providers/Microsoft.Insights/components/appi-test", `{}`)
+}
+
+func loadTestInventoryManifest(t *testing.T, file string) InventoryManifest {
+	manifest, err := LoadInventoryManifestE(filepath.Join("testdata", "inventory", file))
+	require.NoError(t, err)
+	return *manifest
+}
+
+func TestLoadInventoryManifestE(t *testing.T) {
+	manifest := loadTestInventoryManifest(t, "manifest.yaml")
+	assert.Equal(t, []InventoryEntry{
+		{Type: "Microsoft.Network/virtualNetworks", Name: "vnet-*"},
+		{Type: "Microsoft.Network/publicIPAddresses", Name: "pip-*", Count: 2},
+		{Type: "Microsoft.Insights/*", Name: "*", Optional: true},
+		{Type: "Microsoft.Storage/storageAccounts", Name: "*", Optional: true},
+	}, manifest.Resources)
+
+	_, err := LoadInventoryManifestE(filepath.Join("testdata", "inventory", "invalid.yaml"))
+	assert.Error(t, err)
+
+	_, err = LoadInventoryManifestE(filepath.Join("testdata", "inventory", "bad-pattern.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `Invalid pattern "pip-[" in inventory entry Microsoft.Network/publicIPAddresses pip-[`)
+}
+
+func TestInventoryEntryMatches(t *testing.T) {
+	entry := InventoryEntry{Type: "Microsoft.Network/*", Name: "PIP-?"}
+	assert.True(t, entry.matches("microsoft.network/publicIPAddresses", "pip-1"))
+	assert.False(t, entry.matches("Microsoft.Network/publicIPAddresses", "pip-10"))
+	assert.False(t, entry.matches("Microsoft.Compute/disks", "pip-1"))
+	// * does not cross the / of child types
+	assert.False(t, entry.matches("Microsoft.Network/virtualNetworks/subnets", "pip-1"))
+}
+
+func TestCheckResourceGroupInventoryEBadPattern(t *testing.T) {
+	s := setupTestARMServer(t)
+
+	for _, entry := range []InventoryEntry{
+		{Type: "Microsoft.Network/[", Name: "*"},
+		{Type: "Microsoft.Network/*", Name: `vnet-\`},
+	} {
+		_, err := CheckResourceGroupInventoryE("rg-test", InventoryManifest{Resources: []InventoryEntry{entry}})
+		require.Error(t, err, entry.String())
+		assert.Contains(t, err.Error(), path.ErrBadPattern.Error())
+	}
+	assert.Empty(t, s.Requests(), "the patterns are checked before listing the resources")
+}
+
+func TestCheckResourceGroupInventoryE(t *testing.T) {
+	setupInventoryResources(t)
+
+	diff, err := CheckResourceGroupInventoryE("rg-test", loadTestInventoryManifest(t, "manifest.yaml"))
+	require.NoError(t, err)
+	assert.True(t, diff.IsEmpty(), "%+v", diff)
+
+	diff, err = CheckResourceGroupInventoryE("rg-test", loadTestInventoryManifest(t, "drift.yaml"))
+	require.NoError(t, err)
+	assert.False(t, diff.IsEmpty())
+	assert.Equal(t, []string{
+		"Microsoft.Network/virtualNetworks vnet-hub",
+		"microsoft.network/publicipaddresses PIP-*: expected 3, found 2",
+	}, diff.Missing)
+	assert.Equal(t, []string{testVnetID}, diff.Unexpected)
+}
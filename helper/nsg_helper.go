@@ -0,0 +1,502 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+)
+
+/********************************
+		Network flows
+*********************************/
+
+// flowFQDNPrefix marks the host name destination of a flow written for ParseNetworkFlowE
+const flowFQDNPrefix = "fqdn:"
+
+// NetworkFlow is a connection evaluated offline against network rules, e.g. the rules of a network security group.
+// Addresses are IP addresses, or service tags such as Internet to stand for any address of the tag.
+// A flow to a host name, e.g. through Azure Firewall, has a DestinationFQDN instead of a DestinationAddress.
+type NetworkFlow struct {
+	// Protocol is Tcp, Udp, Icmp, Esp or Ah
+	Protocol           string
+	SourceAddress      string
+	SourcePort         int
+	DestinationAddress string
+	DestinationFQDN    string
+	DestinationPort    int
+	// SourceApplicationSecurityGroups are the IDs of the application security groups of the source NIC
+	SourceApplicationSecurityGroups []string
+	// DestinationApplicationSecurityGroups are the IDs of the application security groups of the destination NIC
+	DestinationApplicationSecurityGroups []string
+}
+
+func (f NetworkFlow) String() string {
+	source := f.SourceAddress
+	if f.SourcePort != 0 {
+		source = net.JoinHostPort(source, strconv.Itoa(f.SourcePort))
+	}
+	destination := f.DestinationAddress
+	if destination == "" {
+		destination = f.DestinationFQDN
+	}
+	if f.DestinationPort != 0 {
+		destination = net.JoinHostPort(destination, strconv.Itoa(f.DestinationPort))
+	}
+	if f.DestinationAddress == "" && f.DestinationFQDN != "" {
+		destination = flowFQDNPrefix + destination
+	}
+	return fmt.Sprintf("%s %s -> %s", f.Protocol, source, destination)
+}
+
+// ParseNetworkFlowE parses a flow written as "<protocol> <source>[:port] -> <destination>[:port]",
+// e.g. "TCP 10.1.0.4 -> 10.2.0.5:1433", "Udp Internet -> 10.2.0.5:53" or "Tcp [fd00::4]:5000 -> [fd00::5]:443".
+// A destination prefixed with fqdn:, as in "Https 10.1.0.4 -> fqdn:www.microsoft.com:443", is the DestinationFQDN of the flow;
+// any other destination is an address or a service tag such as Storage.WestEurope or AzureCloud.westeurope.
+func ParseNetworkFlowE(flow string) (NetworkFlow, error) {
+	fields := strings.Fields(flow)
+	if len(fields) != 4 || fields[2] != "->" {
+		return NetworkFlow{}, fmt.Errorf("Invalid flow %q: expected \"<protocol> <source>[:port] -> <destination>[:port]\"", flow)
+	}
+	sourceAddress, sourcePort, err := parseFlowEndpoint(fields[1])
+	if err != nil {
+		return NetworkFlow{}, fmt.Errorf("Invalid flow %q: %s", flow, err)
+	}
+	destination := fields[3]
+	isFQDN := len(destination) > len(flowFQDNPrefix) && strings.EqualFold(destination[:len(flowFQDNPrefix)], flowFQDNPrefix)
+	if isFQDN {
+		destination = destination[len(flowFQDNPrefix):]
+	}
+	destinationAddress, destinationPort, err := parseFlowEndpoint(destination)
+	if err != nil {
+		return NetworkFlow{}, fmt.Errorf("Invalid flow %q: %s", flow, err)
+	}
+	parsed := NetworkFlow{
+		Protocol:           fields[0],
+		SourceAddress:      sourceAddress,
+		SourcePort:         sourcePort,
+		DestinationAddress: destinationAddress,
+		DestinationPort:    destinationPort,
+	}
+	if isFQDN {
+		parsed.DestinationAddress = ""
+		parsed.DestinationFQDN = destinationAddress
+	}
+	return parsed, nil
+}
+
+// parseFlowEndpoint splits an address and an optional port, IPv6 addresses with a port are written [address]:port
+func parseFlowEndpoint(endpoint string) (string, int, error) {
+	if strings.Count(endpoint, ":") > 1 && !strings.HasPrefix(endpoint, "[") {
+		// IPv6 address without port
+		return endpoint, 0, nil
+	}
+	if !strings.Contains(endpoint, ":") {
+		return endpoint, 0, nil
+	}
+	address, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return "", 0, err
+	}
+	number, err := strconv.Atoi(port)
+	if err != nil || number < 1 || number > 65535 {
+		return "", 0, fmt.Errorf("invalid port %s", port)
+	}
+	return address, number, nil
+}
+
+/********************************
+		Service tags
+*********************************/
+
+// ServiceTagPrefixes are the address prefixes of service tags by name, e.g. "Storage.WestEurope": {"20.38.96.0/19", ...}
+type ServiceTagPrefixes map[string][]string
+
+// defaultServiceTagPrefixes are used for the tags missing from the ServiceTagPrefixes given to the evaluators.
+// The VirtualNetwork tag stands for the address space of the virtual network and of its peerings, pass it
+// explicitly when the private ranges are not accurate enough.
+var defaultServiceTagPrefixes = ServiceTagPrefixes{
+	"VirtualNetwork":    {"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"},
+	"AzureLoadBalancer": {"168.63.129.16/32"},
+}
+
+// GetServiceTagPrefixesE returns the address prefixes of every service tag as published for a location,
+// to be passed to the offline evaluators
+func GetServiceTagPrefixesE(location string, subscriptionID ...string) (ServiceTagPrefixes, error) {
+	return GetServiceTagPrefixesWithContextE(context.Background(), location, subscriptionID...)
+}
+
+// GetServiceTagPrefixesWithContextE returns the address prefixes of every service tag as published for a location,
+// to be passed to the offline evaluators
+func GetServiceTagPrefixesWithContextE(ctx context.Context, location string, subscriptionID ...string) (ServiceTagPrefixes, error) {
+	client, err := GetServiceTagsClientE(getTargetSubscription(subscriptionID...))
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.List(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	prefixes := make(ServiceTagPrefixes)
+	if result.Values == nil {
+		return prefixes, nil
+	}
+	for _, tag := range *result.Values {
+		if tag.Name == nil || tag.Properties == nil || tag.Properties.AddressPrefixes == nil {
+			continue
+		}
+		prefixes[*tag.Name] = *tag.Properties.AddressPrefixes
+	}
+	return prefixes, nil
+}
+
+// GetServiceTagsClientE creates a ServiceTagsClient
+func GetServiceTagsClientE(subscriptionID string) (*network.ServiceTagsClient, error) {
+	client, err := defaultClientFactory.ResourceManagerClientE(subscriptionID, func(subscriptionID string) interface{} {
+		client := network.NewServiceTagsClient(subscriptionID)
+		return &client
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(*network.ServiceTagsClient), nil
+}
+
+// lookup returns the prefixes of a tag, or false if the tag is unknown
+func (p ServiceTagPrefixes) lookup(tag string) ([]string, bool) {
+	for _, tags := range []ServiceTagPrefixes{p, defaultServiceTagPrefixes} {
+		for name, prefixes := range tags {
+			if strings.EqualFold(name, tag) {
+				return prefixes, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// addressMatches tells whether address, an IP address or a service tag, is in prefix, a CIDR, an IP address,
+// a service tag or * (any)
+func (p ServiceTagPrefixes) addressMatches(prefix string, address string) (bool, error) {
+	prefix = strings.TrimSpace(prefix)
+	switch strings.ToLower(prefix) {
+	case "*", "any", "0.0.0.0/0", "::/0":
+		return true, nil
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		// the flow uses a service tag
+		return strings.EqualFold(prefix, address), nil
+	}
+	if matched, isAddress := ipInPrefix(ip, prefix); isAddress {
+		return matched, nil
+	}
+	if strings.EqualFold(prefix, "Internet") {
+		// anything outside the virtual network and the Azure infrastructure addresses
+		for _, tag := range []string{"VirtualNetwork", "AzureLoadBalancer"} {
+			if matched, err := p.addressMatches(tag, address); err != nil || matched {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	prefixes, ok := p.lookup(prefix)
+	if !ok {
+		return false, fmt.Errorf("the prefixes of service tag %s are unknown, see GetServiceTagPrefixesE", prefix)
+	}
+	for _, tagPrefix := range prefixes {
+		if matched, _ := ipInPrefix(ip, tagPrefix); matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ipInPrefix tells whether ip is in prefix, and whether prefix is an IP address or CIDR at all
+func ipInPrefix(ip net.IP, prefix string) (bool, bool) {
+	if _, network, err := net.ParseCIDR(prefix); err == nil {
+		return network.Contains(ip), true
+	}
+	if prefixIP := net.ParseIP(prefix); prefixIP != nil {
+		return prefixIP.Equal(ip), true
+	}
+	return false, false
+}
+
+// portMatches tells whether port is in portRange, a port, a range such as 1000-2000 or * (any)
+func portMatches(portRange string, port int) (bool, error) {
+	portRange = strings.TrimSpace(portRange)
+	if portRange == "*" {
+		return true, nil
+	}
+	bounds := strings.SplitN(portRange, "-", 2)
+	low, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return false, fmt.Errorf("invalid port range %s", portRange)
+	}
+	high := low
+	if len(bounds) == 2 {
+		if high, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+			return false, fmt.Errorf("invalid port range %s", portRange)
+		}
+	}
+	return port >= low && port <= high, nil
+}
+
+/********************************
+		Network security groups
+*********************************/
+
+// SecurityFlowDecision is the outcome of evaluating a flow against a network security group
+type SecurityFlowDecision struct {
+	Allowed bool
+	// RuleName is the name of the deciding rule, e.g. DenyAllInBound
+	RuleName string
+	Priority int32
+	// DefaultRule tells whether the deciding rule is one of the default rules
+	DefaultRule bool
+}
+
+func (d SecurityFlowDecision) String() string {
+	access := network.SecurityRuleAccessDeny
+	if d.Allowed {
+		access = network.SecurityRuleAccessAllow
+	}
+	return fmt.Sprintf("%s by rule %s (priority %d)", access, d.RuleName, d.Priority)
+}
+
+// securityRule is a rule of a network security group with every list filled
+type securityRule struct {
+	name                      string
+	priority                  int32
+	access                    network.SecurityRuleAccess
+	protocol                  string
+	sourcePrefixes            []string
+	sourcePorts               []string
+	destinationPrefixes       []string
+	destinationPorts          []string
+	sourceSecurityGroups      []string
+	destinationSecurityGroups []string
+	defaultRule               bool
+}
+
+// defaultSecurityRules are the rules Azure adds to every network security group, used when the
+// DefaultSecurityRules of the group are not filled
+var defaultSecurityRules = map[network.SecurityRuleDirection][]securityRule{
+	network.SecurityRuleDirectionInbound: {
+		{name: "AllowVnetInBound", priority: 65000, access: network.SecurityRuleAccessAllow, protocol: "*",
+			sourcePrefixes: []string{"VirtualNetwork"}, sourcePorts: []string{"*"}, destinationPrefixes: []string{"VirtualNetwork"}, destinationPorts: []string{"*"}, defaultRule: true},
+		{name: "AllowAzureLoadBalancerInBound", priority: 65001, access: network.SecurityRuleAccessAllow, protocol: "*",
+			sourcePrefixes: []string{"AzureLoadBalancer"}, sourcePorts: []string{"*"}, destinationPrefixes: []string{"*"}, destinationPorts: []string{"*"}, defaultRule: true},
+		{name: "DenyAllInBound", priority: 65500, access: network.SecurityRuleAccessDeny, protocol: "*",
+			sourcePrefixes: []string{"*"}, sourcePorts: []string{"*"}, destinationPrefixes: []string{"*"}, destinationPorts: []string{"*"}, defaultRule: true},
+	},
+	network.SecurityRuleDirectionOutbound: {
+		{name: "AllowVnetOutBound", priority: 65000, access: network.SecurityRuleAccessAllow, protocol: "*",
+			sourcePrefixes: []string{"VirtualNetwork"}, sourcePorts: []string{"*"}, destinationPrefixes: []string{"VirtualNetwork"}, destinationPorts: []string{"*"}, defaultRule: true},
+		{name: "AllowInternetOutBound", priority: 65001, access: network.SecurityRuleAccessAllow, protocol: "*",
+			sourcePrefixes: []string{"*"}, sourcePorts: []string{"*"}, destinationPrefixes: []string{"Internet"}, destinationPorts: []string{"*"}, defaultRule: true},
+		{name: "DenyAllOutBound", priority: 65500, access: network.SecurityRuleAccessDeny, protocol: "*",
+			sourcePrefixes: []string{"*"}, sourcePorts: []string{"*"}, destinationPrefixes: []string{"*"}, destinationPorts: []string{"*"}, defaultRule: true},
+	},
+}
+
+// EvaluateSecurityGroupFlowE evaluates offline whether a network security group, as returned by GetSecurityGroupE, allows
+// a flow in a direction, like Azure does: the custom rules and the default rules of that direction are tried by priority
+// and the first one matching the protocol, addresses, ports and application security groups decides. Service tags are
+// resolved with serviceTags, which can be nil (see defaultServiceTagPrefixes and GetServiceTagPrefixesE). A SourcePort
+// of 0 matches any source port range.
+func EvaluateSecurityGroupFlowE(securityGroup *network.SecurityGroup, direction network.SecurityRuleDirection, flow NetworkFlow, serviceTags ServiceTagPrefixes) (*SecurityFlowDecision, error) {
+	if securityGroup == nil {
+		return nil, fmt.Errorf("Network security group is nil")
+	}
+	if direction != network.SecurityRuleDirectionInbound && direction != network.SecurityRuleDirectionOutbound {
+		return nil, fmt.Errorf("Invalid direction %s: expected Inbound or Outbound", direction)
+	}
+
+	rules := make([]securityRule, 0)
+	var defaultRules []securityRule
+	if securityGroup.SecurityGroupPropertiesFormat != nil {
+		properties := securityGroup.SecurityGroupPropertiesFormat
+		if properties.SecurityRules != nil {
+			rules = append(rules, toSecurityRules(*properties.SecurityRules, direction, false)...)
+		}
+		if properties.DefaultSecurityRules != nil {
+			defaultRules = toSecurityRules(*properties.DefaultSecurityRules, direction, true)
+		}
+	}
+	if len(defaultRules) == 0 {
+		defaultRules = defaultSecurityRules[direction]
+	}
+	rules = append(rules, defaultRules...)
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].priority < rules[j].priority })
+
+	for _, rule := range rules {
+		matched, err := rule.matches(flow, serviceTags)
+		if err != nil {
+			return nil, fmt.Errorf("Can not evaluate rule %s: %s", rule.name, err)
+		}
+		if matched {
+			return &SecurityFlowDecision{
+				Allowed:     strings.EqualFold(string(rule.access), string(network.SecurityRuleAccessAllow)),
+				RuleName:    rule.name,
+				Priority:    rule.priority,
+				DefaultRule: rule.defaultRule,
+			}, nil
+		}
+	}
+	// the default rules end with a deny all, this is only reached when the group lists partial default rules
+	return &SecurityFlowDecision{Allowed: false, DefaultRule: true}, nil
+}
+
+// AssertSecurityGroupAllowsFlow fails the test unless the network security group allows the flow, written as for ParseNetworkFlowE,
+// e.g. AssertSecurityGroupAllowsFlow(t, nsg, network.SecurityRuleDirectionInbound, "TCP 10.1.0.4 -> 10.2.0.5:1433", nil)
+func AssertSecurityGroupAllowsFlow(t *testing.T, securityGroup *network.SecurityGroup, direction network.SecurityRuleDirection, flow string, serviceTags ServiceTagPrefixes) {
+	t.Helper()
+	assertSecurityGroupFlow(t, securityGroup, direction, flow, serviceTags, true)
+}
+
+// AssertSecurityGroupDeniesFlow fails the test unless the network security group denies the flow, written as for ParseNetworkFlowE
+func AssertSecurityGroupDeniesFlow(t *testing.T, securityGroup *network.SecurityGroup, direction network.SecurityRuleDirection, flow string, serviceTags ServiceTagPrefixes) {
+	t.Helper()
+	assertSecurityGroupFlow(t, securityGroup, direction, flow, serviceTags, false)
+}
+
+func assertSecurityGroupFlow(t *testing.T, securityGroup *network.SecurityGroup, direction network.SecurityRuleDirection, flow string, serviceTags ServiceTagPrefixes, allowed bool) {
+	t.Helper()
+	parsed, err := ParseNetworkFlowE(flow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decision, err := EvaluateSecurityGroupFlowE(securityGroup, direction, parsed, serviceTags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.Allowed != allowed {
+		name := ""
+		if securityGroup.Name != nil {
+			name = *securityGroup.Name
+		}
+		t.Errorf("%s flow %s on network security group %s is %s", direction, parsed, name, decision)
+	}
+}
+
+// toSecurityRules keeps the rules of a direction
+func toSecurityRules(rules []network.SecurityRule, direction network.SecurityRuleDirection, defaultRule bool) []securityRule {
+	converted := make([]securityRule, 0, len(rules))
+	for _, rule := range rules {
+		properties := rule.SecurityRulePropertiesFormat
+		if properties == nil || !strings.EqualFold(string(properties.Direction), string(direction)) {
+			continue
+		}
+		converted = append(converted, securityRule{
+			name:                      stringValue(rule.Name),
+			priority:                  int32Value(properties.Priority),
+			access:                    properties.Access,
+			protocol:                  string(properties.Protocol),
+			sourcePrefixes:            stringList(properties.SourceAddressPrefix, properties.SourceAddressPrefixes),
+			sourcePorts:               stringList(properties.SourcePortRange, properties.SourcePortRanges),
+			destinationPrefixes:       stringList(properties.DestinationAddressPrefix, properties.DestinationAddressPrefixes),
+			destinationPorts:          stringList(properties.DestinationPortRange, properties.DestinationPortRanges),
+			sourceSecurityGroups:      applicationSecurityGroupIDs(properties.SourceApplicationSecurityGroups),
+			destinationSecurityGroups: applicationSecurityGroupIDs(properties.DestinationApplicationSecurityGroups),
+			defaultRule:               defaultRule,
+		})
+	}
+	return converted
+}
+
+func (r securityRule) matches(flow NetworkFlow, serviceTags ServiceTagPrefixes) (bool, error) {
+	if r.protocol != "" && r.protocol != "*" && !strings.EqualFold(r.protocol, flow.Protocol) {
+		return false, nil
+	}
+	if matched, err := endpointMatches(r.sourcePrefixes, r.sourceSecurityGroups, flow.SourceAddress, flow.SourceApplicationSecurityGroups, serviceTags); err != nil || !matched {
+		return false, err
+	}
+	if matched, err := endpointMatches(r.destinationPrefixes, r.destinationSecurityGroups, flow.DestinationAddress, flow.DestinationApplicationSecurityGroups, serviceTags); err != nil || !matched {
+		return false, err
+	}
+	if flow.SourcePort != 0 {
+		if matched, err := anyPortMatches(r.sourcePorts, flow.SourcePort); err != nil || !matched {
+			return false, err
+		}
+	}
+	return anyPortMatches(r.destinationPorts, flow.DestinationPort)
+}
+
+// endpointMatches matches the address prefixes of a rule, or its application security groups when it has some
+func endpointMatches(prefixes []string, securityGroups []string, address string, flowSecurityGroups []string, serviceTags ServiceTagPrefixes) (bool, error) {
+	if len(securityGroups) > 0 {
+		for _, id := range securityGroups {
+			for _, flowID := range flowSecurityGroups {
+				if strings.EqualFold(id, flowID) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+	for _, prefix := range prefixes {
+		matched, err := serviceTags.addressMatches(prefix, address)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+func anyPortMatches(portRanges []string, port int) (bool, error) {
+	if len(portRanges) == 0 {
+		return true, nil
+	}
+	for _, portRange := range portRanges {
+		matched, err := portMatches(portRange, port)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+func applicationSecurityGroupIDs(groups *[]network.ApplicationSecurityGroup) []string {
+	ids := make([]string, 0)
+	if groups == nil {
+		return ids
+	}
+	for _, group := range *groups {
+		if group.ID != nil {
+			ids = append(ids, *group.ID)
+		}
+	}
+	return ids
+}
+
+// stringList merges the single value and list forms of a property, such as sourceAddressPrefix and sourceAddressPrefixes
+func stringList(value *string, values *[]string) []string {
+	list := make([]string, 0)
+	if value != nil && *value != "" {
+		list = append(list, *value)
+	}
+	if values != nil {
+		list = append(list, *values...)
+	}
+	return list
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func int32Value(value *int32) int32 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
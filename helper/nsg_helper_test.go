@@ -0,0 +1,156 @@
+package helper
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testApplicationSecurityGroupsID = testResourceGroupID + "/providers/Microsoft.Network/applicationSecurityGroups"
+
+func loadTestSecurityGroup(t *testing.T, file string) *network.SecurityGroup {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "nsg", file))
+	require.NoError(t, err)
+	var securityGroup network.SecurityGroup
+	require.NoError(t, json.Unmarshal(content, &securityGroup))
+	return &securityGroup
+}
+
+func TestParseNetworkFlowE(t *testing.T) {
+	cases := []struct {
+		flow     string
+		expected NetworkFlow
+	}{
+		{"TCP 10.1.0.4 -> 10.2.0.5:1433", NetworkFlow{Protocol: "TCP", SourceAddress: "10.1.0.4", DestinationAddress: "10.2.0.5", DestinationPort: 1433}},
+		{"Udp Internet:5353 -> 10.2.0.5:53", NetworkFlow{Protocol: "Udp", SourceAddress: "Internet", SourcePort: 5353, DestinationAddress: "10.2.0.5", DestinationPort: 53}},
+		{"Tcp [fd00::4]:5000 -> [fd00::5]:443", NetworkFlow{Protocol: "Tcp", SourceAddress: "fd00::4", SourcePort: 5000, DestinationAddress: "fd00::5", DestinationPort: 443}},
+		{"Icmp fd00::4 -> fd00::5", NetworkFlow{Protocol: "Icmp", SourceAddress: "fd00::4", DestinationAddress: "fd00::5"}},
+		{"Tcp 10.1.0.4 -> Storage.WestEurope:443", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "Storage.WestEurope", DestinationPort: 443}},
+		// regional service tags can be in lower case and host names in upper case, only the fqdn: prefix makes a host name
+		{"Tcp 10.1.0.4 -> AzureCloud.westeurope:443", NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "AzureCloud.westeurope", DestinationPort: 443}},
+		{"Https 10.1.0.4 -> fqdn:www.microsoft.com:443", NetworkFlow{Protocol: "Https", SourceAddress: "10.1.0.4", DestinationFQDN: "www.microsoft.com", DestinationPort: 443}},
+		{"Https 10.1.0.4 -> FQDN:WWW.MICROSOFT.COM", NetworkFlow{Protocol: "Https", SourceAddress: "10.1.0.4", DestinationFQDN: "WWW.MICROSOFT.COM"}},
+	}
+	for _, c := range cases {
+		t.Run(c.flow, func(t *testing.T) {
+			flow, err := ParseNetworkFlowE(c.flow)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, flow)
+		})
+	}
+	assert.Equal(t, "Https 10.1.0.4 -> fqdn:www.microsoft.com:443",
+		NetworkFlow{Protocol: "Https", SourceAddress: "10.1.0.4", DestinationFQDN: "www.microsoft.com", DestinationPort: 443}.String())
+
+	for _, invalid := range []string{"", "Tcp 10.1.0.4 10.2.0.5:1433", "Tcp 10.1.0.4 => 10.2.0.5", "Tcp 10.1.0.4 -> 10.2.0.5:0", "Tcp 10.1.0.4 -> 10.2.0.5:http", "Https 10.1.0.4 -> fqdn:www.microsoft.com:https"} {
+		_, err := ParseNetworkFlowE(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestEvaluateSecurityGroupFlowE(t *testing.T) {
+	securityGroup := loadTestSecurityGroup(t, "nsg-app.json")
+	serviceTags := ServiceTagPrefixes{"Storage.WestEurope": {"20.38.96.0/19"}}
+	appAndDB := NetworkFlow{
+		Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "10.0.2.4", DestinationPort: 1433,
+		SourceApplicationSecurityGroups:      []string{testApplicationSecurityGroupsID + "/asg-app"},
+		DestinationApplicationSecurityGroups: []string{testApplicationSecurityGroupsID + "/ASG-DB"},
+	}
+	appOnly := appAndDB
+	appOnly.DestinationApplicationSecurityGroups = nil
+
+	cases := []struct {
+		name      string
+		direction network.SecurityRuleDirection
+		flow      interface{}
+		allowed   bool
+		rule      string
+	}{
+		{"custom rule", network.SecurityRuleDirectionInbound, "Tcp 203.0.113.10 -> 10.0.1.4:443", true, "AllowHttpsFromInternet"},
+		{"protocol mismatch", network.SecurityRuleDirectionInbound, "Udp 203.0.113.10 -> 10.0.1.4:443", false, "DenyAllInBound"},
+		{"Internet excludes the virtual network", network.SecurityRuleDirectionInbound, "Tcp 10.1.0.4 -> 10.0.1.4:443", true, "AllowVnetInBound"},
+		{"default deny", network.SecurityRuleDirectionInbound, "Tcp 203.0.113.10 -> 10.0.1.4:22", false, "DenyAllInBound"},
+		{"default load balancer rule", network.SecurityRuleDirectionInbound, "Tcp 168.63.129.16 -> 10.0.1.4:22", true, "AllowAzureLoadBalancerInBound"},
+		{"priority before default rule", network.SecurityRuleDirectionInbound, "Tcp 10.1.0.4 -> 10.0.2.4:1433", false, "DenySqlFromVnet"},
+		// AllowSqlFromApp is listed after DenySqlFromVnet but has a lower priority
+		{"application security groups", network.SecurityRuleDirectionInbound, appAndDB, true, "AllowSqlFromApp"},
+		{"missing application security group", network.SecurityRuleDirectionInbound, appOnly, false, "DenySqlFromVnet"},
+		{"service tag", network.SecurityRuleDirectionInbound, "Tcp 20.38.100.1 -> 10.0.1.4:8085", true, "AllowStorageMonitoring"},
+		{"service tag in the flow", network.SecurityRuleDirectionInbound, "Tcp Storage.WestEurope -> 10.0.1.4:9000", true, "AllowStorageMonitoring"},
+		{"port range upper bound", network.SecurityRuleDirectionInbound, "Tcp 20.38.100.1 -> 10.0.1.4:8090", true, "AllowStorageMonitoring"},
+		{"outside port ranges", network.SecurityRuleDirectionInbound, "Tcp 20.38.100.1 -> 10.0.1.4:8091", false, "DenyAllInBound"},
+		{"IPv6 prefix", network.SecurityRuleDirectionInbound, "Tcp [fd00:1::10]:50000 -> [fd00:2::5]:80", true, "AllowIpv6Web"},
+		{"IPv6 virtual network", network.SecurityRuleDirectionInbound, "Tcp [fd00:3::10]:50000 -> [fd00:2::5]:80", true, "AllowVnetInBound"},
+		{"IPv6 Internet", network.SecurityRuleDirectionInbound, "Tcp [2001:db8::1]:50000 -> [fd00:2::5]:80", false, "DenyAllInBound"},
+		{"source port range", network.SecurityRuleDirectionInbound, "Udp 203.0.113.10:53000 -> 10.0.1.4:53", true, "AllowDnsFromHighPorts"},
+		{"outside source port range", network.SecurityRuleDirectionInbound, "Udp 203.0.113.10:53 -> 10.0.1.4:53", false, "DenyAllInBound"},
+		{"any source port", network.SecurityRuleDirectionInbound, "Udp 203.0.113.10 -> 10.0.1.4:53", true, "AllowDnsFromHighPorts"},
+		{"outbound custom rule", network.SecurityRuleDirectionOutbound, "Tcp 10.0.1.4 -> 203.0.113.10:80", false, "DenyInternetWeb"},
+		{"outbound Internet", network.SecurityRuleDirectionOutbound, "Tcp 10.0.1.4 -> 203.0.113.10:443", true, "AllowInternetOutBound"},
+		{"outbound virtual network", network.SecurityRuleDirectionOutbound, "Tcp 10.0.1.4 -> 10.1.0.4:80", true, "AllowVnetOutBound"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			flow, ok := c.flow.(NetworkFlow)
+			if !ok {
+				var err error
+				flow, err = ParseNetworkFlowE(c.flow.(string))
+				require.NoError(t, err)
+			}
+			decision, err := EvaluateSecurityGroupFlowE(securityGroup, c.direction, flow, serviceTags)
+			require.NoError(t, err)
+			assert.Equal(t, c.allowed, decision.Allowed, decision.String())
+			assert.Equal(t, c.rule, decision.RuleName)
+		})
+	}
+}
+
+func TestEvaluateSecurityGroupFlowEDefaultRules(t *testing.T) {
+	decision, err := EvaluateSecurityGroupFlowE(loadTestSecurityGroup(t, "nsg-app.json"), network.SecurityRuleDirectionInbound,
+		NetworkFlow{Protocol: "Tcp", SourceAddress: "203.0.113.10", DestinationAddress: "10.0.1.4", DestinationPort: 22},
+		ServiceTagPrefixes{"Storage.WestEurope": {"20.38.96.0/19"}})
+	require.NoError(t, err)
+	assert.Equal(t, SecurityFlowDecision{Allowed: false, RuleName: "DenyAllInBound", Priority: 65500, DefaultRule: true}, *decision)
+
+	// the default rules listed by the group are used instead of the built-in ones, here without AllowVnetInBound
+	securityGroup := loadTestSecurityGroup(t, "nsg-defaults.json")
+	decision, err = EvaluateSecurityGroupFlowE(securityGroup, network.SecurityRuleDirectionInbound,
+		NetworkFlow{Protocol: "Tcp", SourceAddress: "10.1.0.4", DestinationAddress: "10.0.1.4", DestinationPort: 22}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "DenyAllInBound", decision.RuleName)
+	assert.True(t, decision.DefaultRule)
+
+	// the group lists no outbound default rule
+	decision, err = EvaluateSecurityGroupFlowE(securityGroup, network.SecurityRuleDirectionOutbound,
+		NetworkFlow{Protocol: "Tcp", SourceAddress: "10.0.1.4", DestinationAddress: "203.0.113.10", DestinationPort: 443}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "AllowInternetOutBound", decision.RuleName)
+}
+
+func TestEvaluateSecurityGroupFlowEErrors(t *testing.T) {
+	securityGroup := loadTestSecurityGroup(t, "nsg-app.json")
+	flow := NetworkFlow{Protocol: "Tcp", SourceAddress: "20.38.100.1", DestinationAddress: "10.0.1.4", DestinationPort: 8080}
+
+	// Storage.WestEurope is not in the default service tags
+	_, err := EvaluateSecurityGroupFlowE(securityGroup, network.SecurityRuleDirectionInbound, flow, nil)
+	assert.EqualError(t, err, "Can not evaluate rule AllowStorageMonitoring: the prefixes of service tag Storage.WestEurope are unknown, see GetServiceTagPrefixesE")
+
+	_, err = EvaluateSecurityGroupFlowE(securityGroup, "Both", flow, nil)
+	assert.EqualError(t, err, "Invalid direction Both: expected Inbound or Outbound")
+
+	_, err = EvaluateSecurityGroupFlowE(nil, network.SecurityRuleDirectionInbound, flow, nil)
+	assert.Error(t, err)
+
+	_, err = portMatches("80-http", 80)
+	assert.EqualError(t, err, "invalid port range 80-http")
+}
+
+func TestAssertSecurityGroupFlow(t *testing.T) {
+	securityGroup := loadTestSecurityGroup(t, "nsg-app.json")
+	AssertSecurityGroupAllowsFlow(t, securityGroup, network.SecurityRuleDirectionInbound, "Tcp 203.0.113.10 -> 10.0.1.4:443", nil)
+	AssertSecurityGroupDeniesFlow(t, securityGroup, network.SecurityRuleDirectionOutbound, "Tcp 10.0.1.4 -> 203.0.113.10:80", nil)
+}
@@ -0,0 +1,178 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+)
+
+/********************************
+	Virtual network peerings
+*********************************/
+
+// PeeringRole is the role of a virtual network in a peering
+type PeeringRole string
+
+const (
+	// PeeringRoleHub is the hub of a hub-and-spoke topology, it may share its gateway with the spokes
+	PeeringRoleHub PeeringRole = "hub"
+	// PeeringRoleSpoke is a spoke of a hub-and-spoke topology, its traffic may be forwarded by the hub and use the hub gateway
+	PeeringRoleSpoke PeeringRole = "spoke"
+	// PeeringRolePeer is a virtual network peered with another one of the same level, e.g. in a mesh
+	PeeringRolePeer PeeringRole = "peer"
+)
+
+// CheckVirtualNetworkPeeringE checks both sides of a peering of a virtual network whose role is role and returns every issue found.
+// The remote virtual network is resolved from the peering, even in another resource group or subscription, and its peering back
+// must exist. Both sides must be Connected and allow virtual network access, and the flags must match the roles: a hub doesn't use
+// remote gateways, a spoke allows forwarded traffic and doesn't offer gateway transit, and a side uses remote gateways only when
+// the other side allows gateway transit.
+func CheckVirtualNetworkPeeringE(resourceGroupName, virtualNetworkName string, peeringName string, role PeeringRole, subscriptionID ...string) ([]string, error) {
+	return CheckVirtualNetworkPeeringWithContextE(context.Background(), resourceGroupName, virtualNetworkName, peeringName, role, subscriptionID...)
+}
+
+// CheckVirtualNetworkPeeringWithContextE checks both sides of a peering of a virtual network whose role is role and returns every issue found,
+// see CheckVirtualNetworkPeeringE
+func CheckVirtualNetworkPeeringWithContextE(ctx context.Context, resourceGroupName, virtualNetworkName string, peeringName string, role PeeringRole, subscriptionID ...string) ([]string, error) {
+	peering, err := GetVirtualNetworkPeeringWithContextE(ctx, resourceGroupName, virtualNetworkName, peeringName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+	localID := virtualNetworkID(getTargetSubscription(subscriptionID...), resourceGroupName, virtualNetworkName)
+	return checkVirtualNetworkPeering(ctx, localID, *peering, role)
+}
+
+// CheckVirtualNetworkPeeringsE checks every peering of a virtual network whose role is role, see CheckVirtualNetworkPeeringE
+func CheckVirtualNetworkPeeringsE(resourceGroupName, virtualNetworkName string, role PeeringRole, subscriptionID ...string) ([]string, error) {
+	return CheckVirtualNetworkPeeringsWithContextE(context.Background(), resourceGroupName, virtualNetworkName, role, subscriptionID...)
+}
+
+// CheckVirtualNetworkPeeringsWithContextE checks every peering of a virtual network whose role is role, see CheckVirtualNetworkPeeringE
+func CheckVirtualNetworkPeeringsWithContextE(ctx context.Context, resourceGroupName, virtualNetworkName string, role PeeringRole, subscriptionID ...string) ([]string, error) {
+	peerings, err := ListVirtualNetworkPeeringWithContextE(ctx, resourceGroupName, virtualNetworkName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+	localID := virtualNetworkID(getTargetSubscription(subscriptionID...), resourceGroupName, virtualNetworkName)
+	issues := make([]string, 0)
+	for _, peering := range peerings {
+		peeringIssues, err := checkVirtualNetworkPeering(ctx, localID, peering, role)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, peeringIssues...)
+	}
+	return issues, nil
+}
+
+// AssertVirtualNetworkPeering fails the test with every issue found on both sides of a peering, see CheckVirtualNetworkPeeringE
+func AssertVirtualNetworkPeering(t *testing.T, resourceGroupName, virtualNetworkName string, peeringName string, role PeeringRole, subscriptionID ...string) {
+	t.Helper()
+	issues, err := CheckVirtualNetworkPeeringWithContextE(NewTestContext(t), resourceGroupName, virtualNetworkName, peeringName, role, subscriptionID...)
+	failOnAzureError(t, err, "check", "Microsoft.Network/virtualNetworks/virtualNetworkPeerings", virtualNetworkName+"/"+peeringName, resourceGroupName)
+	if len(issues) > 0 {
+		t.Errorf("Peering %s of virtual network %s has %d issues:\n  %s", peeringName, virtualNetworkName, len(issues), strings.Join(issues, "\n  "))
+	}
+}
+
+// AssertVirtualNetworkPeerings fails the test with every issue found on both sides of every peering of a virtual network
+func AssertVirtualNetworkPeerings(t *testing.T, resourceGroupName, virtualNetworkName string, role PeeringRole, subscriptionID ...string) {
+	t.Helper()
+	issues, err := CheckVirtualNetworkPeeringsWithContextE(NewTestContext(t), resourceGroupName, virtualNetworkName, role, subscriptionID...)
+	failOnAzureError(t, err, "check the peerings of", "Microsoft.Network/virtualNetworks", virtualNetworkName, resourceGroupName)
+	if len(issues) > 0 {
+		t.Errorf("Peerings of virtual network %s have %d issues:\n  %s", virtualNetworkName, len(issues), strings.Join(issues, "\n  "))
+	}
+}
+
+// checkVirtualNetworkPeering finds the reverse of a peering of the virtual network localID and checks both sides
+func checkVirtualNetworkPeering(ctx context.Context, localID string, peering network.VirtualNetworkPeering, role PeeringRole) ([]string, error) {
+	name := stringValue(peering.Name)
+	properties := peering.VirtualNetworkPeeringPropertiesFormat
+	if properties == nil || properties.RemoteVirtualNetwork == nil || properties.RemoteVirtualNetwork.ID == nil {
+		return []string{fmt.Sprintf("peering %s has no remote virtual network", name)}, nil
+	}
+	remoteID, err := ParseResourceIDOfTypeE(*properties.RemoteVirtualNetwork.ID, "Microsoft.Network/virtualNetworks")
+	if err != nil {
+		return nil, err
+	}
+	remoteName := remoteID.Name()
+
+	remotePeerings, err := ListVirtualNetworkPeeringWithContextE(ctx, remoteID.ResourceGroup, remoteName, remoteID.SubscriptionID)
+	if err != nil {
+		if isNotFoundError(err) {
+			return []string{fmt.Sprintf("peering %s: remote virtual network %s doesn't exist", name, remoteID)}, nil
+		}
+		return nil, err
+	}
+	var reverse *network.VirtualNetworkPeering
+	for i := range remotePeerings {
+		remote := remotePeerings[i].VirtualNetworkPeeringPropertiesFormat
+		if remote != nil && remote.RemoteVirtualNetwork != nil && remote.RemoteVirtualNetwork.ID != nil &&
+			strings.EqualFold(strings.TrimRight(*remote.RemoteVirtualNetwork.ID, "/"), localID) {
+			reverse = &remotePeerings[i]
+			break
+		}
+	}
+	if reverse == nil {
+		return []string{fmt.Sprintf("peering %s: virtual network %s has no peering back", name, remoteName)}, nil
+	}
+	return checkPeeringPair(name, *properties, stringValue(reverse.Name), *reverse.VirtualNetworkPeeringPropertiesFormat, role), nil
+}
+
+// checkPeeringPair checks the flags of a peering and of its reverse, given the role of the local virtual network
+func checkPeeringPair(localName string, local network.VirtualNetworkPeeringPropertiesFormat, remoteName string, remote network.VirtualNetworkPeeringPropertiesFormat, role PeeringRole) []string {
+	remoteRole := role
+	switch role {
+	case PeeringRoleHub:
+		remoteRole = PeeringRoleSpoke
+	case PeeringRoleSpoke:
+		remoteRole = PeeringRoleHub
+	}
+	issues := make([]string, 0)
+	sides := []struct {
+		name       string
+		role       PeeringRole
+		properties network.VirtualNetworkPeeringPropertiesFormat
+		other      network.VirtualNetworkPeeringPropertiesFormat
+	}{
+		{localName, role, local, remote},
+		{remoteName, remoteRole, remote, local},
+	}
+	for _, side := range sides {
+		if side.properties.PeeringState != network.VirtualNetworkPeeringStateConnected {
+			issues = append(issues, fmt.Sprintf("peering %s is %q, not Connected", side.name, side.properties.PeeringState))
+		}
+		if !boolValue(side.properties.AllowVirtualNetworkAccess) {
+			issues = append(issues, fmt.Sprintf("peering %s doesn't allow virtual network access", side.name))
+		}
+		if boolValue(side.properties.UseRemoteGateways) && !boolValue(side.other.AllowGatewayTransit) {
+			issues = append(issues, fmt.Sprintf("peering %s uses remote gateways but the other side doesn't allow gateway transit", side.name))
+		}
+		switch side.role {
+		case PeeringRoleHub:
+			if boolValue(side.properties.UseRemoteGateways) {
+				issues = append(issues, fmt.Sprintf("peering %s of the hub uses remote gateways", side.name))
+			}
+		case PeeringRoleSpoke:
+			if boolValue(side.properties.AllowGatewayTransit) {
+				issues = append(issues, fmt.Sprintf("peering %s of the spoke allows gateway transit", side.name))
+			}
+			if !boolValue(side.properties.AllowForwardedTraffic) {
+				issues = append(issues, fmt.Sprintf("peering %s of the spoke doesn't allow forwarded traffic from the hub", side.name))
+			}
+		}
+	}
+	return issues
+}
+
+func virtualNetworkID(subscriptionID string, resourceGroupName string, virtualNetworkName string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks/%s", subscriptionID, resourceGroupName, virtualNetworkName)
+}
+
+func boolValue(value *bool) bool {
+	return value != nil && *value
+}
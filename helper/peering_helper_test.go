@@ -0,0 +1,140 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testHubVnetID   = testResourceGroupID + "/providers/Microsoft.Network/virtualNetworks/vnet-hub"
+	testSpokeVnetID = testOtherResourceGroupID + "/providers/Microsoft.Network/virtualNetworks/vnet-spoke"
+)
+
+// testPeering is the JSON of a connected peering to remoteID, with the flags of the properties overridden by flags
+func testPeering(remoteID string, flags map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{
+		"remoteVirtualNetwork":      map[string]interface{}{"id": remoteID},
+		"peeringState":              "Connected",
+		"allowVirtualNetworkAccess": true,
+		"allowForwardedTraffic":     true,
+		"allowGatewayTransit":       false,
+		"useRemoteGateways":         false,
+	}
+	for name, value := range flags {
+		properties[name] = value
+	}
+	return map[string]interface{}{"properties": properties}
+}
+
+// setupPeerings seeds a hub in rg-test and a spoke in rg-other peered both ways, the spoke using the gateway of the hub
+func setupPeerings(t *testing.T, hubFlags map[string]interface{}, spokeFlags map[string]interface{}) *FakeARMServer {
+	s := setupTestARMServer(t)
+	s.AddResource(t, testHubVnetID, `{"properties": {"addressSpace": {"addressPrefixes": ["10.0.0.0/16"]}}}`)
+	s.AddResource(t, testSpokeVnetID, `{"properties": {"addressSpace": {"addressPrefixes": ["10.1.0.0/16"]}}}`)
+	hub := map[string]interface{}{"allowGatewayTransit": true}
+	for name, value := range hubFlags {
+		hub[name] = value
+	}
+	spoke := map[string]interface{}{"useRemoteGateways": true}
+	for name, value := range spokeFlags {
+		spoke[name] = value
+	}
+	s.AddResource(t, testHubVnetID+"/virtualNetworkPeerings/hub-to-spoke", testPeering(testSpokeVnetID, hub))
+	s.AddResource(t, testSpokeVnetID+"/virtualNetworkPeerings/spoke-to-hub", testPeering(testHubVnetID, spoke))
+	return s
+}
+
+func TestCheckVirtualNetworkPeeringE(t *testing.T) {
+	setupPeerings(t, nil, nil)
+
+	issues, err := CheckVirtualNetworkPeeringE("rg-test", "vnet-hub", "hub-to-spoke", PeeringRoleHub)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	// from the spoke, in another resource group
+	issues, err = CheckVirtualNetworkPeeringE("rg-other", "vnet-spoke", "spoke-to-hub", PeeringRoleSpoke)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	AssertVirtualNetworkPeering(t, "rg-test", "vnet-hub", "hub-to-spoke", PeeringRoleHub)
+	AssertVirtualNetworkPeerings(t, "rg-other", "vnet-spoke", PeeringRoleSpoke)
+
+	_, err = CheckVirtualNetworkPeeringE("rg-test", "vnet-hub", "hub-to-nowhere", PeeringRoleHub)
+	assert.Error(t, err)
+}
+
+func TestCheckVirtualNetworkPeeringEIssues(t *testing.T) {
+	cases := []struct {
+		name       string
+		hubFlags   map[string]interface{}
+		spokeFlags map[string]interface{}
+		issues     []string
+	}{
+		{"missing reverse peering", nil, map[string]interface{}{"remoteVirtualNetwork": map[string]interface{}{"id": testVnetID}},
+			[]string{"peering hub-to-spoke: virtual network vnet-spoke has no peering back"}},
+		{"remote virtual network missing", map[string]interface{}{"remoteVirtualNetwork": map[string]interface{}{"id": testVnetID}}, nil,
+			[]string{"peering hub-to-spoke: remote virtual network " + testVnetID + " doesn't exist"}},
+		{"peering without remote virtual network", map[string]interface{}{"remoteVirtualNetwork": nil}, nil,
+			[]string{"peering hub-to-spoke has no remote virtual network"}},
+		{"spoke with gateway transit", nil, map[string]interface{}{"allowGatewayTransit": true},
+			[]string{"peering spoke-to-hub of the spoke allows gateway transit"}},
+		{"spoke without forwarded traffic", nil, map[string]interface{}{"allowForwardedTraffic": false},
+			[]string{"peering spoke-to-hub of the spoke doesn't allow forwarded traffic from the hub"}},
+		{"hub without gateway transit", map[string]interface{}{"allowGatewayTransit": false}, nil,
+			[]string{"peering spoke-to-hub uses remote gateways but the other side doesn't allow gateway transit"}},
+		{"hub using remote gateways", map[string]interface{}{"useRemoteGateways": true}, nil, []string{
+			"peering hub-to-spoke uses remote gateways but the other side doesn't allow gateway transit",
+			"peering hub-to-spoke of the hub uses remote gateways",
+		}},
+		{"not connected", map[string]interface{}{"peeringState": "Disconnected"}, map[string]interface{}{"peeringState": "Initiated", "allowVirtualNetworkAccess": false}, []string{
+			`peering hub-to-spoke is "Disconnected", not Connected`,
+			`peering spoke-to-hub is "Initiated", not Connected`,
+			"peering spoke-to-hub doesn't allow virtual network access",
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			setupPeerings(t, c.hubFlags, c.spokeFlags)
+			issues, err := CheckVirtualNetworkPeeringE("rg-test", "vnet-hub", "hub-to-spoke", PeeringRoleHub)
+			require.NoError(t, err)
+			assert.Equal(t, c.issues, issues)
+		})
+	}
+}
+
+func TestCheckVirtualNetworkPeeringsE(t *testing.T) {
+	s := setupPeerings(t, nil, map[string]interface{}{"allowGatewayTransit": true})
+	// a second peering of the hub, to a spoke with no peering back
+	s.AddResource(t, testVnetID, testVnet)
+	s.AddResource(t, testHubVnetID+"/virtualNetworkPeerings/hub-to-test", testPeering(testVnetID, nil))
+
+	issues, err := CheckVirtualNetworkPeeringsE("rg-test", "vnet-hub", PeeringRoleHub)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"peering spoke-to-hub of the spoke allows gateway transit",
+		"peering hub-to-test: virtual network vnet-test has no peering back",
+	}, issues)
+}
+
+func TestCheckPeeringPairPeers(t *testing.T) {
+	// peers of a mesh have no role specific flags
+	local := network.VirtualNetworkPeeringPropertiesFormat{
+		PeeringState:              network.VirtualNetworkPeeringStateConnected,
+		AllowVirtualNetworkAccess: to.BoolPtr(true),
+		AllowGatewayTransit:       to.BoolPtr(true),
+	}
+	remote := local
+	remote.AllowGatewayTransit = to.BoolPtr(false)
+	assert.Empty(t, checkPeeringPair("a-to-b", local, "b-to-a", remote, PeeringRolePeer))
+
+	remote.UseRemoteGateways = to.BoolPtr(true)
+	assert.Empty(t, checkPeeringPair("a-to-b", local, "b-to-a", remote, PeeringRolePeer))
+
+	local.AllowGatewayTransit = nil
+	assert.Equal(t, []string{"peering b-to-a uses remote gateways but the other side doesn't allow gateway transit"},
+		checkPeeringPair("a-to-b", local, "b-to-a", remote, PeeringRolePeer))
+}
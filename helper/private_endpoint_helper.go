@@ -0,0 +1,267 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/privatedns/mgmt/2018-09-01/privatedns"
+)
+
+/********************************
+	Private endpoint wiring
+*********************************/
+
+// privateDNSPageSize is the number of record sets and virtual network links of a private DNS zone asked per call
+const privateDNSPageSize = 1000
+
+// PrivateEndpointDNSRecord is an A record of a private DNS zone pointing at a private endpoint
+type PrivateEndpointDNSRecord struct {
+	ZoneID      string
+	Name        string
+	IPAddresses []string
+}
+
+// PrivateEndpointWiringReport is the outcome of following the DNS chain of a private endpoint
+type PrivateEndpointWiringReport struct {
+	// PrivateIPAddresses are the addresses of the network interfaces of the private endpoint
+	PrivateIPAddresses []string
+	Records            []PrivateEndpointDNSRecord
+	// Issues describes every broken link of the chain
+	Issues []string
+}
+
+// GetPrivateEndpointWiringReportE follows the whole chain of a private endpoint and reports every broken link: the connection
+// must be approved, the endpoint must have a private DNS zone group, each zone of the group must exist and hold A records
+// pointing at the private IP addresses of the endpoint network interface (and only at them), and each zone must be linked
+// to virtualNetworkID, the virtual network of the clients. An empty virtualNetworkID stands for the virtual network of the endpoint.
+func GetPrivateEndpointWiringReportE(resourceGroupName, endpointName string, virtualNetworkID string, subscriptionID ...string) (*PrivateEndpointWiringReport, error) {
+	return GetPrivateEndpointWiringReportWithContextE(context.Background(), resourceGroupName, endpointName, virtualNetworkID, subscriptionID...)
+}
+
+// GetPrivateEndpointWiringReportWithContextE follows the whole chain of a private endpoint and reports every broken link,
+// see GetPrivateEndpointWiringReportE
+func GetPrivateEndpointWiringReportWithContextE(ctx context.Context, resourceGroupName, endpointName string, virtualNetworkID string, subscriptionID ...string) (*PrivateEndpointWiringReport, error) {
+	endpoint, err := GetPrivateEndpointWithContextE(ctx, resourceGroupName, endpointName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.PrivateEndpointProperties == nil {
+		return nil, fmt.Errorf("Private endpoint %s has no properties", endpointName)
+	}
+	report := &PrivateEndpointWiringReport{
+		PrivateIPAddresses: make([]string, 0),
+		Records:            make([]PrivateEndpointDNSRecord, 0),
+		Issues:             make([]string, 0),
+	}
+
+	// connection to the target resource
+	connections := make([]network.PrivateLinkServiceConnection, 0)
+	for _, list := range []*[]network.PrivateLinkServiceConnection{endpoint.PrivateLinkServiceConnections, endpoint.ManualPrivateLinkServiceConnections} {
+		if list != nil {
+			connections = append(connections, *list...)
+		}
+	}
+	for _, connection := range connections {
+		status := ""
+		if connection.PrivateLinkServiceConnectionProperties != nil && connection.PrivateLinkServiceConnectionState != nil {
+			status = stringValue(connection.PrivateLinkServiceConnectionState.Status)
+		}
+		if !strings.EqualFold(status, "Approved") {
+			report.Issues = append(report.Issues, fmt.Sprintf("connection %s is %q, not Approved", stringValue(connection.Name), status))
+		}
+	}
+
+	// private IP addresses of the endpoint
+	if endpoint.NetworkInterfaces != nil {
+		for _, reference := range *endpoint.NetworkInterfaces {
+			if reference.ID == nil {
+				continue
+			}
+			nic, err := GetInterfaceByIDWithContextE(ctx, *reference.ID)
+			if err != nil {
+				return nil, err
+			}
+			if nic.InterfacePropertiesFormat == nil || nic.IPConfigurations == nil {
+				continue
+			}
+			for _, ipConfiguration := range *nic.IPConfigurations {
+				if ipConfiguration.InterfaceIPConfigurationPropertiesFormat != nil && ipConfiguration.PrivateIPAddress != nil {
+					report.PrivateIPAddresses = append(report.PrivateIPAddresses, *ipConfiguration.PrivateIPAddress)
+				}
+			}
+		}
+	}
+	if len(report.PrivateIPAddresses) == 0 {
+		report.Issues = append(report.Issues, "the network interface of the endpoint has no private IP address")
+	}
+
+	if virtualNetworkID == "" && endpoint.Subnet != nil && endpoint.Subnet.ID != nil {
+		if subnetID, err := ParseResourceIDOfTypeE(*endpoint.Subnet.ID, "Microsoft.Network/virtualNetworks/subnets"); err == nil {
+			subnetID.Types = subnetID.Types[:1]
+			subnetID.Names = subnetID.Names[:1]
+			virtualNetworkID = subnetID.String()
+		}
+	}
+
+	// DNS zone groups, zones, records and links
+	groups, err := ListPrivateDNSZoneGroupsWithContextE(ctx, resourceGroupName, endpointName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+	zoneConfigs := make([]network.PrivateDNSZoneConfig, 0)
+	for _, group := range *groups {
+		if group.PrivateDNSZoneGroupPropertiesFormat != nil && group.PrivateDNSZoneConfigs != nil {
+			zoneConfigs = append(zoneConfigs, *group.PrivateDNSZoneConfigs...)
+		}
+	}
+	if len(zoneConfigs) == 0 {
+		report.Issues = append(report.Issues, "the endpoint has no private DNS zone group")
+	}
+	for _, zoneConfig := range zoneConfigs {
+		if zoneConfig.PrivateDNSZonePropertiesFormat == nil || zoneConfig.PrivateDNSZoneID == nil {
+			continue
+		}
+		issues, records, err := checkPrivateEndpointZone(ctx, *zoneConfig.PrivateDNSZoneID, report.PrivateIPAddresses, virtualNetworkID)
+		if err != nil {
+			return nil, err
+		}
+		report.Issues = append(report.Issues, issues...)
+		report.Records = append(report.Records, records...)
+	}
+	return report, nil
+}
+
+// AssertPrivateEndpointWiring fails the test with every broken link of the chain of a private endpoint, see GetPrivateEndpointWiringReportE
+func AssertPrivateEndpointWiring(t *testing.T, resourceGroupName, endpointName string, virtualNetworkID string, subscriptionID ...string) {
+	t.Helper()
+	report, err := GetPrivateEndpointWiringReportWithContextE(NewTestContext(t), resourceGroupName, endpointName, virtualNetworkID, subscriptionID...)
+	failOnAzureError(t, err, "check the wiring of", "Microsoft.Network/privateEndpoints", endpointName, resourceGroupName)
+	if len(report.Issues) > 0 {
+		t.Errorf("Private endpoint %s in resource group %s has %d wiring issues:\n  %s", endpointName, resourceGroupName, len(report.Issues), strings.Join(report.Issues, "\n  "))
+	}
+}
+
+// checkPrivateEndpointZone checks that a private DNS zone has A records pointing at the endpoint IP addresses only,
+// and a link to the virtual network of the clients
+func checkPrivateEndpointZone(ctx context.Context, zoneID string, privateIPAddresses []string, virtualNetworkID string) ([]string, []PrivateEndpointDNSRecord, error) {
+	id, err := ParseResourceIDOfTypeE(zoneID, "Microsoft.Network/privateDnsZones")
+	if err != nil {
+		return nil, nil, err
+	}
+	zoneName := id.Name()
+	issues := make([]string, 0)
+	records := make([]PrivateEndpointDNSRecord, 0)
+
+	if _, err := GetPrivateDNSZoneWithContextE(ctx, id.ResourceGroup, zoneName, id.SubscriptionID); err != nil {
+		if isNotFoundError(err) {
+			return []string{fmt.Sprintf("private DNS zone %s doesn't exist", zoneID)}, records, nil
+		}
+		return nil, nil, err
+	}
+
+	recordSets, err := listPrivateDNSRecordSets(ctx, id.ResourceGroup, zoneName, id.SubscriptionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	endpointIPs := make(map[string]bool)
+	for _, ip := range privateIPAddresses {
+		endpointIPs[ip] = true
+	}
+	for _, recordSet := range recordSets {
+		if recordSet.RecordSetProperties == nil || recordSet.ARecords == nil {
+			continue
+		}
+		ips := make([]string, 0)
+		pointsAtEndpoint := false
+		for _, record := range *recordSet.ARecords {
+			if record.Ipv4Address == nil {
+				continue
+			}
+			ips = append(ips, *record.Ipv4Address)
+			pointsAtEndpoint = pointsAtEndpoint || endpointIPs[*record.Ipv4Address]
+		}
+		if !pointsAtEndpoint {
+			continue
+		}
+		sort.Strings(ips)
+		record := PrivateEndpointDNSRecord{ZoneID: zoneID, Name: stringValue(recordSet.Name), IPAddresses: ips}
+		records = append(records, record)
+		for _, ip := range ips {
+			if !endpointIPs[ip] {
+				issues = append(issues, fmt.Sprintf("A record %s.%s also points at %s, which isn't an address of the endpoint", record.Name, zoneName, ip))
+			}
+		}
+	}
+	if len(records) == 0 {
+		issues = append(issues, fmt.Sprintf("private DNS zone %s has no A record pointing at %s", zoneName, strings.Join(privateIPAddresses, ", ")))
+	}
+
+	if virtualNetworkID == "" {
+		return issues, records, nil
+	}
+	links, err := listPrivateDNSZoneLinks(ctx, id.ResourceGroup, zoneName, id.SubscriptionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	linked := false
+	for _, link := range links {
+		if link.VirtualNetworkLinkProperties == nil || link.VirtualNetwork == nil || link.VirtualNetwork.ID == nil ||
+			!strings.EqualFold(strings.TrimRight(*link.VirtualNetwork.ID, "/"), strings.TrimRight(virtualNetworkID, "/")) {
+			continue
+		}
+		linked = true
+		if link.VirtualNetworkLinkState != "" && link.VirtualNetworkLinkState != privatedns.Completed {
+			issues = append(issues, fmt.Sprintf("link %s of private DNS zone %s is %s", stringValue(link.Name), zoneName, link.VirtualNetworkLinkState))
+		}
+	}
+	if !linked {
+		issues = append(issues, fmt.Sprintf("private DNS zone %s is not linked to virtual network %s", zoneName, virtualNetworkID))
+	}
+	return issues, records, nil
+}
+
+// listPrivateDNSRecordSets returns every record set of a private DNS zone, following the next links
+func listPrivateDNSRecordSets(ctx context.Context, resourceGroupName string, zoneName string, subscriptionID string) ([]privatedns.RecordSet, error) {
+	client, err := GetRecordSetsClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	top := int32(privateDNSPageSize)
+	iterator, err := client.ListComplete(ctx, resourceGroupName, zoneName, &top, "")
+	if err != nil {
+		return nil, err
+	}
+	recordSets := make([]privatedns.RecordSet, 0)
+	for iterator.NotDone() {
+		recordSets = append(recordSets, iterator.Value())
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return recordSets, nil
+}
+
+// listPrivateDNSZoneLinks returns every virtual network link of a private DNS zone, following the next links
+func listPrivateDNSZoneLinks(ctx context.Context, resourceGroupName string, zoneName string, subscriptionID string) ([]privatedns.VirtualNetworkLink, error) {
+	client, err := GetVirtualNetworkLinksClient(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	top := int32(privateDNSPageSize)
+	iterator, err := client.ListComplete(ctx, resourceGroupName, zoneName, &top)
+	if err != nil {
+		return nil, err
+	}
+	links := make([]privatedns.VirtualNetworkLink, 0)
+	for iterator.NotDone() {
+		links = append(links, iterator.Value())
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return links, nil
+}
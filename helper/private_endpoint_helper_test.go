@@ -0,0 +1,173 @@
+package helper
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPrivateEndpointID = testResourceGroupID + "/providers/Microsoft.Network/privateEndpoints/pe-blob"
+	testPrivateDNSZoneID  = testResourceGroupID + "/providers/Microsoft.Network/privateDnsZones/privatelink.blob.core.windows.net"
+)
+
+// testPrivateDNSRecords is the list of the record sets of the zone, with an A record for the endpoint
+const testPrivateDNSRecords = `{"value": [
+	{"name": "@", "type": "Microsoft.Network/privateDnsZones/SOA", "properties": {"soaRecord": {"host": "azureprivatedns.net"}}},
+	{"name": "stblob", "type": "Microsoft.Network/privateDnsZones/A", "properties": {"aRecords": [{"ipv4Address": "10.0.1.4"}]}},
+	{"name": "stother", "type": "Microsoft.Network/privateDnsZones/A", "properties": {"aRecords": [{"ipv4Address": "10.0.1.9"}]}}
+]}`
+
+// setupPrivateEndpoint seeds a private endpoint of vnet-test wired correctly to its private DNS zone
+func setupPrivateEndpoint(t *testing.T) *FakeARMServer {
+	s := setupTestARMServer(t)
+	nicID := testResourceGroupID + "/providers/Microsoft.Network/networkInterfaces/pe-blob.nic"
+	s.AddResource(t, testVnetID, testVnet)
+	s.AddResource(t, testPrivateEndpointID, `{"properties": {
+		"subnet": {"id": "`+testVnetID+`/subnets/snet-a"},
+		"networkInterfaces": [{"id": "`+nicID+`"}],
+		"privateLinkServiceConnections": [{"name": "blob", "properties": {"privateLinkServiceConnectionState": {"status": "Approved"}}}]
+	}}`)
+	s.AddResource(t, nicID, `{"properties": {"ipConfigurations": [{"name": "ipconfig1", "properties": {"privateIPAddress": "10.0.1.4"}}]}}`)
+	s.AddResource(t, testPrivateEndpointID+"/privateDnsZoneGroups/default", `{"properties": {
+		"privateDnsZoneConfigs": [{"name": "blob", "properties": {"privateDnsZoneId": "`+testPrivateDNSZoneID+`"}}]
+	}}`)
+	s.AddResource(t, testPrivateDNSZoneID, `{"location": "global"}`)
+	require.NoError(t, s.AddResponseE(http.MethodGet, testPrivateDNSZoneID+"/ALL", http.StatusOK, testPrivateDNSRecords))
+	s.AddResource(t, testPrivateDNSZoneID+"/virtualNetworkLinks/link-vnet-test", `{"properties": {
+		"virtualNetwork": {"id": "`+testVnetID+`"},
+		"virtualNetworkLinkState": "Completed"
+	}}`)
+	return s
+}
+
+func TestGetPrivateEndpointWiringReportE(t *testing.T) {
+	setupPrivateEndpoint(t)
+
+	report, err := GetPrivateEndpointWiringReportE("rg-test", "pe-blob", "")
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, []string{"10.0.1.4"}, report.PrivateIPAddresses)
+	assert.Equal(t, []PrivateEndpointDNSRecord{
+		{ZoneID: testPrivateDNSZoneID, Name: "stblob", IPAddresses: []string{"10.0.1.4"}},
+	}, report.Records)
+
+	AssertPrivateEndpointWiring(t, "rg-test", "pe-blob", testVnetID)
+}
+
+func TestGetPrivateEndpointWiringReportEIssues(t *testing.T) {
+	otherVnetID := testResourceGroupID + "/providers/Microsoft.Network/virtualNetworks/vnet-other"
+	cases := []struct {
+		name             string
+		virtualNetworkID string
+		setup            func(t *testing.T, s *FakeARMServer)
+		issues           []string
+	}{
+		{"unapproved connection", "", func(t *testing.T, s *FakeARMServer) {
+			s.AddResource(t, testPrivateEndpointID, `{"properties": {
+				"subnet": {"id": "`+testVnetID+`/subnets/snet-a"},
+				"networkInterfaces": [{"id": "`+testResourceGroupID+`/providers/Microsoft.Network/networkInterfaces/pe-blob.nic"}],
+				"manualPrivateLinkServiceConnections": [{"name": "blob", "properties": {"privateLinkServiceConnectionState": {"status": "Pending"}}}]
+			}}`)
+		}, []string{`connection blob is "Pending", not Approved`}},
+		{"stray A record", "", func(t *testing.T, s *FakeARMServer) {
+			require.NoError(t, s.AddResponseE(http.MethodGet, testPrivateDNSZoneID+"/ALL", http.StatusOK, `{"value": [
+				{"name": "stblob", "properties": {"aRecords": [{"ipv4Address": "10.0.1.4"}, {"ipv4Address": "10.0.1.10"}]}}
+			]}`))
+		}, []string{"A record stblob.privatelink.blob.core.windows.net also points at 10.0.1.10, which isn't an address of the endpoint"}},
+		{"no A record", "", func(t *testing.T, s *FakeARMServer) {
+			require.NoError(t, s.AddResponseE(http.MethodGet, testPrivateDNSZoneID+"/ALL", http.StatusOK, `{"value": [
+				{"name": "stblob", "properties": {"aRecords": [{"ipv4Address": "10.0.2.4"}]}}
+			]}`))
+		}, []string{"private DNS zone privatelink.blob.core.windows.net has no A record pointing at 10.0.1.4"}},
+		{"missing virtual network link", otherVnetID, nil,
+			[]string{"private DNS zone privatelink.blob.core.windows.net is not linked to virtual network " + otherVnetID}},
+		{"link in progress", "", func(t *testing.T, s *FakeARMServer) {
+			s.AddResource(t, testPrivateDNSZoneID+"/virtualNetworkLinks/link-vnet-test", `{"properties": {
+				"virtualNetwork": {"id": "`+testVnetID+`"},
+				"virtualNetworkLinkState": "InProgress"
+			}}`)
+		}, []string{"link link-vnet-test of private DNS zone privatelink.blob.core.windows.net is InProgress"}},
+		{"missing zone", "", func(t *testing.T, s *FakeARMServer) {
+			s.AddResource(t, testPrivateEndpointID+"/privateDnsZoneGroups/default", `{"properties": {
+				"privateDnsZoneConfigs": [{"name": "file", "properties": {"privateDnsZoneId": "`+testResourceGroupID+`/providers/Microsoft.Network/privateDnsZones/privatelink.file.core.windows.net"}}]
+			}}`)
+		}, []string{"private DNS zone " + testResourceGroupID + "/providers/Microsoft.Network/privateDnsZones/privatelink.file.core.windows.net doesn't exist"}},
+		{"no zone group", "", func(t *testing.T, s *FakeARMServer) {
+			require.NoError(t, s.AddResponseE(http.MethodGet, testPrivateEndpointID+"/privateDnsZoneGroups", http.StatusOK, `{"value": []}`))
+		}, []string{"the endpoint has no private DNS zone group"}},
+		{"no private IP address", "", func(t *testing.T, s *FakeARMServer) {
+			s.AddResource(t, testResourceGroupID+"/providers/Microsoft.Network/networkInterfaces/pe-blob.nic", `{"properties": {"ipConfigurations": []}}`)
+		}, []string{
+			"the network interface of the endpoint has no private IP address",
+			"private DNS zone privatelink.blob.core.windows.net has no A record pointing at ",
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := setupPrivateEndpoint(t)
+			if c.setup != nil {
+				c.setup(t, s)
+			}
+			report, err := GetPrivateEndpointWiringReportE("rg-test", "pe-blob", c.virtualNetworkID)
+			require.NoError(t, err)
+			assert.Equal(t, c.issues, report.Issues)
+		})
+	}
+}
+
+func TestGetPrivateEndpointWiringReportEPages(t *testing.T) {
+	s := setupPrivateEndpoint(t)
+	recordsPath := testPrivateDNSZoneID + "/ALL"
+	linksPath := testPrivateDNSZoneID + "/virtualNetworkLinks"
+	nextLink := func(path string) string {
+		return "https://management.azure.com" + path + "?api-version=2018-09-01&$skipToken=page-2"
+	}
+	// the A record of the endpoint and the link of its virtual network are on the second pages
+	require.NoError(t, s.AddResponsesE(http.MethodGet, recordsPath, http.StatusOK, `{
+		"value": [{"name": "stother", "properties": {"aRecords": [{"ipv4Address": "10.0.1.9"}]}}],
+		"nextLink": "`+nextLink(recordsPath)+`"
+	}`, `{"value": [{"name": "stblob", "properties": {"aRecords": [{"ipv4Address": "10.0.1.4"}]}}]}`))
+	require.NoError(t, s.AddResponsesE(http.MethodGet, linksPath, http.StatusOK, `{
+		"value": [{"name": "link-vnet-other", "properties": {"virtualNetwork": {"id": "`+testResourceGroupID+`/providers/Microsoft.Network/virtualNetworks/vnet-other"}, "virtualNetworkLinkState": "Completed"}}],
+		"nextLink": "`+nextLink(linksPath)+`"
+	}`, `{"value": [{"name": "link-vnet-test", "properties": {"virtualNetwork": {"id": "`+testVnetID+`"}, "virtualNetworkLinkState": "Completed"}}]}`))
+
+	report, err := GetPrivateEndpointWiringReportE("rg-test", "pe-blob", testVnetID)
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, []PrivateEndpointDNSRecord{
+		{ZoneID: testPrivateDNSZoneID, Name: "stblob", IPAddresses: []string{"10.0.1.4"}},
+	}, report.Records)
+
+	requests := make(map[string]int)
+	for _, request := range s.Requests() {
+		requests[request]++
+	}
+	assert.Equal(t, 2, requests["GET "+recordsPath])
+	assert.Equal(t, 2, requests["GET "+linksPath])
+}
+
+func TestCheckPrivateEndpointZone(t *testing.T) {
+	setupPrivateEndpoint(t)
+
+	// without virtual network the links are not checked
+	issues, records, err := checkPrivateEndpointZone(context.Background(), testPrivateDNSZoneID, []string{"10.0.1.4", "10.0.1.9"}, "")
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+	assert.Equal(t, []PrivateEndpointDNSRecord{
+		{ZoneID: testPrivateDNSZoneID, Name: "stblob", IPAddresses: []string{"10.0.1.4"}},
+		{ZoneID: testPrivateDNSZoneID, Name: "stother", IPAddresses: []string{"10.0.1.9"}},
+	}, records)
+
+	// a trailing slash in the virtual network ID is ignored
+	issues, _, err = checkPrivateEndpointZone(context.Background(), testPrivateDNSZoneID, []string{"10.0.1.4"}, testVnetID+"/")
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	_, _, err = checkPrivateEndpointZone(context.Background(), testVnetID, []string{"10.0.1.4"}, "")
+	assert.Error(t, err)
+}
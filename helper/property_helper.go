@@ -0,0 +1,205 @@
+package helper
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// propertyPathSegment is either a property name or a list index
+type propertyPathSegment struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+func (s propertyPathSegment) String() string {
+	if s.isIndex {
+		return fmt.Sprintf("[%d]", s.index)
+	}
+	return s.name
+}
+
+// parsePropertyPath splits a path such as properties.subnets[0].name or tags["app.name"]
+func parsePropertyPath(path string) ([]propertyPathSegment, error) {
+	segments := make([]propertyPathSegment, 0)
+	name := ""
+	flush := func() {
+		if name != "" {
+			segments = append(segments, propertyPathSegment{name: name})
+			name = ""
+		}
+	}
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '.':
+			flush()
+		case '[':
+			flush()
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("Invalid property path %s: missing ]", path)
+			}
+			content := path[i+1 : i+end]
+			i += end
+			if unquoted, err := strconv.Unquote(content); err == nil {
+				segments = append(segments, propertyPathSegment{name: unquoted})
+				continue
+			}
+			index, err := strconv.Atoi(content)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("Invalid property path %s: %s is not an index or a quoted name", path, content)
+			}
+			segments = append(segments, propertyPathSegment{index: index, isIndex: true})
+		default:
+			name += string(path[i])
+		}
+	}
+	flush()
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("Empty property path")
+	}
+	return segments, nil
+}
+
+// GetPropertyByPathE returns the value at path in resource, e.g. properties.addressSpace.addressPrefixes[0]
+// or tags["cost.center"]. resource can be a generic map (such as returned by GetResourceByIDE) or an SDK struct,
+// whose properties are named after their JSON names. Names are matched case insensitively when there is no exact match.
+// Pointers are dereferenced, and a null value is returned as nil.
+func GetPropertyByPathE(resource interface{}, path string) (interface{}, error) {
+	segments, err := parsePropertyPath(path)
+	if err != nil {
+		return nil, err
+	}
+	value := reflect.ValueOf(resource)
+	for i, segment := range segments {
+		value = indirectValue(value)
+		if !value.IsValid() {
+			return nil, fmt.Errorf("Property %s is null", joinPropertyPath(segments[:i]))
+		}
+		next, err := propertyValue(value, segment)
+		if err != nil {
+			return nil, fmt.Errorf("Property %s: %s", joinPropertyPath(segments[:i+1]), err)
+		}
+		value = next
+	}
+	value = indirectValue(value)
+	if !value.IsValid() {
+		return nil, nil
+	}
+	return value.Interface(), nil
+}
+
+// GetStringPropertyByPathE is GetPropertyByPathE for a string property
+func GetStringPropertyByPathE(resource interface{}, path string) (string, error) {
+	value, err := GetPropertyByPathE(resource, path)
+	if err != nil {
+		return "", err
+	}
+	if value == nil {
+		return "", nil
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	if s, ok := value.(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+	if reflect.ValueOf(value).Kind() == reflect.String {
+		// SDK enums, e.g. network.SecurityRuleAccess
+		return reflect.ValueOf(value).String(), nil
+	}
+	return "", fmt.Errorf("Property %s is a %T, not a string", path, value)
+}
+
+func joinPropertyPath(segments []propertyPathSegment) string {
+	path := ""
+	for _, segment := range segments {
+		if !segment.isIndex && path != "" {
+			path += "."
+		}
+		path += segment.String()
+	}
+	return path
+}
+
+// indirectValue dereferences pointers and interfaces, it returns an invalid value for nil
+func indirectValue(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+func propertyValue(value reflect.Value, segment propertyPathSegment) (reflect.Value, error) {
+	if segment.isIndex {
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			return reflect.Value{}, fmt.Errorf("not a list")
+		}
+		if segment.index >= value.Len() {
+			return reflect.Value{}, fmt.Errorf("index out of range, the list has %d items", value.Len())
+		}
+		return value.Index(segment.index), nil
+	}
+
+	switch value.Kind() {
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, fmt.Errorf("not an object")
+		}
+		if v := value.MapIndex(reflect.ValueOf(segment.name).Convert(value.Type().Key())); v.IsValid() {
+			return v, nil
+		}
+		for _, key := range value.MapKeys() {
+			if strings.EqualFold(key.String(), segment.name) {
+				return value.MapIndex(key), nil
+			}
+		}
+	case reflect.Struct:
+		if v, ok := structField(value, segment.name, false); ok {
+			return v, nil
+		}
+		if v, ok := structField(value, segment.name, true); ok {
+			return v, nil
+		}
+	default:
+		return reflect.Value{}, fmt.Errorf("not an object")
+	}
+	return reflect.Value{}, fmt.Errorf("not found")
+}
+
+// structField finds a field by its JSON name, looking into embedded structs without a JSON name
+func structField(value reflect.Value, name string, ignoreCase bool) (reflect.Value, bool) {
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			// unexported
+			continue
+		}
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonName == "-" {
+			continue
+		}
+		if jsonName == "" && field.Anonymous {
+			embedded := indirectValue(value.Field(i))
+			if embedded.IsValid() && embedded.Kind() == reflect.Struct {
+				if v, ok := structField(embedded, name, ignoreCase); ok {
+					return v, true
+				}
+			}
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		if jsonName == name || (ignoreCase && strings.EqualFold(jsonName, name)) {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
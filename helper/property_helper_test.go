@@ -0,0 +1,120 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePropertyPath(t *testing.T) {
+	cases := []struct {
+		path     string
+		segments []propertyPathSegment
+		err      string
+	}{
+		{"name", []propertyPathSegment{{name: "name"}}, ""},
+		{"properties.addressSpace.addressPrefixes", []propertyPathSegment{{name: "properties"}, {name: "addressSpace"}, {name: "addressPrefixes"}}, ""},
+		{"properties.subnets[1].name", []propertyPathSegment{{name: "properties"}, {name: "subnets"}, {index: 1, isIndex: true}, {name: "name"}}, ""},
+		{"value[0][2]", []propertyPathSegment{{name: "value"}, {index: 0, isIndex: true}, {index: 2, isIndex: true}}, ""},
+		{`tags["cost.center"]`, []propertyPathSegment{{name: "tags"}, {name: "cost.center"}}, ""},
+		{`tags["0"]`, []propertyPathSegment{{name: "tags"}, {name: "0"}}, ""},
+		{"properties..name.", []propertyPathSegment{{name: "properties"}, {name: "name"}}, ""},
+		{"", nil, "Empty property path"},
+		{"properties.subnets[0", nil, "Invalid property path properties.subnets[0: missing ]"},
+		{"properties.subnets[-1]", nil, "Invalid property path properties.subnets[-1]: -1 is not an index or a quoted name"},
+		{"tags[cost]", nil, "Invalid property path tags[cost]: cost is not an index or a quoted name"},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			segments, err := parsePropertyPath(c.path)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.segments, segments)
+		})
+	}
+}
+
+func TestGetPropertyByPathE(t *testing.T) {
+	resource := map[string]interface{}{
+		"name": "vnet-test",
+		"tags": map[string]interface{}{"cost.center": "42"},
+		"properties": map[string]interface{}{
+			"addressSpace": map[string]interface{}{"addressPrefixes": []interface{}{"10.0.0.0/16", "10.1.0.0/16"}},
+			"subnets": []interface{}{
+				map[string]interface{}{"name": "snet-a", "properties": map[string]interface{}{"routeTable": nil}},
+			},
+			"enableDdosProtection": false,
+		},
+	}
+	vnet := network.VirtualNetwork{
+		Name: to.StringPtr("vnet-test"),
+		VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+			AddressSpace: &network.AddressSpace{AddressPrefixes: &[]string{"10.0.0.0/16"}},
+			Subnets:      &[]network.Subnet{{Name: to.StringPtr("snet-a")}},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		resource interface{}
+		path     string
+		value    interface{}
+		err      string
+	}{
+		{"top level", resource, "name", "vnet-test", ""},
+		{"nested", resource, "properties.enableDdosProtection", false, ""},
+		{"index", resource, "properties.addressSpace.addressPrefixes[1]", "10.1.0.0/16", ""},
+		{"index then name", resource, "properties.subnets[0].name", "snet-a", ""},
+		{"quoted name", resource, `tags["cost.center"]`, "42", ""},
+		{"case insensitive", resource, "Properties.AddressSpace.addressprefixes[0]", "10.0.0.0/16", ""},
+		{"null value", resource, "properties.subnets[0].properties.routeTable", nil, ""},
+		{"missing key", resource, "properties.dhcpOptions", nil, "Property properties.dhcpOptions: not found"},
+		{"missing nested key", resource, "properties.subnets[0].id", nil, "Property properties.subnets[0].id: not found"},
+		{"index out of range", resource, "properties.subnets[1].name", nil, "Property properties.subnets[1]: index out of range, the list has 1 items"},
+		{"index of an object", resource, "properties[0]", nil, "Property properties[0]: not a list"},
+		{"name of a string", resource, "name.value", nil, "Property name.value: not an object"},
+		{"name of a list", resource, "properties.subnets.name", nil, "Property properties.subnets.name: not an object"},
+		{"below null", resource, "properties.subnets[0].properties.routeTable.id", nil, "Property properties.subnets[0].properties.routeTable is null"},
+		{"invalid path", resource, "properties[", nil, "Invalid property path properties[: missing ]"},
+		{"struct JSON names", vnet, "properties.addressSpace.addressPrefixes[0]", "10.0.0.0/16", ""},
+		{"struct pointer", &vnet, "properties.subnets[0].name", "snet-a", ""},
+		{"struct null pointer", vnet, "properties.dhcpOptions", nil, ""},
+		{"struct below null pointer", vnet, "properties.dhcpOptions.dnsServers", nil, "Property properties.dhcpOptions is null"},
+		{"struct missing field", vnet, "properties.foo", nil, "Property properties.foo: not found"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			value, err := GetPropertyByPathE(c.resource, c.path)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.value, value)
+		})
+	}
+}
+
+func TestGetStringPropertyByPathE(t *testing.T) {
+	rule := network.SecurityRule{SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+		Access:   network.SecurityRuleAccessDeny,
+		Priority: to.Int32Ptr(100),
+	}}
+
+	access, err := GetStringPropertyByPathE(rule, "properties.access")
+	require.NoError(t, err)
+	assert.Equal(t, "Deny", access)
+
+	description, err := GetStringPropertyByPathE(rule, "properties.description")
+	require.NoError(t, err)
+	assert.Empty(t, description)
+
+	_, err = GetStringPropertyByPathE(rule, "properties.priority")
+	assert.EqualError(t, err, "Property properties.priority is a int32, not a string")
+}
@@ -0,0 +1,329 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+const (
+	// RecordModeEnvName selects how the helper clients reach Azure: live (default), record or replay
+	RecordModeEnvName = "TEST_RECORD_MODE"
+	// CassetteDirEnvName folder of the cassette files, testdata/cassettes by default
+	CassetteDirEnvName = "TEST_CASSETTE_DIR"
+	// redactedValue replaces the secrets written to cassettes
+	redactedValue = "REDACTED"
+)
+
+// RecordMode tells whether calls made by the helper clients go to Azure, are recorded or are replayed
+type RecordMode string
+
+const (
+	// RecordModeLive calls Azure without recording
+	RecordModeLive RecordMode = "live"
+	// RecordModeRecord calls Azure and saves every request and response to a cassette
+	RecordModeRecord RecordMode = "record"
+	// RecordModeReplay answers every call from a cassette, without network access or credentials
+	RecordModeReplay RecordMode = "replay"
+)
+
+// GetRecordModeE returns the mode set in TEST_RECORD_MODE, live if it is empty
+func GetRecordModeE() (RecordMode, error) {
+	mode := RecordMode(strings.ToLower(os.Getenv(RecordModeEnvName)))
+	switch mode {
+	case "":
+		return RecordModeLive, nil
+	case RecordModeLive, RecordModeRecord, RecordModeReplay:
+		return mode, nil
+	}
+	return "", fmt.Errorf("Unknown record mode %s set in %s", mode, RecordModeEnvName)
+}
+
+// cassette is the content of a cassette file
+type cassette struct {
+	Interactions []interaction `json:"interactions"`
+}
+
+// interaction is a recorded request and its response
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type recordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder records the calls made by the helper clients to a cassette file or replays them from it.
+// Only the final response of a retried call is recorded. Requests are matched on method and URL,
+// in the order they were recorded.
+type Recorder struct {
+	mode     RecordMode
+	path     string
+	mu       sync.Mutex
+	cassette cassette
+	used     []bool
+}
+
+// NewRecorderE creates a Recorder for the cassette at path. In replay mode the cassette must exist.
+func NewRecorderE(mode RecordMode, path string) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path}
+	if mode != RecordModeReplay {
+		return r, nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Can not read cassette %s: %s", path, err)
+	}
+	if err := json.Unmarshal(content, &r.cassette); err != nil {
+		return nil, fmt.Errorf("Invalid cassette %s: %s", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// SendDecorator returns the autorest.SendDecorator that records or replays the calls
+func (r *Recorder) SendDecorator() autorest.SendDecorator {
+	return func(next autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			if r.mode == RecordModeReplay {
+				return r.replay(req)
+			}
+			return r.record(req, next)
+		})
+	}
+}
+
+// Save writes the recorded calls to the cassette file, creating its folder if needed
+func (r *Recorder) Save() error {
+	if r.mode != RecordModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	content, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, content, 0644)
+}
+
+func (r *Recorder) record(req *http.Request, next autorest.Sender) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	resp, err := next.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	recorded := interaction{
+		Request: recordedRequest{
+			Method: req.Method,
+			URL:    normalizeURL(req),
+			Header: scrubHeader(req.Header),
+			Body:   string(requestBody),
+		},
+		Response: recordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     scrubHeader(resp.Header),
+			Body:       string(responseBody),
+		},
+	}
+	secretCall := isSecretRequest(req)
+	recorded.Request.Body = scrubSecrets(recorded.Request.Body, secretCall)
+	recorded.Response.Body = scrubSecrets(recorded.Response.Body, secretCall)
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, recorded)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	url := normalizeURL(req)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, recorded := range r.cassette.Interactions {
+		if r.used[i] || recorded.Request.Method != req.Method || recorded.Request.URL != url {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.Response.StatusCode, http.StatusText(recorded.Response.StatusCode)),
+			StatusCode:    recorded.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.Response.Header,
+			Body:          ioutil.NopCloser(strings.NewReader(recorded.Response.Body)),
+			ContentLength: int64(len(recorded.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("No recorded response for %s %s in cassette %s", req.Method, url, r.path)
+}
+
+// normalizeURL sorts the query parameters so a request matches its recording
+func normalizeURL(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = u.Query().Encode()
+	return u.String()
+}
+
+// scrubHeader returns a copy of header without credentials
+func scrubHeader(header http.Header) http.Header {
+	scrubbed := http.Header{}
+	for name, values := range header {
+		switch http.CanonicalHeaderKey(name) {
+		case "Authorization", "Cookie", "Set-Cookie":
+			scrubbed[name] = []string{redactedValue}
+		default:
+			scrubbed[name] = values
+		}
+	}
+	return scrubbed
+}
+
+// secretPathRegexp matches the calls whose bodies are made of keys, passwords or connection strings,
+// e.g. storage listKeys, AKS listClusterAdminCredential, web app config/appsettings/list or Key Vault secrets
+var secretPathRegexp = regexp.MustCompile(`(?i)(/list[a-z]*(keys|secrets|credentials?|connectionstrings)$|/regenerate[a-z]*key$|/secrets/|/config/[a-z]+/list$)`)
+
+// secretPropertyRegexp matches the JSON properties scrubbed from every recorded body
+var secretPropertyRegexp = regexp.MustCompile(`(?i)(key|secret|password|connectionstring|token)$`)
+
+// identityPropertyRegexp matches the JSON properties kept in the bodies of the secret calls, every other
+// string is scrubbed as their names can be anything, e.g. the names of app settings
+var identityPropertyRegexp = regexp.MustCompile(`^(id|name|type|location|kind|keyName)$`)
+
+func isSecretRequest(req *http.Request) bool {
+	return secretPathRegexp.MatchString(req.URL.Path)
+}
+
+// scrubSecrets replaces the secret string values of a JSON body. For a secret call every string value but the
+// identity of resources is replaced, and the whole body if it isn't JSON. Bodies without secrets are kept as is.
+func scrubSecrets(body string, secretCall bool) string {
+	if body == "" {
+		return body
+	}
+	var content interface{}
+	decoder := json.NewDecoder(strings.NewReader(body))
+	// keep large numbers as they are
+	decoder.UseNumber()
+	if err := decoder.Decode(&content); err != nil {
+		if secretCall {
+			return redactedValue
+		}
+		return body
+	}
+	if !scrubValue(content, secretCall) {
+		return body
+	}
+	scrubbed, err := json.Marshal(content)
+	if err != nil {
+		return redactedValue
+	}
+	return string(scrubbed)
+}
+
+// scrubValue scrubs the secrets of a decoded JSON value in place and tells whether there were any
+func scrubValue(value interface{}, secretCall bool) bool {
+	scrubbed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, property := range v {
+			if s, ok := property.(string); ok {
+				secret := secretPropertyRegexp.MatchString(name) || (secretCall && !identityPropertyRegexp.MatchString(name))
+				if secret && s != "" && s != redactedValue {
+					v[name] = redactedValue
+					scrubbed = true
+				}
+				continue
+			}
+			scrubbed = scrubValue(property, secretCall) || scrubbed
+		}
+	case []interface{}:
+		for i := range v {
+			if s, ok := v[i].(string); ok && secretCall && s != "" && s != redactedValue {
+				v[i] = redactedValue
+				scrubbed = true
+				continue
+			}
+			scrubbed = scrubValue(v[i], secretCall) || scrubbed
+		}
+	}
+	return scrubbed
+}
+
+// SetupRecording records or replays the calls made by the helper clients during the test, depending on
+// TEST_RECORD_MODE. The cassette is <TEST_CASSETTE_DIR>/<test name>.json and is saved when the test finishes.
+// In replay mode no credentials are needed. It changes the default client factory until the test finishes,
+// so tests using it must not run in parallel.
+func SetupRecording(t *testing.T) {
+	mode, err := GetRecordModeE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode == RecordModeLive {
+		return
+	}
+	dir := os.Getenv(CassetteDirEnvName)
+	if dir == "" {
+		dir = filepath.Join("testdata", "cassettes")
+	}
+	path := filepath.Join(dir, strings.Replace(t.Name(), "/", "_", -1)+".json")
+	recorder, err := NewRecorderE(mode, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decorators := defaultClientFactory.SendDecorators()
+	authorizer := defaultClientFactory.Authorizer()
+	defaultClientFactory.SetSendDecorators(recorder.SendDecorator())
+	if mode == RecordModeReplay {
+		defaultClientFactory.SetAuthorizer(autorest.NullAuthorizer{})
+	}
+	t.Cleanup(func() {
+		defaultClientFactory.SetSendDecorators(decorators...)
+		defaultClientFactory.SetAuthorizer(authorizer)
+		if err := recorder.Save(); err != nil {
+			t.Errorf("Can not save cassette %s: %s", path, err)
+		}
+	})
+	t.Logf("Using cassette %s in %s mode", path, mode)
+}
@@ -0,0 +1,150 @@
+package helper
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testResourceGroupID = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg-test"
+
+// recordResponses records one call per path of responses, answered by a local server, and returns the saved cassette
+func recordResponses(t *testing.T, responses map[string]string) cassette {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Write([]byte(responses[req.URL.Path]))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := NewRecorderE(RecordModeRecord, path)
+	require.NoError(t, err)
+	sender := recorder.SendDecorator()(http.DefaultClient)
+	for urlPath := range responses {
+		method := http.MethodGet
+		if strings.Contains(urlPath, "/list") {
+			method = http.MethodPost
+		}
+		req, err := http.NewRequest(method, server.URL+urlPath+"?api-version=2020-01-01", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret-token")
+		resp, err := sender.Do(req)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		// the caller gets the response as it was sent
+		assert.Equal(t, responses[urlPath], string(body))
+	}
+	require.NoError(t, recorder.Save())
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var saved cassette
+	require.NoError(t, json.Unmarshal(content, &saved))
+	require.Len(t, saved.Interactions, len(responses))
+	assert.NotContains(t, string(content), "secret")
+	return saved
+}
+
+func findInteraction(t *testing.T, saved cassette, urlPath string) interaction {
+	for _, recorded := range saved.Interactions {
+		if strings.Contains(recorded.Request.URL, urlPath+"?") {
+			return recorded
+		}
+	}
+	t.Fatalf("No interaction for %s", urlPath)
+	return interaction{}
+}
+
+func TestRecorderScrubsSecrets(t *testing.T) {
+	aksPath := testResourceGroupID + "/providers/Microsoft.ContainerService/managedClusters/aks/listClusterAdminCredential"
+	appSettingsPath := testResourceGroupID + "/providers/Microsoft.Web/sites/app/config/appsettings/list"
+	storageKeysPath := testResourceGroupID + "/providers/Microsoft.Storage/storageAccounts/st/listKeys"
+	deploymentPath := testResourceGroupID + "/providers/Microsoft.Resources/deployments/main"
+	saved := recordResponses(t, map[string]string{
+		aksPath: `{"kubeconfigs": [{"name": "clusterAdmin", "value": "YXBpVmVyc2lvbjogdjEKc2VjcmV0"}]}`,
+		appSettingsPath: `{"id": "` + appSettingsPath + `", "name": "appsettings", "properties": {
+			"DB_CONNECTION": "Server=tcp:sql;Password=secret", "FEATURE_FLAG": "on"}}`,
+		storageKeysPath: `{"keys": [{"keyName": "key1", "value": "secretkey1", "permissions": "FULL"}]}`,
+		deploymentPath: `{"name": "main", "properties": {"outputs": {
+			"vnetId": {"type": "String", "value": "/subscriptions/x/vnet"},
+			"adminPassword": "secret-password",
+			"size": {"type": "Int", "value": 12345678901234567890}}}}`,
+	})
+
+	aks := findInteraction(t, saved, aksPath)
+	assert.JSONEq(t, `{"kubeconfigs": [{"name": "clusterAdmin", "value": "REDACTED"}]}`, aks.Response.Body)
+	assert.Equal(t, []string{redactedValue}, aks.Request.Header["Authorization"])
+	assert.Equal(t, []string{redactedValue}, aks.Response.Header["Set-Cookie"])
+
+	appSettings := findInteraction(t, saved, appSettingsPath)
+	assert.JSONEq(t, `{"id": "`+appSettingsPath+`", "name": "appsettings", "properties": {
+		"DB_CONNECTION": "REDACTED", "FEATURE_FLAG": "REDACTED"}}`, appSettings.Response.Body)
+
+	storageKeys := findInteraction(t, saved, storageKeysPath)
+	assert.JSONEq(t, `{"keys": [{"keyName": "key1", "value": "REDACTED", "permissions": "REDACTED"}]}`, storageKeys.Response.Body)
+
+	// outside of secret calls only the properties named like secrets are scrubbed, numbers are kept as they are
+	deployment := findInteraction(t, saved, deploymentPath)
+	assert.Contains(t, deployment.Response.Body, `"value":"/subscriptions/x/vnet"`)
+	assert.Contains(t, deployment.Response.Body, `"adminPassword":"REDACTED"`)
+	assert.Contains(t, deployment.Response.Body, "12345678901234567890")
+}
+
+func TestScrubSecretsKeepsBodiesWithoutSecrets(t *testing.T) {
+	body := `{"name": "vnet",  "properties": {"addressSpace": {"addressPrefixes": ["10.0.0.0/16"]}}}`
+	assert.Equal(t, body, scrubSecrets(body, false))
+	assert.Equal(t, "not json", scrubSecrets("not json", false))
+	assert.Equal(t, redactedValue, scrubSecrets("not json", true))
+	assert.Equal(t, "", scrubSecrets("", true))
+}
+
+func TestIsSecretRequest(t *testing.T) {
+	cases := map[string]bool{
+		"/subscriptions/s/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/st/listKeys":                             true,
+		"/subscriptions/s/resourceGroups/rg/providers/Microsoft.Cache/redis/redis/listKeys":                                      true,
+		"/subscriptions/s/resourceGroups/rg/providers/Microsoft.DocumentDB/databaseAccounts/db/listConnectionStrings":            true,
+		"/subscriptions/s/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks/listClusterAdminCredential": true,
+		"/subscriptions/s/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks/listClusterUserCredential":  true,
+		"/subscriptions/s/resourceGroups/rg/providers/Microsoft.ContainerRegistry/registries/acr/listCredentials":                true,
+		"/subscriptions/s/resourceGroups/rg/providers/Microsoft.Web/sites/app/config/appsettings/list":                           true,
+		"/subscriptions/s/resourceGroups/rg/providers/Microsoft.Web/sites/app/config/connectionstrings/list":                     true,
+		"/subscriptions/s/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/st/regenerateKey":                        true,
+		"/secrets/db-password/0123456789abcdef":                                               true,
+		"/subscriptions/s/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet": false,
+		"/subscriptions/s/resourceGroups/rg/providers/Microsoft.Web/sites/app/config/web":     false,
+	}
+	for path, secret := range cases {
+		req := httptest.NewRequest(http.MethodPost, "https://management.azure.com"+path, nil)
+		assert.Equal(t, secret, isSecretRequest(req), path)
+	}
+}
+
+func TestSetupRecordingRestoresTheClientFactory(t *testing.T) {
+	s := setupTestARMServer(t)
+	s.AddResource(t, testVnetID, testVnet)
+	dir := t.TempDir()
+	t.Setenv(CassetteDirEnvName, dir)
+	cassettePath := filepath.Join(dir, "TestSetupRecordingRestoresTheClientFactory_replay.json")
+	require.NoError(t, ioutil.WriteFile(cassettePath, []byte(`{"interactions": []}`), 0600))
+
+	for _, mode := range []RecordMode{RecordModeRecord, RecordModeReplay} {
+		t.Run(string(mode), func(t *testing.T) {
+			t.Setenv(RecordModeEnvName, string(mode))
+			SetupRecording(t)
+		})
+		// the calls go to the fake server again, without credentials
+		_, err := GetVirtualNetworkE("rg-test", "vnet-test")
+		require.NoError(t, err, mode)
+		assert.Equal(t, autorest.NullAuthorizer{}, defaultClientFactory.Authorizer(), mode)
+	}
+}
@@ -0,0 +1,112 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2019-04-01/resourcegraph"
+	"github.com/mitchellh/mapstructure"
+)
+
+/********************************
+		Resource Graph
+*********************************/
+
+// resourceGraphPageSize is the number of rows asked per call, the maximum allowed by Resource Graph
+const resourceGraphPageSize = 1000
+
+// QueryResourceGraphE runs a Resource Graph (KQL) query against subscriptions, ARM_SUBSCRIPTION_ID if none is given,
+// and returns every row, following the skip tokens until the last page
+func QueryResourceGraphE(query string, subscriptions ...string) ([]map[string]interface{}, error) {
+	return QueryResourceGraphWithContextE(context.Background(), query, subscriptions...)
+}
+
+// QueryResourceGraphWithContextE runs a Resource Graph (KQL) query against subscriptions, ARM_SUBSCRIPTION_ID if none is given,
+// and returns every row, following the skip tokens until the last page
+func QueryResourceGraphWithContextE(ctx context.Context, query string, subscriptions ...string) ([]map[string]interface{}, error) {
+	if len(subscriptions) == 0 {
+		subscriptions = []string{getTargetSubscription()}
+	}
+	client, err := GetResourceGraphClientE()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string]interface{}, 0)
+	top := int32(resourceGraphPageSize)
+	request := resourcegraph.QueryRequest{
+		Subscriptions: &subscriptions,
+		Query:         &query,
+		Options: &resourcegraph.QueryRequestOptions{
+			Top:          &top,
+			ResultFormat: resourcegraph.ResultFormatObjectArray,
+		},
+	}
+	for {
+		response, err := client.Resources(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		page, ok := response.Data.([]interface{})
+		if !ok && response.Data != nil {
+			return nil, fmt.Errorf("Unexpected Resource Graph result of type %T", response.Data)
+		}
+		for _, row := range page {
+			columns, ok := row.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("Unexpected Resource Graph row of type %T", row)
+			}
+			rows = append(rows, columns)
+		}
+		if response.SkipToken == nil || *response.SkipToken == "" {
+			if response.ResultTruncated == resourcegraph.True {
+				log.Printf("Warning: Resource Graph results of query %q are truncated, project the id column to page through all of them\n", query)
+			}
+			return rows, nil
+		}
+		request.Options.SkipToken = response.SkipToken
+	}
+}
+
+// QueryResourceGraphIntoE runs a Resource Graph (KQL) query like QueryResourceGraphE and decodes the rows into result,
+// a pointer to a slice of structs, the same way GetYamlVariables decodes YAML. Columns are matched to the fields
+// by name (case insensitive) or by mapstructure tag. Values are converted to the type of the field when they can be,
+// e.g. a count into an int or a number into a string.
+func QueryResourceGraphIntoE(query string, result interface{}, subscriptions ...string) error {
+	return QueryResourceGraphIntoWithContextE(context.Background(), query, result, subscriptions...)
+}
+
+// QueryResourceGraphIntoWithContextE runs a Resource Graph (KQL) query like QueryResourceGraphE and decodes the rows into result,
+// a pointer to a slice of structs, the same way GetYamlVariables decodes YAML. Columns are matched to the fields
+// by name (case insensitive) or by mapstructure tag.
+func QueryResourceGraphIntoWithContextE(ctx context.Context, query string, result interface{}, subscriptions ...string) error {
+	rows, err := QueryResourceGraphWithContextE(ctx, query, subscriptions...)
+	if err != nil {
+		return err
+	}
+	// numbers come as float64 from JSON, weak typing decodes them into integer fields (and any column into a string)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           result,
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(rows); err != nil {
+		return fmt.Errorf("Can not decode Resource Graph rows: %s", err)
+	}
+	return nil
+}
+
+// GetResourceGraphClientE creates a Resource Graph client
+func GetResourceGraphClientE() (*resourcegraph.BaseClient, error) {
+	client, err := defaultClientFactory.ResourceManagerClientE("", func(subscriptionID string) interface{} {
+		client := resourcegraph.New()
+		return &client
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(*resourcegraph.BaseClient), nil
+}
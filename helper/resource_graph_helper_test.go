@@ -0,0 +1,85 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryResourceGraphIntoE(t *testing.T) {
+	s := setupTestARMServer(t)
+	require.NoError(t, s.AddResponseE(http.MethodPost, "/providers/Microsoft.ResourceGraph/resources", http.StatusOK, `{
+		"totalRecords": 2,
+		"count": 2,
+		"resultTruncated": "false",
+		"data": [
+			{"name": "disk-a", "location": "westeurope", "diskSizeGB": 128, "count_": 3, "encrypted": true, "tier": 20},
+			{"name": "disk-b", "location": "northeurope", "diskSizeGB": 1024, "count_": 1, "encrypted": "false", "tier": "P30"}
+		]
+	}`))
+
+	type disk struct {
+		Name       string
+		Location   string
+		DiskSizeGB int64
+		Count      int `mapstructure:"count_"`
+		Encrypted  bool
+		Tier       string
+	}
+	var disks []disk
+	require.NoError(t, QueryResourceGraphIntoE("Resources | where type =~ 'microsoft.compute/disks'", &disks))
+	assert.Equal(t, []disk{
+		{Name: "disk-a", Location: "westeurope", DiskSizeGB: 128, Count: 3, Encrypted: true, Tier: "20"},
+		{Name: "disk-b", Location: "northeurope", DiskSizeGB: 1024, Count: 1, Encrypted: false, Tier: "P30"},
+	}, disks)
+
+	// a name is not a number, even weakly typed
+	var invalid []struct{ Name int }
+	assert.Error(t, QueryResourceGraphIntoE("Resources", &invalid))
+}
+
+func TestQueryResourceGraphIntoEPages(t *testing.T) {
+	s := setupTestARMServer(t)
+	require.NoError(t, s.AddResponsesE(http.MethodPost, "/providers/Microsoft.ResourceGraph/resources", http.StatusOK, `{
+		"totalRecords": 3,
+		"count": 2,
+		"resultTruncated": "false",
+		"$skipToken": "page-2",
+		"data": [{"name": "disk-a"}, {"name": "disk-b"}]
+	}`, `{
+		"totalRecords": 3,
+		"count": 1,
+		"resultTruncated": "false",
+		"data": [{"name": "disk-c"}]
+	}`))
+
+	// keep the request bodies to check the skip token is sent back
+	requests := make([]map[string]interface{}, 0)
+	capture := func(next autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			content, err := ioutil.ReadAll(req.Body)
+			require.NoError(t, err)
+			req.Body = ioutil.NopCloser(bytes.NewReader(content))
+			request := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(content, &request))
+			requests = append(requests, request)
+			return next.Do(req)
+		})
+	}
+	defaultClientFactory.SetSendDecorators(capture, s.SendDecorator())
+
+	var disks []struct{ Name string }
+	require.NoError(t, QueryResourceGraphIntoE("Resources | project name", &disks))
+	assert.Equal(t, []struct{ Name string }{{"disk-a"}, {"disk-b"}, {"disk-c"}}, disks)
+
+	require.Len(t, requests, 2)
+	assert.NotContains(t, bodyProperty(t, requests[0], "options"), "$skipToken")
+	assert.Equal(t, "page-2", bodyProperty(t, requests[1], `options["$skipToken"]`))
+	assert.Equal(t, []interface{}{testSubscriptionID}, bodyProperty(t, requests[1], "subscriptions"))
+}
@@ -0,0 +1,1259 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/containerservice/mgmt/containerservice"
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/msi/mgmt/msi"
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/recoveryservices/mgmt/backup"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-12-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/containerregistry/mgmt/2019-04-01/containerregistry"
+	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mgmt/2020-04-01/documentdb"
+	"github.com/Azure/azure-sdk-for-go/services/eventhub/mgmt/2017-04-01/eventhub"
+	"github.com/Azure/azure-sdk-for-go/services/frontdoor/mgmt/2019-05-01/frontdoor"
+	kv "github.com/Azure/azure-sdk-for-go/services/keyvault/mgmt/2018-02-14/keyvault"
+	mysql "github.com/Azure/azure-sdk-for-go/services/mysql/mgmt/2020-01-01/mysql"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/preview/operationalinsights/mgmt/2015-11-01-preview/operationalinsights"
+	"github.com/Azure/azure-sdk-for-go/services/preview/operationsmanagement/mgmt/2015-11-01-preview/operationsmanagement"
+	sqlmi "github.com/Azure/azure-sdk-for-go/services/preview/sql/mgmt/v3.0/sql"
+	"github.com/Azure/azure-sdk-for-go/services/privatedns/mgmt/2018-09-01/privatedns"
+	"github.com/Azure/azure-sdk-for-go/services/recoveryservices/mgmt/2016-06-01/recoveryservices"
+	"github.com/Azure/azure-sdk-for-go/services/redis/mgmt/2018-03-01/redis"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
+	"github.com/Azure/azure-sdk-for-go/services/sql/mgmt/2014-04-01/sql"
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2019-06-01/storage"
+	"github.com/Azure/azure-sdk-for-go/services/web/mgmt/2019-08-01/web"
+)
+
+// ResourceID is a parsed Azure Resource Manager resource ID, e.g.
+// /subscriptions/{id}/resourceGroups/{rg}/providers/Microsoft.Network/virtualNetworks/{vnet}/subnets/{subnet}
+type ResourceID struct {
+	SubscriptionID string
+	ResourceGroup  string
+	Provider       string
+	// Types and Names hold the type/name pairs that follow the provider, e.g. [virtualNetworks subnets] and [vnet subnet]
+	Types []string
+	Names []string
+	// Scope is the resource an extension resource applies to, e.g. the virtual network of
+	// .../virtualNetworks/{vnet}/providers/Microsoft.Authorization/locks/{lock}, nil for other resources
+	Scope *ResourceID
+}
+
+// ParseResourceIDE parses an Azure Resource Manager resource ID. The ID of an extension resource, e.g. a lock or
+// diagnostic settings of another resource, is parsed as the extension resource with the other resource as Scope.
+func ParseResourceIDE(resourceID string) (*ResourceID, error) {
+	parts := strings.Split(strings.Trim(resourceID, "/"), "/")
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("Invalid resource ID %s", resourceID)
+		}
+	}
+	if len(parts) < 2 || !strings.EqualFold(parts[0], "subscriptions") {
+		return nil, fmt.Errorf("Invalid resource ID %s: it must start with /subscriptions/{id}", resourceID)
+	}
+
+	id := ResourceID{SubscriptionID: parts[1]}
+	parts = parts[2:]
+	if len(parts) >= 2 && strings.EqualFold(parts[0], "resourceGroups") {
+		id.ResourceGroup = parts[1]
+		parts = parts[2:]
+	}
+	if len(parts) == 0 {
+		return &id, nil
+	}
+	for {
+		if len(parts) < 4 || !strings.EqualFold(parts[0], "providers") {
+			return nil, fmt.Errorf("Invalid resource ID %s: expected /providers/{namespace}/{type}/{name}", resourceID)
+		}
+		id.Provider = parts[1]
+		i := 2
+		for ; i < len(parts) && !strings.EqualFold(parts[i], "providers"); i += 2 {
+			if i+1 == len(parts) {
+				return nil, fmt.Errorf("Invalid resource ID %s: type %s has no name", resourceID, parts[i])
+			}
+			id.Types = append(id.Types, parts[i])
+			id.Names = append(id.Names, parts[i+1])
+		}
+		if i == len(parts) {
+			return &id, nil
+		}
+		// extension resource of the resource parsed so far
+		scope := id
+		id = ResourceID{SubscriptionID: scope.SubscriptionID, ResourceGroup: scope.ResourceGroup, Scope: &scope}
+		parts = parts[i:]
+	}
+}
+
+// ParseResourceIDOfTypeE parses resourceID and checks it points to a resource of resourceType,
+// e.g. Microsoft.Network/virtualNetworks/subnets
+func ParseResourceIDOfTypeE(resourceID string, resourceType string) (*ResourceID, error) {
+	id, err := ParseResourceIDE(resourceID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(id.ResourceType(), resourceType) {
+		return nil, fmt.Errorf("Resource ID %s is a %s, expected %s", resourceID, id.ResourceType(), resourceType)
+	}
+	return id, nil
+}
+
+// ResourceType returns the full resource type, e.g. Microsoft.Network/virtualNetworks/subnets
+func (id *ResourceID) ResourceType() string {
+	if id.Provider == "" {
+		if id.ResourceGroup == "" {
+			return "Microsoft.Resources/subscriptions"
+		}
+		return "Microsoft.Resources/resourceGroups"
+	}
+	return strings.Join(append([]string{id.Provider}, id.Types...), "/")
+}
+
+// Name returns the name of the resource the ID points to
+func (id *ResourceID) Name() string {
+	if len(id.Names) > 0 {
+		return id.Names[len(id.Names)-1]
+	}
+	if id.ResourceGroup != "" {
+		return id.ResourceGroup
+	}
+	return id.SubscriptionID
+}
+
+// String returns the resource ID
+func (id *ResourceID) String() string {
+	resourceID := fmt.Sprintf("/subscriptions/%s", id.SubscriptionID)
+	if id.Scope != nil {
+		resourceID = id.Scope.String()
+	} else if id.ResourceGroup != "" {
+		resourceID = fmt.Sprintf("%s/resourceGroups/%s", resourceID, id.ResourceGroup)
+	}
+	if id.Provider != "" {
+		resourceID = fmt.Sprintf("%s/providers/%s", resourceID, id.Provider)
+		for i := range id.Types {
+			resourceID = fmt.Sprintf("%s/%s/%s", resourceID, id.Types[i], id.Names[i])
+		}
+	}
+	return resourceID
+}
+
+/********************************
+		ResourceGroup by ID
+*********************************/
+
+// GetResourceGroupByID is the same as GetResourceGroupByIDE but fails the test on error
+func GetResourceGroupByID(t *testing.T, resourceID string) *resources.Group {
+	t.Helper()
+	resourceGroup, err := GetResourceGroupByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Resources/resourceGroups", resourceID)
+	return resourceGroup
+}
+
+// GetResourceGroupByIDE will return resources.Group object from its resource ID and an error object
+func GetResourceGroupByIDE(resourceID string) (*resources.Group, error) {
+	return GetResourceGroupByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetResourceGroupByIDWithContextE will return resources.Group object from its resource ID and an error object
+func GetResourceGroupByIDWithContextE(ctx context.Context, resourceID string) (*resources.Group, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Resources/resourceGroups")
+	if err != nil {
+		return nil, err
+	}
+	return GetResourceGroupWithContextE(ctx, id.ResourceGroup, id.SubscriptionID)
+}
+
+/********************************
+		VirtualMachine by ID
+*********************************/
+
+// GetVirtualMachineByID is the same as GetVirtualMachineByIDE but fails the test on error
+func GetVirtualMachineByID(t *testing.T, resourceID string) *compute.VirtualMachine {
+	t.Helper()
+	virtualMachine, err := GetVirtualMachineByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Compute/virtualMachines", resourceID)
+	return virtualMachine
+}
+
+// GetVirtualMachineByIDE will return compute.VirtualMachine object from its resource ID and an error object
+func GetVirtualMachineByIDE(resourceID string) (*compute.VirtualMachine, error) {
+	return GetVirtualMachineByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetVirtualMachineByIDWithContextE will return compute.VirtualMachine object from its resource ID and an error object
+func GetVirtualMachineByIDWithContextE(ctx context.Context, resourceID string) (*compute.VirtualMachine, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Compute/virtualMachines")
+	if err != nil {
+		return nil, err
+	}
+	return GetVirtualMachineWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		Disk by ID
+*********************************/
+
+// GetDiskByID is the same as GetDiskByIDE but fails the test on error
+func GetDiskByID(t *testing.T, resourceID string) *compute.Disk {
+	t.Helper()
+	disk, err := GetDiskByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Compute/disks", resourceID)
+	return disk
+}
+
+// GetDiskByIDE will return compute.Disk object from its resource ID and an error object
+func GetDiskByIDE(resourceID string) (*compute.Disk, error) {
+	return GetDiskByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetDiskByIDWithContextE will return compute.Disk object from its resource ID and an error object
+func GetDiskByIDWithContextE(ctx context.Context, resourceID string) (*compute.Disk, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Compute/disks")
+	if err != nil {
+		return nil, err
+	}
+	return GetDiskWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		Interface by ID
+*********************************/
+
+// GetInterfaceByID is the same as GetInterfaceByIDE but fails the test on error
+func GetInterfaceByID(t *testing.T, resourceID string) *network.Interface {
+	t.Helper()
+	networkInterface, err := GetInterfaceByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/networkInterfaces", resourceID)
+	return networkInterface
+}
+
+// GetInterfaceByIDE will return network.Interface object from its resource ID and an error object
+func GetInterfaceByIDE(resourceID string) (*network.Interface, error) {
+	return GetInterfaceByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetInterfaceByIDWithContextE will return network.Interface object from its resource ID and an error object
+func GetInterfaceByIDWithContextE(ctx context.Context, resourceID string) (*network.Interface, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/networkInterfaces")
+	if err != nil {
+		return nil, err
+	}
+	return GetInterfaceWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		StorageAccount by ID
+*********************************/
+
+// GetStorageAccountByID is the same as GetStorageAccountByIDE but fails the test on error
+func GetStorageAccountByID(t *testing.T, resourceID string) *storage.Account {
+	t.Helper()
+	storageAccount, err := GetStorageAccountByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Storage/storageAccounts", resourceID)
+	return storageAccount
+}
+
+// GetStorageAccountByIDE will return storage.Account object from its resource ID and an error object
+func GetStorageAccountByIDE(resourceID string) (*storage.Account, error) {
+	return GetStorageAccountByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetStorageAccountByIDWithContextE will return storage.Account object from its resource ID and an error object
+func GetStorageAccountByIDWithContextE(ctx context.Context, resourceID string) (*storage.Account, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Storage/storageAccounts")
+	if err != nil {
+		return nil, err
+	}
+	return GetStorageAccountWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		VirtualNetwork by ID
+*********************************/
+
+// GetVirtualNetworkByID is the same as GetVirtualNetworkByIDE but fails the test on error
+func GetVirtualNetworkByID(t *testing.T, resourceID string) *network.VirtualNetwork {
+	t.Helper()
+	virtualNetwork, err := GetVirtualNetworkByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/virtualNetworks", resourceID)
+	return virtualNetwork
+}
+
+// GetVirtualNetworkByIDE will return network.VirtualNetwork object from its resource ID and an error object
+func GetVirtualNetworkByIDE(resourceID string) (*network.VirtualNetwork, error) {
+	return GetVirtualNetworkByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetVirtualNetworkByIDWithContextE will return network.VirtualNetwork object from its resource ID and an error object
+func GetVirtualNetworkByIDWithContextE(ctx context.Context, resourceID string) (*network.VirtualNetwork, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/virtualNetworks")
+	if err != nil {
+		return nil, err
+	}
+	return GetVirtualNetworkWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		VirtualNetworkPeering by ID
+*********************************/
+
+// GetVirtualNetworkPeeringByID is the same as GetVirtualNetworkPeeringByIDE but fails the test on error
+func GetVirtualNetworkPeeringByID(t *testing.T, resourceID string) *network.VirtualNetworkPeering {
+	t.Helper()
+	virtualNetworkPeering, err := GetVirtualNetworkPeeringByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/virtualNetworks/virtualNetworkPeerings", resourceID)
+	return virtualNetworkPeering
+}
+
+// GetVirtualNetworkPeeringByIDE will return network.VirtualNetworkPeering object from its resource ID and an error object
+func GetVirtualNetworkPeeringByIDE(resourceID string) (*network.VirtualNetworkPeering, error) {
+	return GetVirtualNetworkPeeringByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetVirtualNetworkPeeringByIDWithContextE will return network.VirtualNetworkPeering object from its resource ID and an error object
+func GetVirtualNetworkPeeringByIDWithContextE(ctx context.Context, resourceID string) (*network.VirtualNetworkPeering, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/virtualNetworks/virtualNetworkPeerings")
+	if err != nil {
+		return nil, err
+	}
+	return GetVirtualNetworkPeeringWithContextE(ctx, id.ResourceGroup, id.Names[0], id.Names[1], id.SubscriptionID)
+}
+
+/********************************
+		Subnet by ID
+*********************************/
+
+// GetSubnetByID is the same as GetSubnetByIDE but fails the test on error
+func GetSubnetByID(t *testing.T, resourceID string) *network.Subnet {
+	t.Helper()
+	subnet, err := GetSubnetByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/virtualNetworks/subnets", resourceID)
+	return subnet
+}
+
+// GetSubnetByIDE will return network.Subnet object from its resource ID and an error object
+func GetSubnetByIDE(resourceID string) (*network.Subnet, error) {
+	return GetSubnetByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetSubnetByIDWithContextE will return network.Subnet object from its resource ID and an error object
+func GetSubnetByIDWithContextE(ctx context.Context, resourceID string) (*network.Subnet, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/virtualNetworks/subnets")
+	if err != nil {
+		return nil, err
+	}
+	return GetSubnetWithContextE(ctx, id.ResourceGroup, id.Names[0], id.Names[1], id.SubscriptionID)
+}
+
+/********************************
+		KeyVault by ID
+*********************************/
+
+// GetKeyVaultByID is the same as GetKeyVaultByIDE but fails the test on error
+func GetKeyVaultByID(t *testing.T, resourceID string) *kv.Vault {
+	t.Helper()
+	keyVault, err := GetKeyVaultByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.KeyVault/vaults", resourceID)
+	return keyVault
+}
+
+// GetKeyVaultByIDE will return kv.Vault object from its resource ID and an error object
+func GetKeyVaultByIDE(resourceID string) (*kv.Vault, error) {
+	return GetKeyVaultByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetKeyVaultByIDWithContextE will return kv.Vault object from its resource ID and an error object
+func GetKeyVaultByIDWithContextE(ctx context.Context, resourceID string) (*kv.Vault, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.KeyVault/vaults")
+	if err != nil {
+		return nil, err
+	}
+	return GetKeyVaultWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		PrivateEndpoint by ID
+*********************************/
+
+// GetPrivateEndpointByID is the same as GetPrivateEndpointByIDE but fails the test on error
+func GetPrivateEndpointByID(t *testing.T, resourceID string) *network.PrivateEndpoint {
+	t.Helper()
+	privateEndpoint, err := GetPrivateEndpointByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/privateEndpoints", resourceID)
+	return privateEndpoint
+}
+
+// GetPrivateEndpointByIDE will return network.PrivateEndpoint object from its resource ID and an error object
+func GetPrivateEndpointByIDE(resourceID string) (*network.PrivateEndpoint, error) {
+	return GetPrivateEndpointByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetPrivateEndpointByIDWithContextE will return network.PrivateEndpoint object from its resource ID and an error object
+func GetPrivateEndpointByIDWithContextE(ctx context.Context, resourceID string) (*network.PrivateEndpoint, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/privateEndpoints")
+	if err != nil {
+		return nil, err
+	}
+	return GetPrivateEndpointWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		PrivateDNSZoneGroup by ID
+*********************************/
+
+// GetPrivateDNSZoneGroupByID is the same as GetPrivateDNSZoneGroupByIDE but fails the test on error
+func GetPrivateDNSZoneGroupByID(t *testing.T, resourceID string) *network.PrivateDNSZoneGroup {
+	t.Helper()
+	privateDNSZoneGroup, err := GetPrivateDNSZoneGroupByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/privateEndpoints/privateDnsZoneGroups", resourceID)
+	return privateDNSZoneGroup
+}
+
+// GetPrivateDNSZoneGroupByIDE will return network.PrivateDNSZoneGroup object from its resource ID and an error object
+func GetPrivateDNSZoneGroupByIDE(resourceID string) (*network.PrivateDNSZoneGroup, error) {
+	return GetPrivateDNSZoneGroupByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetPrivateDNSZoneGroupByIDWithContextE will return network.PrivateDNSZoneGroup object from its resource ID and an error object
+func GetPrivateDNSZoneGroupByIDWithContextE(ctx context.Context, resourceID string) (*network.PrivateDNSZoneGroup, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/privateEndpoints/privateDnsZoneGroups")
+	if err != nil {
+		return nil, err
+	}
+	return GetPrivateDNSZoneGroupWithContextE(ctx, id.ResourceGroup, id.Names[0], id.Names[1], id.SubscriptionID)
+}
+
+/********************************
+		PrivateDNSZone by ID
+*********************************/
+
+// GetPrivateDNSZoneByID is the same as GetPrivateDNSZoneByIDE but fails the test on error
+func GetPrivateDNSZoneByID(t *testing.T, resourceID string) *privatedns.PrivateZone {
+	t.Helper()
+	privateDNSZone, err := GetPrivateDNSZoneByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/privateDnsZones", resourceID)
+	return privateDNSZone
+}
+
+// GetPrivateDNSZoneByIDE will return privatedns.PrivateZone object from its resource ID and an error object
+func GetPrivateDNSZoneByIDE(resourceID string) (*privatedns.PrivateZone, error) {
+	return GetPrivateDNSZoneByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetPrivateDNSZoneByIDWithContextE will return privatedns.PrivateZone object from its resource ID and an error object
+func GetPrivateDNSZoneByIDWithContextE(ctx context.Context, resourceID string) (*privatedns.PrivateZone, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/privateDnsZones")
+	if err != nil {
+		return nil, err
+	}
+	return GetPrivateDNSZoneWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		VirtualNetworkLink by ID
+*********************************/
+
+// GetVirtualNetworkLinkByID is the same as GetVirtualNetworkLinkByIDE but fails the test on error
+func GetVirtualNetworkLinkByID(t *testing.T, resourceID string) *privatedns.VirtualNetworkLink {
+	t.Helper()
+	virtualNetworkLink, err := GetVirtualNetworkLinkByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/privateDnsZones/virtualNetworkLinks", resourceID)
+	return virtualNetworkLink
+}
+
+// GetVirtualNetworkLinkByIDE will return privatedns.VirtualNetworkLink object from its resource ID and an error object
+func GetVirtualNetworkLinkByIDE(resourceID string) (*privatedns.VirtualNetworkLink, error) {
+	return GetVirtualNetworkLinkByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetVirtualNetworkLinkByIDWithContextE will return privatedns.VirtualNetworkLink object from its resource ID and an error object
+func GetVirtualNetworkLinkByIDWithContextE(ctx context.Context, resourceID string) (*privatedns.VirtualNetworkLink, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/privateDnsZones/virtualNetworkLinks")
+	if err != nil {
+		return nil, err
+	}
+	return GetVirtualNetworkLinkWithContextE(ctx, id.ResourceGroup, id.Names[0], id.Names[1], id.SubscriptionID)
+}
+
+/********************************
+		RouteTable by ID
+*********************************/
+
+// GetRouteTableByID is the same as GetRouteTableByIDE but fails the test on error
+func GetRouteTableByID(t *testing.T, resourceID string) *network.RouteTable {
+	t.Helper()
+	routeTable, err := GetRouteTableByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/routeTables", resourceID)
+	return routeTable
+}
+
+// GetRouteTableByIDE will return network.RouteTable object from its resource ID and an error object
+func GetRouteTableByIDE(resourceID string) (*network.RouteTable, error) {
+	return GetRouteTableByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetRouteTableByIDWithContextE will return network.RouteTable object from its resource ID and an error object
+func GetRouteTableByIDWithContextE(ctx context.Context, resourceID string) (*network.RouteTable, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/routeTables")
+	if err != nil {
+		return nil, err
+	}
+	return GetRouteTableWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		SecurityGroup by ID
+*********************************/
+
+// GetSecurityGroupByID is the same as GetSecurityGroupByIDE but fails the test on error
+func GetSecurityGroupByID(t *testing.T, resourceID string) *network.SecurityGroup {
+	t.Helper()
+	securityGroup, err := GetSecurityGroupByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/networkSecurityGroups", resourceID)
+	return securityGroup
+}
+
+// GetSecurityGroupByIDE will return network.SecurityGroup object from its resource ID and an error object
+func GetSecurityGroupByIDE(resourceID string) (*network.SecurityGroup, error) {
+	return GetSecurityGroupByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetSecurityGroupByIDWithContextE will return network.SecurityGroup object from its resource ID and an error object
+func GetSecurityGroupByIDWithContextE(ctx context.Context, resourceID string) (*network.SecurityGroup, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/networkSecurityGroups")
+	if err != nil {
+		return nil, err
+	}
+	return GetSecurityGroupWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		Registry by ID
+*********************************/
+
+// GetRegistryByID is the same as GetRegistryByIDE but fails the test on error
+func GetRegistryByID(t *testing.T, resourceID string) *containerregistry.Registry {
+	t.Helper()
+	registry, err := GetRegistryByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.ContainerRegistry/registries", resourceID)
+	return registry
+}
+
+// GetRegistryByIDE will return containerregistry.Registry object from its resource ID and an error object
+func GetRegistryByIDE(resourceID string) (*containerregistry.Registry, error) {
+	return GetRegistryByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetRegistryByIDWithContextE will return containerregistry.Registry object from its resource ID and an error object
+func GetRegistryByIDWithContextE(ctx context.Context, resourceID string) (*containerregistry.Registry, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.ContainerRegistry/registries")
+	if err != nil {
+		return nil, err
+	}
+	return GetRegistryWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		BastionHost by ID
+*********************************/
+
+// GetBastionHostByID is the same as GetBastionHostByIDE but fails the test on error
+func GetBastionHostByID(t *testing.T, resourceID string) *network.BastionHost {
+	t.Helper()
+	bastionHost, err := GetBastionHostByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/bastionHosts", resourceID)
+	return bastionHost
+}
+
+// GetBastionHostByIDE will return network.BastionHost object from its resource ID and an error object
+func GetBastionHostByIDE(resourceID string) (*network.BastionHost, error) {
+	return GetBastionHostByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetBastionHostByIDWithContextE will return network.BastionHost object from its resource ID and an error object
+func GetBastionHostByIDWithContextE(ctx context.Context, resourceID string) (*network.BastionHost, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/bastionHosts")
+	if err != nil {
+		return nil, err
+	}
+	return GetBastionHostWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		ApplicationGateway by ID
+*********************************/
+
+// GetApplicationGatewayByID is the same as GetApplicationGatewayByIDE but fails the test on error
+func GetApplicationGatewayByID(t *testing.T, resourceID string) *network.ApplicationGateway {
+	t.Helper()
+	applicationGateway, err := GetApplicationGatewayByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/applicationGateways", resourceID)
+	return applicationGateway
+}
+
+// GetApplicationGatewayByIDE will return network.ApplicationGateway object from its resource ID and an error object
+func GetApplicationGatewayByIDE(resourceID string) (*network.ApplicationGateway, error) {
+	return GetApplicationGatewayByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetApplicationGatewayByIDWithContextE will return network.ApplicationGateway object from its resource ID and an error object
+func GetApplicationGatewayByIDWithContextE(ctx context.Context, resourceID string) (*network.ApplicationGateway, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/applicationGateways")
+	if err != nil {
+		return nil, err
+	}
+	return GetApplicationGatewayWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		PublicIPAddress by ID
+*********************************/
+
+// GetPublicIPAddressByID is the same as GetPublicIPAddressByIDE but fails the test on error
+func GetPublicIPAddressByID(t *testing.T, resourceID string) *network.PublicIPAddress {
+	t.Helper()
+	publicIPAddress, err := GetPublicIPAddressByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/publicIPAddresses", resourceID)
+	return publicIPAddress
+}
+
+// GetPublicIPAddressByIDE will return network.PublicIPAddress object from its resource ID and an error object
+func GetPublicIPAddressByIDE(resourceID string) (*network.PublicIPAddress, error) {
+	return GetPublicIPAddressByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetPublicIPAddressByIDWithContextE will return network.PublicIPAddress object from its resource ID and an error object
+func GetPublicIPAddressByIDWithContextE(ctx context.Context, resourceID string) (*network.PublicIPAddress, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/publicIPAddresses")
+	if err != nil {
+		return nil, err
+	}
+	return GetPublicIPAddressWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		UserAssignedIdentity by ID
+*********************************/
+
+// GetUserAssignedIdentityByID is the same as GetUserAssignedIdentityByIDE but fails the test on error
+func GetUserAssignedIdentityByID(t *testing.T, resourceID string) *msi.Identity {
+	t.Helper()
+	userAssignedIdentity, err := GetUserAssignedIdentityByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.ManagedIdentity/userAssignedIdentities", resourceID)
+	return userAssignedIdentity
+}
+
+// GetUserAssignedIdentityByIDE will return msi.Identity object from its resource ID and an error object
+func GetUserAssignedIdentityByIDE(resourceID string) (*msi.Identity, error) {
+	return GetUserAssignedIdentityByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetUserAssignedIdentityByIDWithContextE will return msi.Identity object from its resource ID and an error object
+func GetUserAssignedIdentityByIDWithContextE(ctx context.Context, resourceID string) (*msi.Identity, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.ManagedIdentity/userAssignedIdentities")
+	if err != nil {
+		return nil, err
+	}
+	return GetUserAssignedIdentityWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		ManagedCluster by ID
+*********************************/
+
+// GetManagedClusterByID is the same as GetManagedClusterByIDE but fails the test on error
+func GetManagedClusterByID(t *testing.T, resourceID string) *containerservice.ManagedCluster {
+	t.Helper()
+	managedCluster, err := GetManagedClusterByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.ContainerService/managedClusters", resourceID)
+	return managedCluster
+}
+
+// GetManagedClusterByIDE will return containerservice.ManagedCluster object from its resource ID and an error object
+func GetManagedClusterByIDE(resourceID string) (*containerservice.ManagedCluster, error) {
+	return GetManagedClusterByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetManagedClusterByIDWithContextE will return containerservice.ManagedCluster object from its resource ID and an error object
+func GetManagedClusterByIDWithContextE(ctx context.Context, resourceID string) (*containerservice.ManagedCluster, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.ContainerService/managedClusters")
+	if err != nil {
+		return nil, err
+	}
+	return GetManagedClusterWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		AvailabilitySet by ID
+*********************************/
+
+// GetAvailabilitySetByID is the same as GetAvailabilitySetByIDE but fails the test on error
+func GetAvailabilitySetByID(t *testing.T, resourceID string) *compute.AvailabilitySet {
+	t.Helper()
+	availabilitySet, err := GetAvailabilitySetByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Compute/availabilitySets", resourceID)
+	return availabilitySet
+}
+
+// GetAvailabilitySetByIDE will return compute.AvailabilitySet object from its resource ID and an error object
+func GetAvailabilitySetByIDE(resourceID string) (*compute.AvailabilitySet, error) {
+	return GetAvailabilitySetByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetAvailabilitySetByIDWithContextE will return compute.AvailabilitySet object from its resource ID and an error object
+func GetAvailabilitySetByIDWithContextE(ctx context.Context, resourceID string) (*compute.AvailabilitySet, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Compute/availabilitySets")
+	if err != nil {
+		return nil, err
+	}
+	return GetAvailabilitySetWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		ManagedInstance by ID
+*********************************/
+
+// GetManagedInstanceByID is the same as GetManagedInstanceByIDE but fails the test on error
+func GetManagedInstanceByID(t *testing.T, resourceID string) *sqlmi.ManagedInstance {
+	t.Helper()
+	managedInstance, err := GetManagedInstanceByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Sql/managedInstances", resourceID)
+	return managedInstance
+}
+
+// GetManagedInstanceByIDE will return sqlmi.ManagedInstance object from its resource ID and an error object
+func GetManagedInstanceByIDE(resourceID string) (*sqlmi.ManagedInstance, error) {
+	return GetManagedInstanceByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetManagedInstanceByIDWithContextE will return sqlmi.ManagedInstance object from its resource ID and an error object
+func GetManagedInstanceByIDWithContextE(ctx context.Context, resourceID string) (*sqlmi.ManagedInstance, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Sql/managedInstances")
+	if err != nil {
+		return nil, err
+	}
+	return GetManagedInstanceWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		LogAnalyticsWorkspace by ID
+*********************************/
+
+// GetLogAnalyticsWorkspaceByID is the same as GetLogAnalyticsWorkspaceByIDE but fails the test on error
+func GetLogAnalyticsWorkspaceByID(t *testing.T, resourceID string) *operationalinsights.Workspace {
+	t.Helper()
+	logAnalyticsWorkspace, err := GetLogAnalyticsWorkspaceByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.OperationalInsights/workspaces", resourceID)
+	return logAnalyticsWorkspace
+}
+
+// GetLogAnalyticsWorkspaceByIDE will return operationalinsights.Workspace object from its resource ID and an error object
+func GetLogAnalyticsWorkspaceByIDE(resourceID string) (*operationalinsights.Workspace, error) {
+	return GetLogAnalyticsWorkspaceByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetLogAnalyticsWorkspaceByIDWithContextE will return operationalinsights.Workspace object from its resource ID and an error object
+func GetLogAnalyticsWorkspaceByIDWithContextE(ctx context.Context, resourceID string) (*operationalinsights.Workspace, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.OperationalInsights/workspaces")
+	if err != nil {
+		return nil, err
+	}
+	return GetLogAnalyticsWorkspaceWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		Solution by ID
+*********************************/
+
+// GetSolutionByID is the same as GetSolutionByIDE but fails the test on error
+func GetSolutionByID(t *testing.T, resourceID string) *operationsmanagement.Solution {
+	t.Helper()
+	solution, err := GetSolutionByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.OperationsManagement/solutions", resourceID)
+	return solution
+}
+
+// GetSolutionByIDE will return operationsmanagement.Solution object from its resource ID and an error object
+func GetSolutionByIDE(resourceID string) (*operationsmanagement.Solution, error) {
+	return GetSolutionByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetSolutionByIDWithContextE will return operationsmanagement.Solution object from its resource ID and an error object
+func GetSolutionByIDWithContextE(ctx context.Context, resourceID string) (*operationsmanagement.Solution, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.OperationsManagement/solutions")
+	if err != nil {
+		return nil, err
+	}
+	return GetSolutionWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		Firewall by ID
+*********************************/
+
+// GetFirewallByID is the same as GetFirewallByIDE but fails the test on error
+func GetFirewallByID(t *testing.T, resourceID string) *network.AzureFirewall {
+	t.Helper()
+	firewall, err := GetFirewallByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/azureFirewalls", resourceID)
+	return firewall
+}
+
+// GetFirewallByIDE will return network.AzureFirewall object from its resource ID and an error object
+func GetFirewallByIDE(resourceID string) (*network.AzureFirewall, error) {
+	return GetFirewallByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetFirewallByIDWithContextE will return network.AzureFirewall object from its resource ID and an error object
+func GetFirewallByIDWithContextE(ctx context.Context, resourceID string) (*network.AzureFirewall, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/azureFirewalls")
+	if err != nil {
+		return nil, err
+	}
+	return GetFirewallWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		DdosProtectionPlan by ID
+*********************************/
+
+// GetDdosProtectionPlanByID is the same as GetDdosProtectionPlanByIDE but fails the test on error
+func GetDdosProtectionPlanByID(t *testing.T, resourceID string) *network.DdosProtectionPlan {
+	t.Helper()
+	ddosProtectionPlan, err := GetDdosProtectionPlanByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/ddosProtectionPlans", resourceID)
+	return ddosProtectionPlan
+}
+
+// GetDdosProtectionPlanByIDE will return network.DdosProtectionPlan object from its resource ID and an error object
+func GetDdosProtectionPlanByIDE(resourceID string) (*network.DdosProtectionPlan, error) {
+	return GetDdosProtectionPlanByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetDdosProtectionPlanByIDWithContextE will return network.DdosProtectionPlan object from its resource ID and an error object
+func GetDdosProtectionPlanByIDWithContextE(ctx context.Context, resourceID string) (*network.DdosProtectionPlan, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/ddosProtectionPlans")
+	if err != nil {
+		return nil, err
+	}
+	return GetDdosProtectionPlanWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		RecoveryServicesVault by ID
+*********************************/
+
+// GetRecoveryServicesVaultByID is the same as GetRecoveryServicesVaultByIDE but fails the test on error
+func GetRecoveryServicesVaultByID(t *testing.T, resourceID string) *recoveryservices.Vault {
+	t.Helper()
+	recoveryServicesVault, err := GetRecoveryServicesVaultByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.RecoveryServices/vaults", resourceID)
+	return recoveryServicesVault
+}
+
+// GetRecoveryServicesVaultByIDE will return recoveryservices.Vault object from its resource ID and an error object
+func GetRecoveryServicesVaultByIDE(resourceID string) (*recoveryservices.Vault, error) {
+	return GetRecoveryServicesVaultByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetRecoveryServicesVaultByIDWithContextE will return recoveryservices.Vault object from its resource ID and an error object
+func GetRecoveryServicesVaultByIDWithContextE(ctx context.Context, resourceID string) (*recoveryservices.Vault, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.RecoveryServices/vaults")
+	if err != nil {
+		return nil, err
+	}
+	return GetRecoveryServicesVaultWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		ProtectedItem by ID
+*********************************/
+
+// GetProtectedItemByID is the same as GetProtectedItemByIDE but fails the test on error
+func GetProtectedItemByID(t *testing.T, resourceID string) *backup.ProtectedItemResource {
+	t.Helper()
+	protectedItem, err := GetProtectedItemByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.RecoveryServices/vaults/backupFabrics/protectionContainers/protectedItems", resourceID)
+	return protectedItem
+}
+
+// GetProtectedItemByIDE will return backup.ProtectedItemResource object from its resource ID and an error object
+func GetProtectedItemByIDE(resourceID string) (*backup.ProtectedItemResource, error) {
+	return GetProtectedItemByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetProtectedItemByIDWithContextE will return backup.ProtectedItemResource object from its resource ID and an error object
+func GetProtectedItemByIDWithContextE(ctx context.Context, resourceID string) (*backup.ProtectedItemResource, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.RecoveryServices/vaults/backupFabrics/protectionContainers/protectedItems")
+	if err != nil {
+		return nil, err
+	}
+	return GetProtectedItemWithContextE(ctx, id.ResourceGroup, id.Names[0], id.Names[1], id.Names[2], id.Names[3], id.SubscriptionID)
+}
+
+/********************************
+		Redis by ID
+*********************************/
+
+// GetRedisByID is the same as GetRedisByIDE but fails the test on error
+func GetRedisByID(t *testing.T, resourceID string) *redis.ResourceType {
+	t.Helper()
+	redis, err := GetRedisByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Cache/redis", resourceID)
+	return redis
+}
+
+// GetRedisByIDE will return redis.ResourceType object from its resource ID and an error object
+func GetRedisByIDE(resourceID string) (*redis.ResourceType, error) {
+	return GetRedisByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetRedisByIDWithContextE will return redis.ResourceType object from its resource ID and an error object
+func GetRedisByIDWithContextE(ctx context.Context, resourceID string) (*redis.ResourceType, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Cache/redis")
+	if err != nil {
+		return nil, err
+	}
+	return GetRedisWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		VirtualNetworkGateway by ID
+*********************************/
+
+// GetVirtualNetworkGatewayByID is the same as GetVirtualNetworkGatewayByIDE but fails the test on error
+func GetVirtualNetworkGatewayByID(t *testing.T, resourceID string) *network.VirtualNetworkGateway {
+	t.Helper()
+	virtualNetworkGateway, err := GetVirtualNetworkGatewayByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/virtualNetworkGateways", resourceID)
+	return virtualNetworkGateway
+}
+
+// GetVirtualNetworkGatewayByIDE will return network.VirtualNetworkGateway object from its resource ID and an error object
+func GetVirtualNetworkGatewayByIDE(resourceID string) (*network.VirtualNetworkGateway, error) {
+	return GetVirtualNetworkGatewayByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetVirtualNetworkGatewayByIDWithContextE will return network.VirtualNetworkGateway object from its resource ID and an error object
+func GetVirtualNetworkGatewayByIDWithContextE(ctx context.Context, resourceID string) (*network.VirtualNetworkGateway, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/virtualNetworkGateways")
+	if err != nil {
+		return nil, err
+	}
+	return GetVirtualNetworkGatewayWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		MySQLServer by ID
+*********************************/
+
+// GetMySQLServerByID is the same as GetMySQLServerByIDE but fails the test on error
+func GetMySQLServerByID(t *testing.T, resourceID string) *mysql.Server {
+	t.Helper()
+	mySQLServer, err := GetMySQLServerByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.DBforMySQL/servers", resourceID)
+	return mySQLServer
+}
+
+// GetMySQLServerByIDE will return mysql.Server object from its resource ID and an error object
+func GetMySQLServerByIDE(resourceID string) (*mysql.Server, error) {
+	return GetMySQLServerByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetMySQLServerByIDWithContextE will return mysql.Server object from its resource ID and an error object
+func GetMySQLServerByIDWithContextE(ctx context.Context, resourceID string) (*mysql.Server, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.DBforMySQL/servers")
+	if err != nil {
+		return nil, err
+	}
+	return GetMySQLServerWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		MySQLDatabase by ID
+*********************************/
+
+// GetMySQLDatabaseByID is the same as GetMySQLDatabaseByIDE but fails the test on error
+func GetMySQLDatabaseByID(t *testing.T, resourceID string) *mysql.Database {
+	t.Helper()
+	mySQLDatabase, err := GetMySQLDatabaseByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.DBforMySQL/servers/databases", resourceID)
+	return mySQLDatabase
+}
+
+// GetMySQLDatabaseByIDE will return mysql.Database object from its resource ID and an error object
+func GetMySQLDatabaseByIDE(resourceID string) (*mysql.Database, error) {
+	return GetMySQLDatabaseByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetMySQLDatabaseByIDWithContextE will return mysql.Database object from its resource ID and an error object
+func GetMySQLDatabaseByIDWithContextE(ctx context.Context, resourceID string) (*mysql.Database, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.DBforMySQL/servers/databases")
+	if err != nil {
+		return nil, err
+	}
+	return GetMySQLDatabaseWithContextE(ctx, id.ResourceGroup, id.Names[0], id.Names[1], id.SubscriptionID)
+}
+
+/********************************
+		CosmosDatabaseAccount by ID
+*********************************/
+
+// GetCosmosDatabaseAccountByID is the same as GetCosmosDatabaseAccountByIDE but fails the test on error
+func GetCosmosDatabaseAccountByID(t *testing.T, resourceID string) *documentdb.DatabaseAccountGetResults {
+	t.Helper()
+	cosmosDatabaseAccount, err := GetCosmosDatabaseAccountByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.DocumentDB/databaseAccounts", resourceID)
+	return cosmosDatabaseAccount
+}
+
+// GetCosmosDatabaseAccountByIDE will return documentdb.DatabaseAccountGetResults object from its resource ID and an error object
+func GetCosmosDatabaseAccountByIDE(resourceID string) (*documentdb.DatabaseAccountGetResults, error) {
+	return GetCosmosDatabaseAccountByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetCosmosDatabaseAccountByIDWithContextE will return documentdb.DatabaseAccountGetResults object from its resource ID and an error object
+func GetCosmosDatabaseAccountByIDWithContextE(ctx context.Context, resourceID string) (*documentdb.DatabaseAccountGetResults, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.DocumentDB/databaseAccounts")
+	if err != nil {
+		return nil, err
+	}
+	return GetCosmosDatabaseAccountWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		CassandraKeySpace by ID
+*********************************/
+
+// GetCassandraKeySpaceByID is the same as GetCassandraKeySpaceByIDE but fails the test on error
+func GetCassandraKeySpaceByID(t *testing.T, resourceID string) *documentdb.CassandraKeyspaceGetResults {
+	t.Helper()
+	cassandraKeySpace, err := GetCassandraKeySpaceByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.DocumentDB/databaseAccounts/cassandraKeyspaces", resourceID)
+	return cassandraKeySpace
+}
+
+// GetCassandraKeySpaceByIDE will return documentdb.CassandraKeyspaceGetResults object from its resource ID and an error object
+func GetCassandraKeySpaceByIDE(resourceID string) (*documentdb.CassandraKeyspaceGetResults, error) {
+	return GetCassandraKeySpaceByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetCassandraKeySpaceByIDWithContextE will return documentdb.CassandraKeyspaceGetResults object from its resource ID and an error object
+func GetCassandraKeySpaceByIDWithContextE(ctx context.Context, resourceID string) (*documentdb.CassandraKeyspaceGetResults, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.DocumentDB/databaseAccounts/cassandraKeyspaces")
+	if err != nil {
+		return nil, err
+	}
+	return GetCassandraKeySpaceWithContextE(ctx, id.ResourceGroup, id.Names[0], id.Names[1], id.SubscriptionID)
+}
+
+/********************************
+		EventHubNamespace by ID
+*********************************/
+
+// GetEventHubNamespaceByID is the same as GetEventHubNamespaceByIDE but fails the test on error
+func GetEventHubNamespaceByID(t *testing.T, resourceID string) *eventhub.EHNamespace {
+	t.Helper()
+	eventHubNamespace, err := GetEventHubNamespaceByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.EventHub/namespaces", resourceID)
+	return eventHubNamespace
+}
+
+// GetEventHubNamespaceByIDE will return eventhub.EHNamespace object from its resource ID and an error object
+func GetEventHubNamespaceByIDE(resourceID string) (*eventhub.EHNamespace, error) {
+	return GetEventHubNamespaceByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetEventHubNamespaceByIDWithContextE will return eventhub.EHNamespace object from its resource ID and an error object
+func GetEventHubNamespaceByIDWithContextE(ctx context.Context, resourceID string) (*eventhub.EHNamespace, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.EventHub/namespaces")
+	if err != nil {
+		return nil, err
+	}
+	return GetEventHubNamespaceWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		EventHub by ID
+*********************************/
+
+// GetEventHubByID is the same as GetEventHubByIDE but fails the test on error
+func GetEventHubByID(t *testing.T, resourceID string) *eventhub.Model {
+	t.Helper()
+	eventHub, err := GetEventHubByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.EventHub/namespaces/eventhubs", resourceID)
+	return eventHub
+}
+
+// GetEventHubByIDE will return eventhub.Model object from its resource ID and an error object
+func GetEventHubByIDE(resourceID string) (*eventhub.Model, error) {
+	return GetEventHubByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetEventHubByIDWithContextE will return eventhub.Model object from its resource ID and an error object
+func GetEventHubByIDWithContextE(ctx context.Context, resourceID string) (*eventhub.Model, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.EventHub/namespaces/eventhubs")
+	if err != nil {
+		return nil, err
+	}
+	return GetEventHubWithContextE(ctx, id.ResourceGroup, id.Names[0], id.Names[1], id.SubscriptionID)
+}
+
+/********************************
+		AppServicePlan by ID
+*********************************/
+
+// GetAppServicePlanByID is the same as GetAppServicePlanByIDE but fails the test on error
+func GetAppServicePlanByID(t *testing.T, resourceID string) *web.AppServicePlan {
+	t.Helper()
+	appServicePlan, err := GetAppServicePlanByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Web/serverfarms", resourceID)
+	return appServicePlan
+}
+
+// GetAppServicePlanByIDE will return web.AppServicePlan object from its resource ID and an error object
+func GetAppServicePlanByIDE(resourceID string) (*web.AppServicePlan, error) {
+	return GetAppServicePlanByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetAppServicePlanByIDWithContextE will return web.AppServicePlan object from its resource ID and an error object
+func GetAppServicePlanByIDWithContextE(ctx context.Context, resourceID string) (*web.AppServicePlan, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Web/serverfarms")
+	if err != nil {
+		return nil, err
+	}
+	return GetAppServicePlanWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		Site by ID
+*********************************/
+
+// GetSiteByID is the same as GetSiteByIDE but fails the test on error
+func GetSiteByID(t *testing.T, resourceID string) *web.Site {
+	t.Helper()
+	site, err := GetSiteByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Web/sites", resourceID)
+	return site
+}
+
+// GetSiteByIDE will return web.Site object from its resource ID and an error object
+func GetSiteByIDE(resourceID string) (*web.Site, error) {
+	return GetSiteByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetSiteByIDWithContextE will return web.Site object from its resource ID and an error object
+func GetSiteByIDWithContextE(ctx context.Context, resourceID string) (*web.Site, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Web/sites")
+	if err != nil {
+		return nil, err
+	}
+	return GetSiteWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		Function by ID
+*********************************/
+
+// GetFunctionByID is the same as GetFunctionByIDE but fails the test on error
+func GetFunctionByID(t *testing.T, resourceID string) *web.FunctionEnvelope {
+	t.Helper()
+	function, err := GetFunctionByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Web/sites/functions", resourceID)
+	return function
+}
+
+// GetFunctionByIDE will return web.FunctionEnvelope object from its resource ID and an error object
+func GetFunctionByIDE(resourceID string) (*web.FunctionEnvelope, error) {
+	return GetFunctionByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetFunctionByIDWithContextE will return web.FunctionEnvelope object from its resource ID and an error object
+func GetFunctionByIDWithContextE(ctx context.Context, resourceID string) (*web.FunctionEnvelope, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Web/sites/functions")
+	if err != nil {
+		return nil, err
+	}
+	return GetFunctionWithContextE(ctx, id.ResourceGroup, id.Names[0], id.Names[1], id.SubscriptionID)
+}
+
+/********************************
+		SQLServer by ID
+*********************************/
+
+// GetSQLServerByID is the same as GetSQLServerByIDE but fails the test on error
+func GetSQLServerByID(t *testing.T, resourceID string) *sql.Server {
+	t.Helper()
+	sqlServer, err := GetSQLServerByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Sql/servers", resourceID)
+	return sqlServer
+}
+
+// GetSQLServerByIDE will return sql.Server object from its resource ID and an error object
+func GetSQLServerByIDE(resourceID string) (*sql.Server, error) {
+	return GetSQLServerByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetSQLServerByIDWithContextE will return sql.Server object from its resource ID and an error object
+func GetSQLServerByIDWithContextE(ctx context.Context, resourceID string) (*sql.Server, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Sql/servers")
+	if err != nil {
+		return nil, err
+	}
+	return GetSQLServerWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		VMScaleSet by ID
+*********************************/
+
+// GetVMScaleSetByID is the same as GetVMScaleSetByIDE but fails the test on error
+func GetVMScaleSetByID(t *testing.T, resourceID string) *compute.VirtualMachineScaleSet {
+	t.Helper()
+	vmScaleSet, err := GetVMScaleSetByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Compute/virtualMachineScaleSets", resourceID)
+	return vmScaleSet
+}
+
+// GetVMScaleSetByIDE will return compute.VirtualMachineScaleSet object from its resource ID and an error object
+func GetVMScaleSetByIDE(resourceID string) (*compute.VirtualMachineScaleSet, error) {
+	return GetVMScaleSetByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetVMScaleSetByIDWithContextE will return compute.VirtualMachineScaleSet object from its resource ID and an error object
+func GetVMScaleSetByIDWithContextE(ctx context.Context, resourceID string) (*compute.VirtualMachineScaleSet, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Compute/virtualMachineScaleSets")
+	if err != nil {
+		return nil, err
+	}
+	return GetVMScaleSetWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
+
+/********************************
+		FrontDoor by ID
+*********************************/
+
+// GetFrontDoorByID is the same as GetFrontDoorByIDE but fails the test on error
+func GetFrontDoorByID(t *testing.T, resourceID string) *frontdoor.FrontDoor {
+	t.Helper()
+	frontDoor, err := GetFrontDoorByIDE(resourceID)
+	failOnAzureErrorByID(t, err, "Microsoft.Network/frontDoors", resourceID)
+	return frontDoor
+}
+
+// GetFrontDoorByIDE will return frontdoor.FrontDoor object from its resource ID and an error object
+func GetFrontDoorByIDE(resourceID string) (*frontdoor.FrontDoor, error) {
+	return GetFrontDoorByIDWithContextE(context.Background(), resourceID)
+}
+
+// GetFrontDoorByIDWithContextE will return frontdoor.FrontDoor object from its resource ID and an error object
+func GetFrontDoorByIDWithContextE(ctx context.Context, resourceID string) (*frontdoor.FrontDoor, error) {
+	id, err := ParseResourceIDOfTypeE(resourceID, "Microsoft.Network/frontDoors")
+	if err != nil {
+		return nil, err
+	}
+	return GetFrontDoorWithContextE(ctx, id.ResourceGroup, id.Names[0], id.SubscriptionID)
+}
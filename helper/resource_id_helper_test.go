@@ -0,0 +1,73 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResourceIDE(t *testing.T) {
+	vnetID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"
+	cases := []struct {
+		name          string
+		resourceID    string
+		resourceType  string
+		resourceName  string
+		resourceGroup string
+		scope         string
+	}{
+		{"subscription", "/subscriptions/sub", "Microsoft.Resources/subscriptions", "sub", "", ""},
+		{"resource group", "/subscriptions/sub/resourceGroups/rg", "Microsoft.Resources/resourceGroups", "rg", "rg", ""},
+		{"resource", vnetID, "Microsoft.Network/virtualNetworks", "vnet", "rg", ""},
+		{"child resource", vnetID + "/subnets/snet", "Microsoft.Network/virtualNetworks/subnets", "snet", "rg", ""},
+		{"resource group lock", "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Authorization/locks/lock", "Microsoft.Authorization/locks", "lock", "rg", ""},
+		{"subscription role assignment", "/subscriptions/sub/providers/Microsoft.Authorization/roleAssignments/ra", "Microsoft.Authorization/roleAssignments", "ra", "", ""},
+		{"resource lock", vnetID + "/providers/Microsoft.Authorization/locks/lock", "Microsoft.Authorization/locks", "lock", "rg", vnetID},
+		{"child resource diagnostic settings", vnetID + "/subnets/snet/providers/microsoft.insights/diagnosticSettings/diag",
+			"microsoft.insights/diagnosticSettings", "diag", "rg", vnetID + "/subnets/snet"},
+		{"trailing slash", vnetID + "/", "Microsoft.Network/virtualNetworks", "vnet", "rg", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			id, err := ParseResourceIDE(c.resourceID)
+			require.NoError(t, err)
+			assert.Equal(t, "sub", id.SubscriptionID)
+			assert.Equal(t, c.resourceGroup, id.ResourceGroup)
+			assert.Equal(t, c.resourceType, id.ResourceType())
+			assert.Equal(t, c.resourceName, id.Name())
+			assert.Equal(t, strings.TrimSuffix(c.resourceID, "/"), id.String())
+			if c.scope == "" {
+				assert.Nil(t, id.Scope)
+			} else if assert.NotNil(t, id.Scope) {
+				assert.Equal(t, c.scope, id.Scope.String())
+			}
+		})
+	}
+}
+
+func TestParseResourceIDEInvalid(t *testing.T) {
+	for _, resourceID := range []string{
+		"",
+		"/resourceGroups/rg",
+		"/subscriptions//resourceGroups/rg",
+		"/subscriptions/sub/resourceGroups/rg/virtualNetworks/vnet",
+		"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network",
+		"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks",
+		"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets",
+		"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/providers/Microsoft.Authorization",
+	} {
+		_, err := ParseResourceIDE(resourceID)
+		assert.Error(t, err, resourceID)
+	}
+}
+
+func TestParseResourceIDOfTypeE(t *testing.T) {
+	lockID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/providers/Microsoft.Authorization/locks/lock"
+	_, err := ParseResourceIDOfTypeE(lockID, "Microsoft.Network/virtualNetworks")
+	assert.Error(t, err)
+	id, err := ParseResourceIDOfTypeE(lockID, "microsoft.authorization/locks")
+	require.NoError(t, err)
+	assert.Equal(t, "Microsoft.Network/virtualNetworks", id.Scope.ResourceType())
+}
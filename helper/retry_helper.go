@@ -0,0 +1,208 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+const (
+	// RetryAttemptsEnvName maximum number of retries for a throttled or failed ARM call
+	RetryAttemptsEnvName = "ARM_RETRY_ATTEMPTS"
+	// RetryMaxDelayEnvName maximum time to wait before a single retry, e.g. 30s
+	RetryMaxDelayEnvName = "ARM_RETRY_MAX_DELAY"
+)
+
+// RetryPolicy controls how calls made by the helper clients are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+	// BaseDelay is the delay before the first retry, doubled on every retry
+	BaseDelay time.Duration
+	// MaxDelay caps a single delay, including the one asked by a Retry-After header
+	MaxDelay time.Duration
+	// StatusCodes are the HTTP status codes that are retried
+	StatusCodes []int
+}
+
+// DefaultRetryPolicy returns the policy used by the helper clients.
+// ARM_RETRY_ATTEMPTS and ARM_RETRY_MAX_DELAY override the number of retries and the delay cap.
+func DefaultRetryPolicy() RetryPolicy {
+	policy := RetryPolicy{
+		MaxRetries: 6,
+		BaseDelay:  2 * time.Second,
+		MaxDelay:   60 * time.Second,
+		StatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+	if value := os.Getenv(RetryAttemptsEnvName); value != "" {
+		if attempts, err := strconv.Atoi(value); err == nil && attempts >= 0 {
+			policy.MaxRetries = attempts
+		} else {
+			log.Printf("Warning: invalid %s %s, using %d\n", RetryAttemptsEnvName, value, policy.MaxRetries)
+		}
+	}
+	if value := os.Getenv(RetryMaxDelayEnvName); value != "" {
+		if delay, err := time.ParseDuration(value); err == nil && delay > 0 {
+			policy.MaxDelay = delay
+		} else {
+			log.Printf("Warning: invalid %s %s, using %s\n", RetryMaxDelayEnvName, value, policy.MaxDelay)
+		}
+	}
+	return policy
+}
+
+func (p RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		// transport errors are retried, unless the call was cancelled
+		return true
+	}
+	for _, code := range p.StatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns the time to wait before retry number attempt (starting at 0)
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if wait, ok := retryAfter(resp); ok {
+		if wait > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return wait
+	}
+	backoff := p.BaseDelay << uint(attempt)
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	// random wait in the upper half of the backoff so parallel tests don't retry in lockstep
+	half := int64(backoff / 2)
+	if half <= 0 {
+		return backoff
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// retryAfter reads the Retry-After header, either in seconds or as an HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
+
+// retrySender is an autorest.Sender that retries throttled and transient failures
+type retrySender struct {
+	policy RetryPolicy
+	next   autorest.Sender
+}
+
+// Do implements autorest.Sender
+func (s *retrySender) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.GetBody == nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.Body, _ = req.GetBody()
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := s.next.Do(req)
+		if attempt >= s.policy.MaxRetries || req.Context().Err() != nil || !s.policy.shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		wait := s.policy.delay(attempt, resp)
+		reason := fmt.Sprintf("%v", err)
+		if err == nil {
+			reason = resp.Status
+			// drain the body so the connection can be reused
+			ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		log.Printf("%s %s failed (%s), retry %d/%d in %s\n", req.Method, req.URL.Path, reason, attempt+1, s.policy.MaxRetries, wait)
+		recordRetry(req.Context())
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+/********************************
+		Retry statistics
+*********************************/
+
+// totalRetries counts every retry done by the helper clients
+var totalRetries int64
+
+// retryCounterKey is the context key of the retry counter of a test
+type retryCounterKey struct{}
+
+// GetRetryCount returns the number of retries done by the helper clients since the start of the test run
+func GetRetryCount() int64 {
+	return atomic.LoadInt64(&totalRetries)
+}
+
+// withRetryCounter returns a context that counts the retries of the calls made with it
+// and logs the total when the test finishes
+func withRetryCounter(ctx context.Context, t *testing.T) context.Context {
+	var retries int64
+	t.Cleanup(func() {
+		if count := atomic.LoadInt64(&retries); count > 0 {
+			t.Logf("%d call(s) to Azure were retried", count)
+		}
+	})
+	return context.WithValue(ctx, retryCounterKey{}, &retries)
+}
+
+func recordRetry(ctx context.Context) {
+	atomic.AddInt64(&totalRetries, 1)
+	if retries, ok := ctx.Value(retryCounterKey{}).(*int64); ok {
+		atomic.AddInt64(retries, 1)
+	}
+}
@@ -0,0 +1,173 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		ok     bool
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"missing", "", false, 0, 0},
+		{"seconds", "12", true, 12 * time.Second, 12 * time.Second},
+		{"zero", "0", true, 0, 0},
+		{"http date", time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat), true, 28 * time.Second, 30 * time.Second},
+		{"invalid", "soon", false, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if c.header != "" {
+				resp.Header.Set("Retry-After", c.header)
+			}
+			wait, ok := retryAfter(resp)
+			assert.Equal(t, c.ok, ok)
+			assert.True(t, wait >= c.min && wait <= c.max, "wait %s not in [%s, %s]", wait, c.min, c.max)
+		})
+	}
+	_, ok := retryAfter(nil)
+	assert.False(t, ok)
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	for attempt := 0; attempt < 70; attempt++ {
+		backoff := time.Second << uint(attempt)
+		if attempt >= 4 {
+			backoff = policy.MaxDelay
+		}
+		delay := policy.delay(attempt, nil)
+		assert.True(t, delay >= backoff/2 && delay <= backoff, "attempt %d: delay %s not in [%s, %s]", attempt, delay, backoff/2, backoff)
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	assert.Equal(t, 3*time.Second, policy.delay(5, resp))
+	resp.Header.Set("Retry-After", "3600")
+	assert.Equal(t, policy.MaxDelay, policy.delay(0, resp))
+}
+
+func TestDefaultRetryPolicyEnvironment(t *testing.T) {
+	t.Setenv(RetryAttemptsEnvName, "")
+	t.Setenv(RetryMaxDelayEnvName, "")
+	policy := DefaultRetryPolicy()
+	assert.Equal(t, 6, policy.MaxRetries)
+	assert.Equal(t, 60*time.Second, policy.MaxDelay)
+
+	t.Setenv(RetryAttemptsEnvName, "2")
+	t.Setenv(RetryMaxDelayEnvName, "5s")
+	policy = DefaultRetryPolicy()
+	assert.Equal(t, 2, policy.MaxRetries)
+	assert.Equal(t, 5*time.Second, policy.MaxDelay)
+
+	t.Setenv(RetryAttemptsEnvName, "0")
+	assert.Equal(t, 0, DefaultRetryPolicy().MaxRetries)
+
+	t.Setenv(RetryAttemptsEnvName, "-1")
+	t.Setenv(RetryMaxDelayEnvName, "forever")
+	policy = DefaultRetryPolicy()
+	assert.Equal(t, 6, policy.MaxRetries)
+	assert.Equal(t, 60*time.Second, policy.MaxDelay)
+}
+
+// countingSender answers every request with statusCode
+type countingSender struct {
+	statusCode int
+	calls      int
+}
+
+func (s *countingSender) Do(req *http.Request) (*http.Response, error) {
+	s.calls++
+	return &http.Response{StatusCode: s.statusCode, Status: http.StatusText(s.statusCode), Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+}
+
+func TestRetrySender(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.MaxRetries = 3
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = time.Millisecond
+	cases := []struct {
+		statusCode int
+		calls      int
+	}{
+		{http.StatusOK, 1},
+		{http.StatusBadRequest, 1},
+		{http.StatusNotFound, 1},
+		{http.StatusConflict, 1},
+		{http.StatusTooManyRequests, 4},
+		{http.StatusServiceUnavailable, 4},
+	}
+	for _, c := range cases {
+		t.Run(fmt.Sprint(c.statusCode), func(t *testing.T) {
+			next := &countingSender{statusCode: c.statusCode}
+			req, err := http.NewRequest(http.MethodGet, "https://management.azure.com/subscriptions", nil)
+			require.NoError(t, err)
+			before := GetRetryCount()
+			resp, err := (&retrySender{policy: policy, next: next}).Do(req)
+			require.NoError(t, err)
+			assert.Equal(t, c.statusCode, resp.StatusCode)
+			assert.Equal(t, c.calls, next.calls)
+			assert.Equal(t, int64(c.calls-1), GetRetryCount()-before)
+		})
+	}
+}
+
+// TestFactoryClientRetries checks the SDK clients do not retry on their own on top of the retry policy
+func TestFactoryClientRetries(t *testing.T) {
+	var calls int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	factory := NewClientFactory()
+	env := azure.PublicCloud
+	env.ResourceManagerEndpoint = server.URL + "/"
+	factory.SetEnvironment(env)
+	factory.SetAuthorizer(autorest.NullAuthorizer{})
+	factory.SetRetryPolicy(RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, StatusCodes: []int{http.StatusServiceUnavailable}})
+
+	// the SDK retries quickly so nested retries would show as extra calls
+	for _, create := range []func(string) interface{}{
+		func(subscriptionID string) interface{} {
+			client := resources.NewClient(subscriptionID)
+			client.RetryDuration = time.Millisecond
+			return &client
+		},
+		func(subscriptionID string) interface{} {
+			client := resources.NewGroupsClient(subscriptionID)
+			client.RetryDuration = time.Millisecond
+			return &client
+		},
+	} {
+		atomic.StoreInt64(&calls, 0)
+		client, err := factory.ResourceManagerClientE("sub", create)
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		switch client := client.(type) {
+		case *resources.Client:
+			_, err = client.GetByID(ctx, "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", "2020-04-01")
+		case *resources.GroupsClient:
+			_, err = client.Get(ctx, "rg")
+		}
+		cancel()
+		assert.Error(t, err)
+		assert.Equal(t, int64(3), atomic.LoadInt64(&calls), "%T", client)
+	}
+}
@@ -0,0 +1,235 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+)
+
+/********************************
+		Next hops
+*********************************/
+
+// RouteNextHopTypeVNetPeering is the next hop type of the system routes to the address space of peered virtual networks,
+// it isn't one of the types a user defined route can have
+const RouteNextHopTypeVNetPeering network.RouteNextHopType = "VNetPeering"
+
+const (
+	// RouteSourceUser is the source of the routes of a route table
+	RouteSourceUser = "User"
+	// RouteSourceDefault is the source of the system routes
+	RouteSourceDefault = "Default"
+)
+
+// NextHop is the route chosen for a destination
+type NextHop struct {
+	Type network.RouteNextHopType
+	// IPAddress is the address of the virtual appliance, when Type is VirtualAppliance
+	IPAddress string
+	// AddressPrefix is the prefix of the chosen route
+	AddressPrefix string
+	// RouteName is the name of the user defined route, empty for system routes
+	RouteName string
+	// Source is User or Default
+	Source string
+}
+
+func (h NextHop) String() string {
+	description := string(h.Type)
+	if h.IPAddress != "" {
+		description += " " + h.IPAddress
+	}
+	if h.RouteName != "" {
+		return fmt.Sprintf("%s (route %s for %s)", description, h.RouteName, h.AddressPrefix)
+	}
+	return fmt.Sprintf("%s (%s route for %s)", description, strings.ToLower(h.Source), h.AddressPrefix)
+}
+
+// candidateRoute is a user defined or system route with its parsed prefix
+type candidateRoute struct {
+	prefix *net.IPNet
+	hop    NextHop
+}
+
+// GetNextHop is the same as GetNextHopE but fails the test on error
+func GetNextHop(t *testing.T, resourceGroupName, virtualNetworkName string, subnetName string, destinationIP string, subscriptionID ...string) *NextHop {
+	t.Helper()
+	hop, err := GetNextHopE(resourceGroupName, virtualNetworkName, subnetName, destinationIP, subscriptionID...)
+	failOnAzureError(t, err, "get next hop of", "Microsoft.Network/virtualNetworks/subnets", virtualNetworkName+"/"+subnetName, resourceGroupName)
+	return hop
+}
+
+// GetNextHopE returns the next hop of the traffic sent from a subnet to destinationIP, computed by EvaluateNextHopE
+// from the virtual network, its peerings and the route table of the subnet
+func GetNextHopE(resourceGroupName, virtualNetworkName string, subnetName string, destinationIP string, subscriptionID ...string) (*NextHop, error) {
+	return GetNextHopWithContextE(context.Background(), resourceGroupName, virtualNetworkName, subnetName, destinationIP, subscriptionID...)
+}
+
+// GetNextHopWithContextE returns the next hop of the traffic sent from a subnet to destinationIP, computed by EvaluateNextHopE
+// from the virtual network, its peerings and the route table of the subnet
+func GetNextHopWithContextE(ctx context.Context, resourceGroupName, virtualNetworkName string, subnetName string, destinationIP string, subscriptionID ...string) (*NextHop, error) {
+	virtualNetwork, err := GetVirtualNetworkWithContextE(ctx, resourceGroupName, virtualNetworkName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+	subnet, err := GetSubnetWithContextE(ctx, resourceGroupName, virtualNetworkName, subnetName, subscriptionID...)
+	if err != nil {
+		return nil, err
+	}
+	var routeTable *network.RouteTable
+	if subnet.SubnetPropertiesFormat != nil && subnet.RouteTable != nil && subnet.RouteTable.ID != nil {
+		// the route table can be in another resource group
+		if routeTable, err = GetRouteTableByIDWithContextE(ctx, *subnet.RouteTable.ID); err != nil {
+			return nil, err
+		}
+	}
+	return EvaluateNextHopE(virtualNetwork, routeTable, destinationIP)
+}
+
+// EvaluateNextHopE computes offline the next hop of the traffic sent to destinationIP from a subnet of virtualNetwork
+// using routeTable (nil when the subnet has none). Like Azure, it picks the longest prefix match among the user defined
+// routes and the system routes (virtual network address space, connected peerings, Internet and the None routes of the
+// private ranges), preferring a user defined route when both have the same prefix. Routes learnt from gateways over BGP
+// are not known offline, and user defined routes to service tags are ignored.
+func EvaluateNextHopE(virtualNetwork *network.VirtualNetwork, routeTable *network.RouteTable, destinationIP string) (*NextHop, error) {
+	ip := net.ParseIP(destinationIP)
+	if ip == nil {
+		return nil, fmt.Errorf("Invalid destination IP address %s", destinationIP)
+	}
+	routes, err := systemRoutes(virtualNetwork)
+	if err != nil {
+		return nil, err
+	}
+	userRoutes, err := userDefinedRoutes(routeTable)
+	if err != nil {
+		return nil, err
+	}
+	routes = append(routes, userRoutes...)
+
+	var best *candidateRoute
+	bestLength := -1
+	for i := range routes {
+		route := &routes[i]
+		if !route.prefix.Contains(ip) {
+			continue
+		}
+		length, _ := route.prefix.Mask.Size()
+		if length > bestLength || (length == bestLength && route.hop.Source == RouteSourceUser && best.hop.Source != RouteSourceUser) {
+			best = route
+			bestLength = length
+		}
+	}
+	if best == nil {
+		// not reached, the Internet system routes match every address
+		return &NextHop{Type: network.RouteNextHopTypeNone, Source: RouteSourceDefault}, nil
+	}
+	hop := best.hop
+	return &hop, nil
+}
+
+// AssertNextHop fails the test unless the traffic sent from a subnet to destinationIP goes to a next hop of type nextHopType,
+// and to nextHopIP when it isn't empty, e.g. to check forced tunnelling through a firewall:
+// AssertNextHop(t, rg, "vnet-spoke", "snet-app", "8.8.8.8", network.RouteNextHopTypeVirtualAppliance, firewallPrivateIP)
+func AssertNextHop(t *testing.T, resourceGroupName, virtualNetworkName string, subnetName string, destinationIP string, nextHopType network.RouteNextHopType, nextHopIP string, subscriptionID ...string) {
+	t.Helper()
+	hop := GetNextHop(t, resourceGroupName, virtualNetworkName, subnetName, destinationIP, subscriptionID...)
+	if !strings.EqualFold(string(hop.Type), string(nextHopType)) || (nextHopIP != "" && hop.IPAddress != nextHopIP) {
+		expected := string(nextHopType)
+		if nextHopIP != "" {
+			expected += " " + nextHopIP
+		}
+		t.Errorf("Traffic from subnet %s/%s to %s goes to %s, expected %s", virtualNetworkName, subnetName, destinationIP, hop, expected)
+	}
+}
+
+// systemRoutes returns the routes Azure creates for every subnet of a virtual network
+func systemRoutes(virtualNetwork *network.VirtualNetwork) ([]candidateRoute, error) {
+	routes := make([]candidateRoute, 0)
+	add := func(prefix string, hopType network.RouteNextHopType) error {
+		_, ipNet, err := net.ParseCIDR(prefix)
+		if err != nil {
+			return fmt.Errorf("Invalid address prefix %s: %s", prefix, err)
+		}
+		routes = append(routes, candidateRoute{
+			prefix: ipNet,
+			hop:    NextHop{Type: hopType, AddressPrefix: prefix, Source: RouteSourceDefault},
+		})
+		return nil
+	}
+
+	for _, prefix := range []string{"0.0.0.0/0", "::/0"} {
+		if err := add(prefix, network.RouteNextHopTypeInternet); err != nil {
+			return nil, err
+		}
+	}
+	// traffic to the private ranges outside the virtual network and its peerings is dropped
+	for _, prefix := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10"} {
+		if err := add(prefix, network.RouteNextHopTypeNone); err != nil {
+			return nil, err
+		}
+	}
+
+	if virtualNetwork == nil || virtualNetwork.VirtualNetworkPropertiesFormat == nil {
+		return nil, fmt.Errorf("Virtual network has no properties")
+	}
+	properties := virtualNetwork.VirtualNetworkPropertiesFormat
+	if properties.AddressSpace != nil && properties.AddressSpace.AddressPrefixes != nil {
+		for _, prefix := range *properties.AddressSpace.AddressPrefixes {
+			if err := add(prefix, network.RouteNextHopTypeVnetLocal); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if properties.VirtualNetworkPeerings != nil {
+		for _, peering := range *properties.VirtualNetworkPeerings {
+			peeringProperties := peering.VirtualNetworkPeeringPropertiesFormat
+			if peeringProperties == nil || peeringProperties.RemoteAddressSpace == nil || peeringProperties.RemoteAddressSpace.AddressPrefixes == nil {
+				continue
+			}
+			// routes are only there while the peering is connected
+			if peeringProperties.PeeringState != "" && peeringProperties.PeeringState != network.VirtualNetworkPeeringStateConnected {
+				continue
+			}
+			for _, prefix := range *peeringProperties.RemoteAddressSpace.AddressPrefixes {
+				if err := add(prefix, RouteNextHopTypeVNetPeering); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return routes, nil
+}
+
+// userDefinedRoutes returns the routes of a route table
+func userDefinedRoutes(routeTable *network.RouteTable) ([]candidateRoute, error) {
+	routes := make([]candidateRoute, 0)
+	if routeTable == nil || routeTable.RouteTablePropertiesFormat == nil || routeTable.Routes == nil {
+		return routes, nil
+	}
+	for _, route := range *routeTable.Routes {
+		if route.RoutePropertiesFormat == nil || route.AddressPrefix == nil {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(*route.AddressPrefix)
+		if err != nil {
+			log.Printf("Warning: Route %s to %s is ignored, only address prefixes are evaluated\n", stringValue(route.Name), *route.AddressPrefix)
+			continue
+		}
+		routes = append(routes, candidateRoute{
+			prefix: ipNet,
+			hop: NextHop{
+				Type:          route.NextHopType,
+				IPAddress:     stringValue(route.NextHopIPAddress),
+				AddressPrefix: *route.AddressPrefix,
+				RouteName:     stringValue(route.Name),
+				Source:        RouteSourceUser,
+			},
+		})
+	}
+	return routes, nil
+}
@@ -0,0 +1,111 @@
+package helper
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadTestNetworkResource reads a fixture of testdata into an SDK struct
+func loadTestNetworkResource(t *testing.T, file string, resource interface{}) {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", file))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(content, resource))
+}
+
+func TestEvaluateNextHopE(t *testing.T) {
+	var virtualNetwork network.VirtualNetwork
+	loadTestNetworkResource(t, filepath.Join("routes", "vnet-spoke.json"), &virtualNetwork)
+	var routeTable network.RouteTable
+	loadTestNetworkResource(t, filepath.Join("routes", "rt-spoke.json"), &routeTable)
+
+	cases := []struct {
+		name        string
+		routeTable  *network.RouteTable
+		destination string
+		expected    NextHop
+	}{
+		{"virtual network", nil, "10.1.3.4", NextHop{Type: network.RouteNextHopTypeVnetLocal, AddressPrefix: "10.1.0.0/16", Source: RouteSourceDefault}},
+		{"Internet", nil, "8.8.8.8", NextHop{Type: network.RouteNextHopTypeInternet, AddressPrefix: "0.0.0.0/0", Source: RouteSourceDefault}},
+		{"IPv6 Internet", nil, "2001:db8::1", NextHop{Type: network.RouteNextHopTypeInternet, AddressPrefix: "::/0", Source: RouteSourceDefault}},
+		{"connected peering", nil, "10.0.1.4", NextHop{Type: RouteNextHopTypeVNetPeering, AddressPrefix: "10.0.0.0/16", Source: RouteSourceDefault}},
+		{"peering without state", nil, "10.6.0.4", NextHop{Type: RouteNextHopTypeVNetPeering, AddressPrefix: "10.6.0.0/16", Source: RouteSourceDefault}},
+		{"disconnected peering", nil, "10.5.0.4", NextHop{Type: network.RouteNextHopTypeNone, AddressPrefix: "10.0.0.0/8", Source: RouteSourceDefault}},
+		{"private range", nil, "172.16.0.1", NextHop{Type: network.RouteNextHopTypeNone, AddressPrefix: "172.16.0.0/12", Source: RouteSourceDefault}},
+		{"shared address space", nil, "100.64.0.1", NextHop{Type: network.RouteNextHopTypeNone, AddressPrefix: "100.64.0.0/10", Source: RouteSourceDefault}},
+		// the user defined route wins the tie with the system route of the same prefix
+		{"user route over Internet", &routeTable, "8.8.8.8",
+			NextHop{Type: network.RouteNextHopTypeVirtualAppliance, IPAddress: "10.0.1.4", AddressPrefix: "0.0.0.0/0", RouteName: "default-via-firewall", Source: RouteSourceUser}},
+		{"user route over virtual network", &routeTable, "10.1.3.4",
+			NextHop{Type: network.RouteNextHopTypeVirtualAppliance, IPAddress: "10.0.1.4", AddressPrefix: "10.1.0.0/16", RouteName: "vnet-via-firewall", Source: RouteSourceUser}},
+		{"longest prefix", &routeTable, "10.1.2.4",
+			NextHop{Type: network.RouteNextHopTypeNone, AddressPrefix: "10.1.2.0/24", RouteName: "isolated-subnet", Source: RouteSourceUser}},
+		{"system route longer than user route", &routeTable, "10.0.1.4",
+			NextHop{Type: RouteNextHopTypeVNetPeering, AddressPrefix: "10.0.0.0/16", Source: RouteSourceDefault}},
+		// the route to the AzureCloud service tag is ignored
+		{"service tag route", &routeTable, "20.38.100.1",
+			NextHop{Type: network.RouteNextHopTypeVirtualAppliance, IPAddress: "10.0.1.4", AddressPrefix: "0.0.0.0/0", RouteName: "default-via-firewall", Source: RouteSourceUser}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hop, err := EvaluateNextHopE(&virtualNetwork, c.routeTable, c.destination)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, *hop, hop.String())
+		})
+	}
+}
+
+func TestEvaluateNextHopEErrors(t *testing.T) {
+	var virtualNetwork network.VirtualNetwork
+	loadTestNetworkResource(t, filepath.Join("routes", "vnet-spoke.json"), &virtualNetwork)
+
+	_, err := EvaluateNextHopE(&virtualNetwork, nil, "10.1.300.4")
+	assert.EqualError(t, err, "Invalid destination IP address 10.1.300.4")
+
+	_, err = EvaluateNextHopE(&network.VirtualNetwork{}, nil, "10.1.3.4")
+	assert.EqualError(t, err, "Virtual network has no properties")
+
+	(*virtualNetwork.AddressSpace.AddressPrefixes)[0] = "10.1.0.0"
+	_, err = EvaluateNextHopE(&virtualNetwork, nil, "10.1.3.4")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Invalid address prefix 10.1.0.0")
+	}
+}
+
+func TestNextHopString(t *testing.T) {
+	assert.Equal(t, "VirtualAppliance 10.0.1.4 (route default-via-firewall for 0.0.0.0/0)",
+		NextHop{Type: network.RouteNextHopTypeVirtualAppliance, IPAddress: "10.0.1.4", AddressPrefix: "0.0.0.0/0", RouteName: "default-via-firewall", Source: RouteSourceUser}.String())
+	assert.Equal(t, "None (default route for 10.0.0.0/8)",
+		NextHop{Type: network.RouteNextHopTypeNone, AddressPrefix: "10.0.0.0/8", Source: RouteSourceDefault}.String())
+}
+
+func TestGetNextHopE(t *testing.T) {
+	s := setupTestARMServer(t)
+	spokeID := testResourceGroupID + "/providers/Microsoft.Network/virtualNetworks/vnet-spoke"
+	// the route table is in another resource group than the virtual network
+	routeTableID := testOtherResourceGroupID + "/providers/Microsoft.Network/routeTables/rt-spoke"
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "routes", "vnet-spoke.json"))
+	require.NoError(t, err)
+	s.AddResource(t, spokeID, content)
+	s.AddResource(t, spokeID+"/subnets/snet-app", `{"properties": {"addressPrefix": "10.1.1.0/24", "routeTable": {"id": "`+routeTableID+`"}}}`)
+	s.AddResource(t, spokeID+"/subnets/snet-web", `{"properties": {"addressPrefix": "10.1.3.0/24"}}`)
+	content, err = ioutil.ReadFile(filepath.Join("testdata", "routes", "rt-spoke.json"))
+	require.NoError(t, err)
+	s.AddResource(t, routeTableID, content)
+
+	hop, err := GetNextHopE("rg-test", "vnet-spoke", "snet-app", "8.8.8.8")
+	require.NoError(t, err)
+	assert.Equal(t, "default-via-firewall", hop.RouteName)
+
+	hop, err = GetNextHopE("rg-test", "vnet-spoke", "snet-web", "8.8.8.8")
+	require.NoError(t, err)
+	assert.Equal(t, network.RouteNextHopTypeInternet, hop.Type)
+
+	AssertNextHop(t, "rg-test", "vnet-spoke", "snet-app", "10.1.3.4", network.RouteNextHopTypeVirtualAppliance, "10.0.1.4")
+	AssertNextHop(t, "rg-test", "vnet-spoke", "snet-web", "10.0.1.4", RouteNextHopTypeVNetPeering, "")
+}
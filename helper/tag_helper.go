@@ -0,0 +1,221 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
+)
+
+// TagPolicy lists the tags every resource of a scope must have, e.g. in YAML
+//
+//	tags:
+//	  - name: costCenter
+//	    pattern: ^[0-9]{4}$
+//	  - name: env
+//	    allowedValues: [dev, test, prod]
+//	  - name: owner
+//	includeResourceGroups: true
+type TagPolicy struct {
+	Tags []RequiredTag `mapstructure:"tags"`
+	// ResourceTypes limits the check to the resource types matching these patterns (see path.Match), all types when empty
+	ResourceTypes []string `mapstructure:"resourceTypes"`
+	// ExcludedResourceTypes are resource type patterns that are not checked, e.g. Microsoft.Insights/*
+	ExcludedResourceTypes []string `mapstructure:"excludedResourceTypes"`
+	// IncludeResourceGroups checks the tags of the resource groups too
+	IncludeResourceGroups bool `mapstructure:"includeResourceGroups"`
+}
+
+// RequiredTag is a tag that must be set, with a value in AllowedValues or matching Pattern when they are set.
+// Tag names are case insensitive, as in Azure.
+type RequiredTag struct {
+	Name          string   `mapstructure:"name"`
+	AllowedValues []string `mapstructure:"allowedValues"`
+	Pattern       string   `mapstructure:"pattern"`
+}
+
+// TagViolation lists the tag issues of a resource
+type TagViolation struct {
+	// ResourceID of the offending resource or resource group
+	ResourceID string
+	Issues     []string
+}
+
+func (v TagViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.ResourceID, strings.Join(v.Issues, ", "))
+}
+
+// LoadTagPolicyE reads a TagPolicy from a YAML file, replacing ${VAR} references by environment variables
+func LoadTagPolicyE(filePath string) (*TagPolicy, error) {
+	policy := TagPolicy{}
+	if err := decodeYamlFileE(filePath, &policy); err != nil {
+		return nil, err
+	}
+	for _, tag := range policy.Tags {
+		if tag.Name == "" {
+			return nil, fmt.Errorf("Invalid tag policy %s: every tag needs a name", filePath)
+		}
+	}
+	return &policy, nil
+}
+
+// CheckRequiredTagsE walks every resource of scope and returns the ones that don't follow policy.
+// scope is a subscription (/subscriptions/{id}) or resource group (/subscriptions/{id}/resourceGroups/{name}) ID,
+// or the name of a resource group in ARM_SUBSCRIPTION_ID.
+func CheckRequiredTagsE(scope string, policy TagPolicy) ([]TagViolation, error) {
+	return CheckRequiredTagsWithContextE(context.Background(), scope, policy)
+}
+
+// CheckRequiredTagsWithContextE walks every resource of scope and returns the ones that don't follow policy.
+// scope is a subscription (/subscriptions/{id}) or resource group (/subscriptions/{id}/resourceGroups/{name}) ID,
+// or the name of a resource group in ARM_SUBSCRIPTION_ID.
+func CheckRequiredTagsWithContextE(ctx context.Context, scope string, policy TagPolicy) ([]TagViolation, error) {
+	patterns := make(map[string]*regexp.Regexp)
+	for _, tag := range policy.Tags {
+		if tag.Pattern == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(tag.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pattern for tag %s: %s", tag.Name, err)
+		}
+		patterns[tag.Name] = pattern
+	}
+
+	subscriptionID, resourceGroupName, err := parseTagScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	violations := make([]TagViolation, 0)
+	check := func(resourceID string, tags map[string]*string) {
+		if issues := checkTags(policy, patterns, tags); len(issues) > 0 {
+			violations = append(violations, TagViolation{ResourceID: resourceID, Issues: issues})
+		}
+	}
+
+	if policy.IncludeResourceGroups {
+		if resourceGroupName != "" {
+			group, err := GetResourceGroupWithContextE(ctx, resourceGroupName, subscriptionID)
+			if err != nil {
+				return nil, err
+			}
+			check(*group.ID, group.Tags)
+		} else {
+			groups, err := ListResourceGroupsWithContextE(ctx, subscriptionID)
+			if err != nil {
+				return nil, err
+			}
+			for _, group := range groups {
+				check(*group.ID, group.Tags)
+			}
+		}
+	}
+
+	var list []resources.GenericResourceExpanded
+	if resourceGroupName != "" {
+		list, err = ListResourcesInResourceGroupWithContextE(ctx, resourceGroupName, subscriptionID)
+	} else {
+		list, err = ListResourcesInSubscriptionWithContextE(ctx, subscriptionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range list {
+		if resource.ID == nil || resource.Type == nil || !policy.appliesTo(*resource.Type) {
+			continue
+		}
+		check(*resource.ID, resource.Tags)
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].ResourceID < violations[j].ResourceID })
+	return violations, nil
+}
+
+// AssertRequiredTags fails the test when resources of scope don't follow policy, reporting every
+// offending resource at once. See CheckRequiredTagsE for scope.
+func AssertRequiredTags(t *testing.T, scope string, policy TagPolicy) {
+	t.Helper()
+	violations, err := CheckRequiredTagsWithContextE(NewTestContext(t), scope, policy)
+	if err != nil {
+		t.Fatalf("Failed to check the tags of %s: %s", scope, err)
+	}
+	if len(violations) == 0 {
+		return
+	}
+	lines := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		lines = append(lines, violation.String())
+	}
+	t.Errorf("%d resource(s) of %s don't have the required tags:\n\t%s", len(violations), scope, strings.Join(lines, "\n\t"))
+}
+
+// parseTagScope returns the subscription and resource group (empty for a whole subscription) of a scope
+func parseTagScope(scope string) (string, string, error) {
+	if !strings.HasPrefix(scope, "/") {
+		return getTargetSubscription(), scope, nil
+	}
+	id, err := ParseResourceIDE(scope)
+	if err != nil {
+		return "", "", err
+	}
+	if id.Provider != "" {
+		return "", "", fmt.Errorf("Scope %s must be a subscription or a resource group", scope)
+	}
+	return id.SubscriptionID, id.ResourceGroup, nil
+}
+
+// appliesTo tells whether the policy checks resources of resourceType
+func (p TagPolicy) appliesTo(resourceType string) bool {
+	for _, pattern := range p.ExcludedResourceTypes {
+		if match, _ := path.Match(strings.ToLower(pattern), strings.ToLower(resourceType)); match {
+			return false
+		}
+	}
+	if len(p.ResourceTypes) == 0 {
+		return true
+	}
+	for _, pattern := range p.ResourceTypes {
+		if match, _ := path.Match(strings.ToLower(pattern), strings.ToLower(resourceType)); match {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTags returns the issues of a set of tags
+func checkTags(policy TagPolicy, patterns map[string]*regexp.Regexp, tags map[string]*string) []string {
+	issues := make([]string, 0)
+	for _, required := range policy.Tags {
+		value, found := "", false
+		for name, v := range tags {
+			if strings.EqualFold(name, required.Name) && v != nil {
+				value, found = *v, true
+				break
+			}
+		}
+		if !found {
+			issues = append(issues, fmt.Sprintf("missing tag %s", required.Name))
+			continue
+		}
+		if len(required.AllowedValues) > 0 && !containsString(required.AllowedValues, value) {
+			issues = append(issues, fmt.Sprintf("tag %s is %q, allowed values are %s", required.Name, value, strings.Join(required.AllowedValues, ", ")))
+		}
+		if pattern, ok := patterns[required.Name]; ok && !pattern.MatchString(value) {
+			issues = append(issues, fmt.Sprintf("tag %s is %q, expected to match %s", required.Name, value, required.Pattern))
+		}
+	}
+	return issues
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
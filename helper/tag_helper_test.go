@@ -0,0 +1,90 @@
+package helper
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOtherResourceGroupID = "/subscriptions/" + testSubscriptionID + "/resourceGroups/rg-other"
+
+func setupTaggedResources(t *testing.T) {
+	s := setupTestARMServer(t)
+	s.AddResource(t, testResourceGroupID, `{"tags": {"env": "dev", "costCenter": "1234", "owner": "team-a"}}`)
+	s.AddResource(t, testOtherResourceGroupID, `{"tags": {}}`)
+	s.AddResource(t, testVnetID, `{"tags": {"Env": "prod", "CostCenter": "12"}, "properties": {}}`)
+	s.AddResource(t, testResourceGroupID+"/providers/Microsoft.Network/publicIPAddresses/pip-test",
+		`{"tags": {"env": "qa", "costCenter": "1234", "owner": "team-a"}}`)
+	s.AddResource(t, testResourceGroupID+"/providers/Microsoft.Insights/components/appi-test", `{}`)
+	s.AddResource(t, testOtherResourceGroupID+"/providers/Microsoft.Network/publicIPAddresses/pip-other", `{"tags": {"owner": "team-b"}}`)
+}
+
+func loadTestTagPolicy(t *testing.T, file string) TagPolicy {
+	policy, err := LoadTagPolicyE(filepath.Join("testdata", "tags", file))
+	require.NoError(t, err)
+	return *policy
+}
+
+func TestLoadTagPolicyE(t *testing.T) {
+	policy := loadTestTagPolicy(t, "policy.yaml")
+	assert.Equal(t, TagPolicy{
+		Tags: []RequiredTag{
+			{Name: "costCenter", Pattern: "^[0-9]{4}$"},
+			{Name: "env", AllowedValues: []string{"dev", "test", "prod"}},
+			{Name: "owner"},
+		},
+		ExcludedResourceTypes: []string{"Microsoft.Insights/*"},
+		IncludeResourceGroups: true,
+	}, policy)
+
+	_, err := LoadTagPolicyE(filepath.Join("testdata", "tags", "invalid.yaml"))
+	assert.Error(t, err)
+}
+
+func TestTagPolicyAppliesTo(t *testing.T) {
+	policy := TagPolicy{ResourceTypes: []string{"Microsoft.Network/*"}, ExcludedResourceTypes: []string{"microsoft.network/networkwatchers"}}
+	assert.True(t, policy.appliesTo("Microsoft.Network/virtualNetworks"))
+	assert.False(t, policy.appliesTo("Microsoft.Network/networkWatchers"))
+	assert.False(t, policy.appliesTo("Microsoft.Compute/disks"))
+	assert.True(t, TagPolicy{}.appliesTo("Microsoft.Compute/disks"))
+}
+
+func TestCheckRequiredTagsE(t *testing.T) {
+	setupTaggedResources(t)
+	pipID := testResourceGroupID + "/providers/Microsoft.Network/publicIPAddresses/pip-test"
+
+	// resource group scope by name
+	violations, err := CheckRequiredTagsE("rg-test", loadTestTagPolicy(t, "policy.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, []TagViolation{
+		{ResourceID: pipID, Issues: []string{`tag env is "qa", allowed values are dev, test, prod`}},
+		{ResourceID: testVnetID, Issues: []string{`tag costCenter is "12", expected to match ^[0-9]{4}$`, "missing tag owner"}},
+	}, violations)
+
+	// subscription scope, with the resource groups
+	violations, err = CheckRequiredTagsE("/subscriptions/"+testSubscriptionID, loadTestTagPolicy(t, "policy.yaml"))
+	require.NoError(t, err)
+	ids := make([]string, 0)
+	for _, violation := range violations {
+		ids = append(ids, violation.ResourceID)
+	}
+	assert.Equal(t, []string{
+		testOtherResourceGroupID,
+		testOtherResourceGroupID + "/providers/Microsoft.Network/publicIPAddresses/pip-other",
+		pipID,
+		testVnetID,
+	}, ids)
+	assert.Equal(t, []string{"missing tag costCenter", "missing tag env", "missing tag owner"}, violations[0].Issues)
+
+	// resource types limited to public IP addresses
+	violations, err = CheckRequiredTagsE(testOtherResourceGroupID, loadTestTagPolicy(t, "network_only.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	_, err = CheckRequiredTagsE(testVnetID, loadTestTagPolicy(t, "policy.yaml"))
+	assert.Error(t, err)
+	_, err = CheckRequiredTagsE("rg-test", TagPolicy{Tags: []RequiredTag{{Name: "env", Pattern: "("}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+)
+
+const (
+	// TerraformOutputFilePath is the environment variable key for the file with the Terraform outputs,
+	// either saved from terraform output -json or a state file, or for the Terraform working folder
+	TerraformOutputFilePath = "TF_OUTPUT_FILE_PATH"
+)
+
+/********************************
+		Terraform outputs
+*********************************/
+
+// GetTerraformOutputsE returns the value of every Terraform output by name. filePath is either a file saved
+// from terraform output -json, a state file (terraform.tfstate, format version 3 or 4) or a Terraform working
+// folder, where terraform output -json is run. Numbers are returned as json.Number, lists as []interface{}
+// and maps or objects as map[string]interface{}.
+func GetTerraformOutputsE(filePath string) (map[string]interface{}, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Path to Terraform outputs not set or invalid: %s", filePath)
+	}
+	var content []byte
+	if info.IsDir() {
+		cmd := exec.Command("terraform", "output", "-json")
+		cmd.Dir = filePath
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if content, err = cmd.Output(); err != nil {
+			if message := strings.TrimSpace(stderr.String()); message != "" {
+				return nil, fmt.Errorf("terraform output -json failed in %s: %s", filePath, message)
+			}
+			return nil, fmt.Errorf("terraform output -json failed in %s: %s", filePath, err)
+		}
+	} else if content, err = ioutil.ReadFile(filePath); err != nil {
+		return nil, fmt.Errorf("Can not read Terraform outputs %s: %s", filePath, err)
+	}
+	return parseTerraformOutputs(content)
+}
+
+// terraformOutput is an output as written by terraform output -json and in state files
+type terraformOutput struct {
+	Value     interface{} `json:"value"`
+	Sensitive bool        `json:"sensitive"`
+}
+
+// terraformState is the part of a state file holding the root module outputs
+type terraformState struct {
+	Version json.Number                `json:"version"`
+	Outputs map[string]terraformOutput `json:"outputs"`
+	Modules []struct {
+		Path    []string                   `json:"path"`
+		Outputs map[string]terraformOutput `json:"outputs"`
+	} `json:"modules"`
+}
+
+// parseTerraformOutputs reads the outputs of terraform output -json or of a state file
+func parseTerraformOutputs(content []byte) (map[string]interface{}, error) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("Invalid Terraform outputs: %s", err)
+	}
+
+	var outputs map[string]terraformOutput
+	if version, isState := document["version"]; isState && isJSONNumber(version) {
+		var state terraformState
+		if err := unmarshalUsingNumber(content, &state); err != nil {
+			return nil, fmt.Errorf("Invalid Terraform state: %s", err)
+		}
+		switch state.Version.String() {
+		case "4":
+			outputs = state.Outputs
+		case "3":
+			// version 3 keeps the outputs per module, only the root module ones are outputs of the configuration
+			for _, module := range state.Modules {
+				if len(module.Path) == 1 && module.Path[0] == "root" {
+					outputs = module.Outputs
+				}
+			}
+		default:
+			return nil, fmt.Errorf("Terraform state format version %s is not supported", state.Version)
+		}
+	} else if err := unmarshalUsingNumber(content, &outputs); err != nil {
+		return nil, fmt.Errorf("Invalid Terraform outputs: %s", err)
+	}
+
+	values := make(map[string]interface{}, len(outputs))
+	for name, output := range outputs {
+		values[name] = output.Value
+	}
+	return values, nil
+}
+
+func isJSONNumber(content json.RawMessage) bool {
+	var number json.Number
+	return unmarshalUsingNumber(content, &number) == nil
+}
+
+// unmarshalUsingNumber unmarshals JSON keeping numbers as json.Number, so large numbers stay exact
+func unmarshalUsingNumber(content []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// FetchTerraformOutputsE fills the fields having a `tfoutput` tag with Terraform outputs read by GetTerraformOutputsE
+// from filePath, or from the path in TF_OUTPUT_FILE_PATH when filePath is empty. The tag is the output name, followed
+// by a property path for nested map and list outputs, e.g. `tfoutput:"vnet_name"`, `tfoutput:"hub.subnet_ids[0]"`
+// or `tfoutput:"subnets[\"AzureFirewallSubnet\"].id"`. Outputs are converted to the field type, lists and maps
+// go to slice and map fields.
+func FetchTerraformOutputsE(s interface{}, filePath string) (interface{}, error) {
+	if filePath == "" {
+		filePath = os.Getenv(TerraformOutputFilePath)
+	}
+	outputs, err := GetTerraformOutputsE(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := reflect.ValueOf(s).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		typeField := fields.Type().Field(i)
+		outputPath := typeField.Tag.Get("tfoutput")
+		if outputPath == "" {
+			continue
+		}
+		value, err := GetPropertyByPathE(outputs, outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("Can not find Terraform output %s for field %s: %s", outputPath, typeField.Name, err)
+		}
+		if err := setConfigField(fields.Field(i), value); err != nil {
+			return nil, fmt.Errorf("Can not set field %s from Terraform output %s: %s", typeField.Name, outputPath, err)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,116 @@
+package helper
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTerraformFile(name string) string {
+	return filepath.Join("testdata", "terraform", name)
+}
+
+func TestGetTerraformOutputsE(t *testing.T) {
+	outputs, err := GetTerraformOutputsE(testTerraformFile("outputs.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "vnet-hub", outputs["vnet_name"])
+	assert.Equal(t, []interface{}{"10.0.0.0/16", "10.1.0.0/16"}, outputs["address_space"])
+	assert.Equal(t, map[string]interface{}{"environment": "test", "owner": "network"}, outputs["tags"])
+	assert.Equal(t, json.Number("65515"), outputs["asn"])
+	assert.Equal(t, true, outputs["ddos_enabled"])
+	// sensitive outputs are returned like the others
+	assert.Equal(t, "c2VjcmV0", outputs["storage_key"])
+}
+
+func TestGetTerraformOutputsEState(t *testing.T) {
+	outputs, err := GetTerraformOutputsE(testTerraformFile("terraform.tfstate"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"vnet_name": "vnet-hub",
+		// above 2^53, would be rounded as a float64
+		"resource_count": json.Number("9007199254740993"),
+	}, outputs)
+
+	// version 3 states only return the outputs of the root module
+	outputs, err = GetTerraformOutputsE(testTerraformFile("v3.tfstate"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"vnet_name": "vnet-hub"}, outputs)
+}
+
+func TestGetTerraformOutputsEErrors(t *testing.T) {
+	_, err := GetTerraformOutputsE(testTerraformFile("missing.json"))
+	assert.EqualError(t, err, "Path to Terraform outputs not set or invalid: "+testTerraformFile("missing.json"))
+
+	_, err = GetTerraformOutputsE(testTerraformFile("v2.tfstate"))
+	assert.EqualError(t, err, "Terraform state format version 2 is not supported")
+
+	_, err = GetTerraformOutputsE(testTerraformFile("invalid.json"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Invalid Terraform outputs")
+	}
+}
+
+func TestParseTerraformOutputsVersionOutput(t *testing.T) {
+	// an output named version is not taken for the version of a state file
+	outputs, err := parseTerraformOutputs([]byte(`{"version": {"value": "1.2.0", "type": "string"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"version": "1.2.0"}, outputs)
+}
+
+func TestFetchTerraformOutputsE(t *testing.T) {
+	config := struct {
+		VnetName       string            `tfoutput:"vnet_name"`
+		SecondPrefix   string            `tfoutput:"address_space[1]"`
+		AddressSpace   []string          `tfoutput:"address_space"`
+		FirewallSubnet string            `tfoutput:"subnets[\"AzureFirewallSubnet\"].prefix"`
+		Tags           map[string]string `tfoutput:"tags"`
+		Asn            int64             `tfoutput:"asn"`
+		AsnText        string            `tfoutput:"asn"`
+		DdosEnabled    bool              `tfoutput:"ddos_enabled"`
+		Untagged       string
+	}{Untagged: "unchanged"}
+
+	_, err := FetchTerraformOutputsE(&config, testTerraformFile("outputs.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "vnet-hub", config.VnetName)
+	assert.Equal(t, "10.1.0.0/16", config.SecondPrefix)
+	assert.Equal(t, []string{"10.0.0.0/16", "10.1.0.0/16"}, config.AddressSpace)
+	assert.Equal(t, "10.0.1.0/26", config.FirewallSubnet)
+	assert.Equal(t, map[string]string{"environment": "test", "owner": "network"}, config.Tags)
+	assert.Equal(t, int64(65515), config.Asn)
+	assert.Equal(t, "65515", config.AsnText)
+	assert.True(t, config.DdosEnabled)
+	assert.Equal(t, "unchanged", config.Untagged)
+	assert.True(t, ValidateTestValues(&config, false))
+}
+
+func TestFetchTerraformOutputsEPathFromEnvironment(t *testing.T) {
+	t.Setenv(TerraformOutputFilePath, testTerraformFile("terraform.tfstate"))
+	config := struct {
+		VnetName string `tfoutput:"vnet_name"`
+	}{}
+
+	_, err := FetchTerraformOutputsE(&config, "")
+	require.NoError(t, err)
+	assert.Equal(t, "vnet-hub", config.VnetName)
+}
+
+func TestFetchTerraformOutputsEErrors(t *testing.T) {
+	missing := struct {
+		Name string `tfoutput:"firewall_name"`
+	}{}
+	_, err := FetchTerraformOutputsE(&missing, testTerraformFile("outputs.json"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Can not find Terraform output firewall_name for field Name")
+	}
+
+	// a map can't go to a string field
+	wrongType := struct {
+		Subnets string `tfoutput:"subnets"`
+	}{}
+	_, err = FetchTerraformOutputsE(&wrongType, testTerraformFile("outputs.json"))
+	assert.EqualError(t, err, "Can not set field Subnets from Terraform output subnets: the value is a map[string]interface {}, not a string")
+}
@@ -15,6 +15,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gocql/gocql"
 	"github.com/gomodule/redigo/redis"
@@ -43,6 +45,29 @@ func SetupTestCase(t *testing.T) {
 	}
 }
 
+// TestContextGracePeriod is the time left between the end of a context created by NewTestContext
+// and the test deadline, so the test can still report the failure before go test kills it
+var TestContextGracePeriod = 30 * time.Second
+
+// NewTestContext returns a context that is cancelled when the test finishes or
+// TestContextGracePeriod before the go test deadline (-timeout flag), whichever comes first.
+// Use it with the *WithContextE helpers so a hung call fails only the current test.
+// The number of retried calls made with the context is logged at the end of the test.
+func NewTestContext(t *testing.T) context.Context {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if deadline, ok := t.Deadline(); ok {
+		if deadline.Add(-TestContextGracePeriod).After(time.Now()) {
+			deadline = deadline.Add(-TestContextGracePeriod)
+		}
+		ctx, cancel = context.WithDeadline(context.Background(), deadline)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	t.Cleanup(cancel)
+	return withRetryCounter(ctx, t)
+}
+
 // LoadEnvFile read an .env file that has a path by the value of TEST_ENV_FILE_PATH environment variable.
 func LoadEnvFile(t *testing.T) error {
 	envFileName := os.Getenv(TestEnvFilePath)
@@ -92,6 +117,30 @@ func getPropertyValueFromString(object string, propertyKey string) string {
 	return propertyValue
 }
 
+// setConfigField sets a field of a config struct from a value read from JSON, converting it to the field type.
+// A nil value leaves the field unchanged.
+func setConfigField(field reflect.Value, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	if field.Kind() == reflect.String {
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			return fmt.Errorf("the value is a %T, not a string", value)
+		}
+		field.SetString(scalarString(value))
+		return nil
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           field.Addr().Interface(),
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(value)
+}
+
 // ValidateTestValues validate if the all parameters has the value. skipGenerated allows ignore a field that has the `generated:"true"` tag.
 func ValidateTestValues(s interface{}, skipGenerated bool) bool {
 	fields := reflect.ValueOf(s).Elem()
@@ -119,6 +168,12 @@ func ValidateTestValues(s interface{}, skipGenerated bool) bool {
 				} else if tagExists(typeField.Tag, "kv") {
 					log.Printf("Warning: Struct Field %s (kv:%s) doesn't have any value.\n", typeField.Name, typeField.Tag.Get("kv"))
 					flag = false
+				} else if tagExists(typeField.Tag, "tfoutput") {
+					log.Printf("Warning: Struct Field %s (tfoutput:%s) doesn't have any value.\n", typeField.Name, typeField.Tag.Get("tfoutput"))
+					flag = false
+				} else if tagExists(typeField.Tag, "armoutput") {
+					log.Printf("Warning: Struct Field %s (armoutput:%s) doesn't have any value.\n", typeField.Name, typeField.Tag.Get("armoutput"))
+					flag = false
 				} else if tagExists(typeField.Tag, "val") {
 					log.Printf("Warning: Struct Field %s doesn't have any value.\n", typeField.Name)
 					flag = false
@@ -227,7 +282,9 @@ func anyTagExists(tag reflect.StructTag) bool {
 	_, isEnv := tag.Lookup("env")
 	_, isKv := tag.Lookup("kv")
 	_, isVal := tag.Lookup("val")
-	return isEnv || isKv || isVal
+	_, isTfOutput := tag.Lookup("tfoutput")
+	_, isArmOutput := tag.Lookup("armoutput")
+	return isEnv || isKv || isVal || isTfOutput || isArmOutput
 }
 
 // GetYamlVariables reads the yaml file in filePath and returns valus specified by interface s
@@ -249,6 +306,27 @@ func GetYamlVariables(filePath string, s interface{}) (interface{}, error) {
 	return s, nil
 }
 
+// decodeYamlFileE reads a YAML file into s like GetYamlVariables, replacing ${VAR} references by
+// environment variables first. Unknown keys are reported as errors.
+func decodeYamlFileE(filePath string, s interface{}) error {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("Can not read %s: %s", filePath, err)
+	}
+	m := make(map[interface{}]interface{})
+	if err := yaml.UnmarshalStrict([]byte(os.ExpandEnv(string(content))), &m); err != nil {
+		return fmt.Errorf("Error parsing Yaml File %s: %s", filePath, err)
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{ErrorUnused: true, Result: s})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(m); err != nil {
+		return fmt.Errorf("Invalid Yaml File %s: %s", filePath, err)
+	}
+	return nil
+}
+
 // CheckIfEndpointIsResponding test an endpoint for availability. Returns true if endpoint is available, false otherwise
 func CheckIfEndpointIsResponding(t *testing.T, endpoint string) bool {
 	// we ignore certificates at this point
@@ -352,3 +430,85 @@ func CheckCassandraConnectivity(t *testing.T, endpoint string, username string,
 	}
 
 }
+
+// GetARMErrorCode returns the error code returned by Azure Resource Manager, e.g. ResourceNotFound
+// or AuthorizationFailed, or an empty string if err doesn't come from Azure
+func GetARMErrorCode(err error) string {
+	for err != nil {
+		switch e := err.(type) {
+		case autorest.DetailedError:
+			if e.Original == nil {
+				return ""
+			}
+			err = e.Original
+		case *autorest.DetailedError:
+			if e.Original == nil {
+				return ""
+			}
+			err = e.Original
+		case *azure.RequestError:
+			if e.ServiceError == nil {
+				return ""
+			}
+			return e.ServiceError.Code
+		case azure.RequestError:
+			if e.ServiceError == nil {
+				return ""
+			}
+			return e.ServiceError.Code
+		case *azure.ServiceError:
+			return e.Code
+		case azure.ServiceError:
+			return e.Code
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// failOnAzureError fails the test when err is not nil, describing the call, e.g.
+// Failed to get Microsoft.Network/virtualNetworks "vnet" in resource group "rg" (ResourceNotFound): ...
+// action, resourceType, resourceName and resourceGroupName are left out of the message when empty.
+func failOnAzureError(t *testing.T, err error, action string, resourceType string, resourceName string, resourceGroupName string) {
+	t.Helper()
+	if err == nil {
+		return
+	}
+	message := "Failed to " + action
+	if resourceType != "" {
+		message += " " + resourceType
+	}
+	if resourceName != "" {
+		message += fmt.Sprintf(" %q", resourceName)
+	}
+	if resourceGroupName != "" {
+		message += fmt.Sprintf(" in resource group %q", resourceGroupName)
+	}
+	if code := GetARMErrorCode(err); code != "" {
+		message += fmt.Sprintf(" (%s)", code)
+	}
+	t.Fatalf("%s: %s", message, err)
+}
+
+// failOnAzureErrorByID is failOnAzureError for a resource given by its resource ID
+func failOnAzureErrorByID(t *testing.T, err error, resourceType string, resourceID string) {
+	t.Helper()
+	if err == nil {
+		return
+	}
+	id, parseErr := ParseResourceIDE(resourceID)
+	if parseErr != nil {
+		failOnAzureError(t, err, "get", resourceType, resourceID, "")
+		return
+	}
+	if resourceType == "" {
+		resourceType = id.ResourceType()
+	}
+	resourceGroupName := id.ResourceGroup
+	if id.Provider == "" {
+		// the resource is the resource group itself
+		resourceGroupName = ""
+	}
+	failOnAzureError(t, err, "get", resourceType, id.Name(), resourceGroupName)
+}
@@ -0,0 +1,328 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+)
+
+/********************************
+	Hub-and-spoke topology
+*********************************/
+
+// HubSpokeTopology describes a hub-and-spoke network, e.g. in YAML
+//
+//	subscriptionId: ${HUB_SUBSCRIPTION_ID}
+//	hub:
+//	  resourceGroup: rg-hub
+//	  virtualNetwork: vnet-hub
+//	firewall:
+//	  name: afw-hub
+//	  privateIPAddress: 10.0.1.4
+//	gateway:
+//	  name: vgw-hub
+//	dnsServers: [10.0.2.4]
+//	ddosProtectionPlan:
+//	  resourceGroup: rg-hub
+//	  name: ddos-plan
+//	spokes:
+//	  - resourceGroup: rg-app
+//	    virtualNetwork: vnet-app
+//	    subscriptionId: ${APP_SUBSCRIPTION_ID}
+//	  - resourceGroup: rg-data
+//	    virtualNetwork: vnet-data
+//	    excludedSubnets: [snet-appgw]
+//
+// The resource group and subscription of the firewall, gateway and DDoS protection plan default to the ones of the hub,
+// which defaults to SubscriptionID, itself defaulting to ARM_SUBSCRIPTION_ID.
+type HubSpokeTopology struct {
+	SubscriptionID string                 `mapstructure:"subscriptionId"`
+	Hub            TopologyVirtualNetwork `mapstructure:"hub"`
+	// Firewall is the appliance the spokes send their Internet traffic to
+	Firewall TopologyFirewall `mapstructure:"firewall"`
+	// Gateway is the virtual network gateway of the hub shared with the spokes, none when its name is empty
+	Gateway TopologyResource `mapstructure:"gateway"`
+	// DNSServers are the DNS servers the spokes must use, such as the hub DNS resolver, in order
+	DNSServers []string `mapstructure:"dnsServers"`
+	// DdosProtectionPlan must protect the hub and the spokes, not checked when its name is empty
+	DdosProtectionPlan TopologyResource         `mapstructure:"ddosProtectionPlan"`
+	Spokes             []TopologyVirtualNetwork `mapstructure:"spokes"`
+}
+
+// TopologyVirtualNetwork is the hub or a spoke of a HubSpokeTopology
+type TopologyVirtualNetwork struct {
+	SubscriptionID string `mapstructure:"subscriptionId"`
+	ResourceGroup  string `mapstructure:"resourceGroup"`
+	VirtualNetwork string `mapstructure:"virtualNetwork"`
+	// ExcludedSubnets are the subnets of a spoke whose default route isn't checked
+	ExcludedSubnets []string `mapstructure:"excludedSubnets"`
+}
+
+// TopologyResource is a resource of a HubSpokeTopology
+type TopologyResource struct {
+	SubscriptionID string `mapstructure:"subscriptionId"`
+	ResourceGroup  string `mapstructure:"resourceGroup"`
+	Name           string `mapstructure:"name"`
+}
+
+// TopologyFirewall is the firewall of a HubSpokeTopology. When Name is set, the firewall must exist and PrivateIPAddress,
+// when empty, is read from it.
+type TopologyFirewall struct {
+	TopologyResource `mapstructure:",squash"`
+	PrivateIPAddress string `mapstructure:"privateIPAddress"`
+}
+
+// LoadHubSpokeTopologyE reads a HubSpokeTopology from a YAML file, replacing ${VAR} references by environment variables
+func LoadHubSpokeTopologyE(filePath string) (*HubSpokeTopology, error) {
+	topology := HubSpokeTopology{}
+	if err := decodeYamlFileE(filePath, &topology); err != nil {
+		return nil, err
+	}
+	if topology.Hub.ResourceGroup == "" || topology.Hub.VirtualNetwork == "" {
+		return nil, fmt.Errorf("Invalid topology %s: the hub needs a resource group and a virtual network", filePath)
+	}
+	for _, spoke := range topology.Spokes {
+		if spoke.ResourceGroup == "" || spoke.VirtualNetwork == "" {
+			return nil, fmt.Errorf("Invalid topology %s: every spoke needs a resource group and a virtual network", filePath)
+		}
+	}
+	if topology.Firewall.Name == "" && topology.Firewall.PrivateIPAddress == "" {
+		return nil, fmt.Errorf("Invalid topology %s: the firewall needs a name or a private IP address", filePath)
+	}
+	return &topology, nil
+}
+
+// CheckHubSpokeTopologyE checks every part of a hub-and-spoke network and returns every issue found:
+//   - each spoke is peered with the hub, both sides being consistent with their role (see CheckVirtualNetworkPeeringE),
+//     and uses the remote gateway when the topology has one
+//   - the route table of each subnet of the spokes sends the default route 0.0.0.0/0 to the firewall
+//   - each spoke uses the DNS servers of the topology
+//   - the hub and the spokes are protected by the DDoS protection plan
+func CheckHubSpokeTopologyE(topology HubSpokeTopology) ([]string, error) {
+	return CheckHubSpokeTopologyWithContextE(context.Background(), topology)
+}
+
+// CheckHubSpokeTopologyWithContextE checks every part of a hub-and-spoke network and returns every issue found, see CheckHubSpokeTopologyE
+func CheckHubSpokeTopologyWithContextE(ctx context.Context, topology HubSpokeTopology) ([]string, error) {
+	hubSubscriptionID := getTargetSubscription(topology.Hub.SubscriptionID, topology.SubscriptionID)
+	hub, err := GetVirtualNetworkWithContextE(ctx, topology.Hub.ResourceGroup, topology.Hub.VirtualNetwork, hubSubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	hubID := virtualNetworkID(hubSubscriptionID, topology.Hub.ResourceGroup, topology.Hub.VirtualNetwork)
+	issues := make([]string, 0)
+
+	firewallIP := topology.Firewall.PrivateIPAddress
+	if topology.Firewall.Name != "" {
+		firewall, err := GetFirewallWithContextE(ctx, topologyResourceGroup(topology.Firewall.TopologyResource, topology.Hub), topology.Firewall.Name,
+			topologySubscription(topology.Firewall.TopologyResource, topology.Hub, topology.SubscriptionID))
+		if err != nil {
+			return nil, err
+		}
+		ips := firewallPrivateIPAddresses(firewall)
+		if len(ips) == 0 {
+			issues = append(issues, fmt.Sprintf("firewall %s has no private IP address", topology.Firewall.Name))
+		} else if firewallIP == "" {
+			firewallIP = ips[0]
+		} else if !containsString(ips, firewallIP) {
+			issues = append(issues, fmt.Sprintf("firewall %s has private IP addresses [%s], not %s", topology.Firewall.Name, strings.Join(ips, ", "), firewallIP))
+		}
+	}
+
+	if topology.Gateway.Name != "" {
+		if _, err := GetVirtualNetworkGatewayWithContextE(ctx, topologyResourceGroup(topology.Gateway, topology.Hub), topology.Gateway.Name,
+			topologySubscription(topology.Gateway, topology.Hub, topology.SubscriptionID)); err != nil {
+			if !isNotFoundError(err) {
+				return nil, err
+			}
+			issues = append(issues, fmt.Sprintf("gateway %s doesn't exist", topology.Gateway.Name))
+		}
+	}
+
+	var planID string
+	if topology.DdosProtectionPlan.Name != "" {
+		plan, err := GetDdosProtectionPlanWithContextE(ctx, topologyResourceGroup(topology.DdosProtectionPlan, topology.Hub), topology.DdosProtectionPlan.Name,
+			topologySubscription(topology.DdosProtectionPlan, topology.Hub, topology.SubscriptionID))
+		if err != nil {
+			return nil, err
+		}
+		planID = stringValue(plan.ID)
+		issues = append(issues, checkDdosProtection(hub, planID)...)
+	}
+
+	for _, spokeDescription := range topology.Spokes {
+		spokeSubscriptionID := getTargetSubscription(spokeDescription.SubscriptionID, topology.SubscriptionID)
+		spoke, err := GetVirtualNetworkWithContextE(ctx, spokeDescription.ResourceGroup, spokeDescription.VirtualNetwork, spokeSubscriptionID)
+		if err != nil {
+			if isNotFoundError(err) {
+				issues = append(issues, fmt.Sprintf("spoke %s doesn't exist", spokeDescription.VirtualNetwork))
+				continue
+			}
+			return nil, err
+		}
+		spokeIssues, err := checkSpoke(ctx, topology, spokeDescription, spoke, spokeSubscriptionID, hubID, firewallIP, planID)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range spokeIssues {
+			issues = append(issues, fmt.Sprintf("spoke %s: %s", spokeDescription.VirtualNetwork, issue))
+		}
+	}
+	return issues, nil
+}
+
+// AssertHubSpokeTopology reads a HubSpokeTopology from a YAML file and fails the test with every issue found, see CheckHubSpokeTopologyE
+func AssertHubSpokeTopology(t *testing.T, filePath string) {
+	t.Helper()
+	topology, err := LoadHubSpokeTopologyE(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := CheckHubSpokeTopologyWithContextE(NewTestContext(t), *topology)
+	failOnAzureError(t, err, "check the topology of", "Microsoft.Network/virtualNetworks", topology.Hub.VirtualNetwork, topology.Hub.ResourceGroup)
+	if len(issues) > 0 {
+		t.Errorf("Hub-and-spoke topology of %s has %d issues:\n  %s", topology.Hub.VirtualNetwork, len(issues), strings.Join(issues, "\n  "))
+	}
+}
+
+// checkSpoke checks the peering, default routes, DNS servers and DDoS protection of a spoke
+func checkSpoke(ctx context.Context, topology HubSpokeTopology, description TopologyVirtualNetwork, spoke *network.VirtualNetwork,
+	subscriptionID string, hubID string, firewallIP string, planID string) ([]string, error) {
+	issues := make([]string, 0)
+	if spoke.VirtualNetworkPropertiesFormat == nil {
+		return []string{"virtual network has no properties"}, nil
+	}
+
+	// peering with the hub
+	var hubPeering *network.VirtualNetworkPeering
+	if spoke.VirtualNetworkPeerings != nil {
+		for i, peering := range *spoke.VirtualNetworkPeerings {
+			if peering.VirtualNetworkPeeringPropertiesFormat != nil && peering.RemoteVirtualNetwork != nil && peering.RemoteVirtualNetwork.ID != nil &&
+				strings.EqualFold(strings.TrimRight(*peering.RemoteVirtualNetwork.ID, "/"), hubID) {
+				hubPeering = &(*spoke.VirtualNetworkPeerings)[i]
+				break
+			}
+		}
+	}
+	if hubPeering == nil {
+		issues = append(issues, "not peered with the hub")
+	} else {
+		spokeID := virtualNetworkID(subscriptionID, description.ResourceGroup, description.VirtualNetwork)
+		peeringIssues, err := checkVirtualNetworkPeering(ctx, spokeID, *hubPeering, PeeringRoleSpoke)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, peeringIssues...)
+		if topology.Gateway.Name != "" && !boolValue(hubPeering.UseRemoteGateways) {
+			issues = append(issues, fmt.Sprintf("peering %s doesn't use the gateway of the hub", stringValue(hubPeering.Name)))
+		}
+	}
+
+	// default route of every subnet
+	routeTables := make(map[string]*network.RouteTable)
+	if spoke.Subnets != nil {
+		for _, subnet := range *spoke.Subnets {
+			name := stringValue(subnet.Name)
+			if containsString(description.ExcludedSubnets, name) {
+				continue
+			}
+			var routeTable *network.RouteTable
+			if subnet.SubnetPropertiesFormat != nil && subnet.RouteTable != nil && subnet.RouteTable.ID != nil {
+				id := strings.ToLower(*subnet.RouteTable.ID)
+				if _, ok := routeTables[id]; !ok {
+					table, err := GetRouteTableByIDWithContextE(ctx, *subnet.RouteTable.ID)
+					if err != nil {
+						return nil, err
+					}
+					routeTables[id] = table
+				}
+				routeTable = routeTables[id]
+			}
+			if issue := checkDefaultRoute(routeTable, firewallIP); issue != "" {
+				issues = append(issues, fmt.Sprintf("subnet %s %s", name, issue))
+			}
+		}
+	}
+
+	// DNS servers
+	servers := make([]string, 0)
+	if spoke.DhcpOptions != nil && spoke.DhcpOptions.DNSServers != nil {
+		servers = *spoke.DhcpOptions.DNSServers
+	}
+	if len(topology.DNSServers) > 0 && strings.Join(servers, ",") != strings.Join(topology.DNSServers, ",") {
+		issues = append(issues, fmt.Sprintf("DNS servers are [%s], expected [%s]", strings.Join(servers, ", "), strings.Join(topology.DNSServers, ", ")))
+	}
+
+	if planID != "" {
+		issues = append(issues, checkDdosProtection(spoke, planID)...)
+	}
+	return issues, nil
+}
+
+// checkDefaultRoute tells why the default route of routeTable doesn't go to firewallIP, an empty string when it does
+func checkDefaultRoute(routeTable *network.RouteTable, firewallIP string) string {
+	routes, err := userDefinedRoutes(routeTable)
+	if err != nil {
+		return err.Error()
+	}
+	for _, route := range routes {
+		if length, bits := route.prefix.Mask.Size(); length != 0 || bits != 8*net.IPv4len {
+			continue
+		}
+		if route.hop.Type != network.RouteNextHopTypeVirtualAppliance || route.hop.IPAddress != firewallIP {
+			return fmt.Sprintf("sends the default route to %s, not to the firewall %s", route.hop, firewallIP)
+		}
+		return ""
+	}
+	if routeTable == nil {
+		return "has no route table"
+	}
+	return fmt.Sprintf("has no default route 0.0.0.0/0 in route table %s", stringValue(routeTable.Name))
+}
+
+// checkDdosProtection checks that a virtual network is protected by the DDoS protection plan planID
+func checkDdosProtection(virtualNetwork *network.VirtualNetwork, planID string) []string {
+	name := stringValue(virtualNetwork.Name)
+	properties := virtualNetwork.VirtualNetworkPropertiesFormat
+	if properties == nil || !boolValue(properties.EnableDdosProtection) {
+		return []string{fmt.Sprintf("DDoS protection of virtual network %s is disabled", name)}
+	}
+	if properties.DdosProtectionPlan == nil || !strings.EqualFold(strings.TrimRight(stringValue(properties.DdosProtectionPlan.ID), "/"), planID) {
+		plan := "none"
+		if properties.DdosProtectionPlan != nil {
+			plan = stringValue(properties.DdosProtectionPlan.ID)
+		}
+		return []string{fmt.Sprintf("virtual network %s uses DDoS protection plan %s, expected %s", name, plan, planID)}
+	}
+	return nil
+}
+
+func firewallPrivateIPAddresses(firewall *network.AzureFirewall) []string {
+	ips := make([]string, 0)
+	if firewall.AzureFirewallPropertiesFormat == nil || firewall.IPConfigurations == nil {
+		return ips
+	}
+	for _, ipConfiguration := range *firewall.IPConfigurations {
+		if ipConfiguration.AzureFirewallIPConfigurationPropertiesFormat != nil && ipConfiguration.PrivateIPAddress != nil {
+			ips = append(ips, *ipConfiguration.PrivateIPAddress)
+		}
+	}
+	return ips
+}
+
+// topologyResourceGroup returns the resource group of a resource, the one of the hub by default
+func topologyResourceGroup(resource TopologyResource, hub TopologyVirtualNetwork) string {
+	if resource.ResourceGroup != "" {
+		return resource.ResourceGroup
+	}
+	return hub.ResourceGroup
+}
+
+// topologySubscription returns the subscription of a resource, the one of the hub by default
+func topologySubscription(resource TopologyResource, hub TopologyVirtualNetwork, subscriptionID string) string {
+	return getTargetSubscription(resource.SubscriptionID, hub.SubscriptionID, subscriptionID)
+}
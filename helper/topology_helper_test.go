@@ -0,0 +1,236 @@
+package helper
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-04-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDdosProtectionPlanID = testResourceGroupID + "/providers/Microsoft.Network/ddosProtectionPlans/ddos-plan"
+	testSpokeRouteTableID    = testOtherResourceGroupID + "/providers/Microsoft.Network/routeTables/rt-spoke"
+)
+
+// testEmbeddedPeering is a peering embedded in the properties of its virtual network
+func testEmbeddedPeering(virtualNetworkID string, name string, remoteID string, flags map[string]interface{}) map[string]interface{} {
+	peering := testPeering(remoteID, flags)
+	peering["id"] = virtualNetworkID + "/virtualNetworkPeerings/" + name
+	peering["name"] = name
+	return peering
+}
+
+// testSpokeProperties are the properties of a spoke wired correctly to the hub of setupTopology
+func testSpokeProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"addressSpace":         map[string]interface{}{"addressPrefixes": []string{"10.1.0.0/16"}},
+		"dhcpOptions":          map[string]interface{}{"dnsServers": []string{"10.0.2.4"}},
+		"enableDdosProtection": true,
+		"ddosProtectionPlan":   map[string]interface{}{"id": testDdosProtectionPlanID},
+		"virtualNetworkPeerings": []interface{}{
+			testEmbeddedPeering(testSpokeVnetID, "spoke-to-hub", testHubVnetID, map[string]interface{}{"useRemoteGateways": true}),
+		},
+		"subnets": []interface{}{
+			map[string]interface{}{"name": "snet-app", "properties": map[string]interface{}{
+				"addressPrefix": "10.1.1.0/24",
+				"routeTable":    map[string]interface{}{"id": testSpokeRouteTableID},
+			}},
+			// excluded by the topology
+			map[string]interface{}{"name": "snet-appgw", "properties": map[string]interface{}{"addressPrefix": "10.1.2.0/24"}},
+		},
+	}
+}
+
+// testDefaultRoute is a route table sending the default route to nextHopType at nextHopIP
+func testDefaultRoute(nextHopType network.RouteNextHopType, nextHopIP string) map[string]interface{} {
+	route := map[string]interface{}{"addressPrefix": "0.0.0.0/0", "nextHopType": nextHopType}
+	if nextHopIP != "" {
+		route["nextHopIpAddress"] = nextHopIP
+	}
+	return map[string]interface{}{"properties": map[string]interface{}{
+		"routes": []interface{}{map[string]interface{}{"name": "default", "properties": route}},
+	}}
+}
+
+// setupTopology seeds the hub-and-spoke network of testdata/topology/topology.yaml without any issue
+func setupTopology(t *testing.T) *FakeARMServer {
+	t.Setenv("TEST_TOPOLOGY_SUBSCRIPTION", testSubscriptionID)
+	s := setupTestARMServer(t)
+	s.AddResource(t, testHubVnetID, map[string]interface{}{"properties": map[string]interface{}{
+		"addressSpace":         map[string]interface{}{"addressPrefixes": []string{"10.0.0.0/16"}},
+		"enableDdosProtection": true,
+		"ddosProtectionPlan":   map[string]interface{}{"id": testDdosProtectionPlanID},
+		"virtualNetworkPeerings": []interface{}{
+			testEmbeddedPeering(testHubVnetID, "hub-to-spoke", testSpokeVnetID, map[string]interface{}{"allowGatewayTransit": true}),
+		},
+	}})
+	s.AddResource(t, testResourceGroupID+"/providers/Microsoft.Network/azureFirewalls/afw-hub",
+		`{"properties": {"ipConfigurations": [{"name": "ipconfig", "properties": {"privateIPAddress": "10.0.1.4"}}]}}`)
+	s.AddResource(t, testResourceGroupID+"/providers/Microsoft.Network/virtualNetworkGateways/vgw-hub", `{"properties": {}}`)
+	s.AddResource(t, testDdosProtectionPlanID, `{"properties": {}}`)
+	s.AddResource(t, testSpokeVnetID, map[string]interface{}{"properties": testSpokeProperties()})
+	s.AddResource(t, testSpokeRouteTableID, testDefaultRoute(network.RouteNextHopTypeVirtualAppliance, "10.0.1.4"))
+	return s
+}
+
+func loadTestTopology(t *testing.T, file string) HubSpokeTopology {
+	topology, err := LoadHubSpokeTopologyE(filepath.Join("testdata", "topology", file))
+	require.NoError(t, err)
+	return *topology
+}
+
+func TestLoadHubSpokeTopologyE(t *testing.T) {
+	t.Setenv("TEST_TOPOLOGY_SUBSCRIPTION", testSubscriptionID)
+	topology := loadTestTopology(t, "topology.yaml")
+	assert.Equal(t, HubSpokeTopology{
+		SubscriptionID:     testSubscriptionID,
+		Hub:                TopologyVirtualNetwork{ResourceGroup: "rg-test", VirtualNetwork: "vnet-hub"},
+		Firewall:           TopologyFirewall{TopologyResource: TopologyResource{Name: "afw-hub"}},
+		Gateway:            TopologyResource{Name: "vgw-hub"},
+		DNSServers:         []string{"10.0.2.4"},
+		DdosProtectionPlan: TopologyResource{Name: "ddos-plan"},
+		Spokes: []TopologyVirtualNetwork{
+			{ResourceGroup: "rg-other", VirtualNetwork: "vnet-spoke", ExcludedSubnets: []string{"snet-appgw"}},
+		},
+	}, topology)
+
+	_, err := LoadHubSpokeTopologyE(filepath.Join("testdata", "topology", "no_firewall.yaml"))
+	assert.EqualError(t, err, "Invalid topology "+filepath.Join("testdata", "topology", "no_firewall.yaml")+": the firewall needs a name or a private IP address")
+}
+
+func TestCheckHubSpokeTopologyE(t *testing.T) {
+	setupTopology(t)
+
+	issues, err := CheckHubSpokeTopologyE(loadTestTopology(t, "topology.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	AssertHubSpokeTopology(t, filepath.Join("testdata", "topology", "topology.yaml"))
+}
+
+func TestCheckHubSpokeTopologyEIssues(t *testing.T) {
+	cases := []struct {
+		name     string
+		setup    func(t *testing.T, s *FakeARMServer)
+		topology func(topology *HubSpokeTopology)
+		issues   []string
+	}{
+		{"default route away from the firewall", func(t *testing.T, s *FakeARMServer) {
+			s.AddResource(t, testSpokeRouteTableID, testDefaultRoute(network.RouteNextHopTypeVirtualAppliance, "10.0.1.5"))
+		}, nil, []string{"spoke vnet-spoke: subnet snet-app sends the default route to VirtualAppliance 10.0.1.5 (route default for 0.0.0.0/0), not to the firewall 10.0.1.4"}},
+		{"default route to Internet", func(t *testing.T, s *FakeARMServer) {
+			s.AddResource(t, testSpokeRouteTableID, testDefaultRoute(network.RouteNextHopTypeInternet, ""))
+		}, nil, []string{"spoke vnet-spoke: subnet snet-app sends the default route to Internet (route default for 0.0.0.0/0), not to the firewall 10.0.1.4"}},
+		{"no default route", func(t *testing.T, s *FakeARMServer) {
+			s.AddResource(t, testSpokeRouteTableID, `{"properties": {"routes": []}}`)
+		}, nil, []string{"spoke vnet-spoke: subnet snet-app has no default route 0.0.0.0/0 in route table rt-spoke"}},
+		{"subnet without route table", nil, func(topology *HubSpokeTopology) {
+			topology.Spokes[0].ExcludedSubnets = nil
+		}, []string{"spoke vnet-spoke: subnet snet-appgw has no route table"}},
+		{"spoke without DDoS protection", func(t *testing.T, s *FakeARMServer) {
+			properties := testSpokeProperties()
+			properties["enableDdosProtection"] = false
+			s.AddResource(t, testSpokeVnetID, map[string]interface{}{"properties": properties})
+		}, nil, []string{"spoke vnet-spoke: DDoS protection of virtual network vnet-spoke is disabled"}},
+		{"hub with another DDoS protection plan", func(t *testing.T, s *FakeARMServer) {
+			otherPlanID := testOtherResourceGroupID + "/providers/Microsoft.Network/ddosProtectionPlans/ddos-other"
+			s.AddResource(t, otherPlanID, `{"properties": {}}`)
+		}, func(topology *HubSpokeTopology) {
+			topology.DdosProtectionPlan = TopologyResource{ResourceGroup: "rg-other", Name: "ddos-other"}
+		}, []string{
+			"virtual network vnet-hub uses DDoS protection plan " + testDdosProtectionPlanID + ", expected " + testOtherResourceGroupID + "/providers/Microsoft.Network/ddosProtectionPlans/ddos-other",
+			"spoke vnet-spoke: virtual network vnet-spoke uses DDoS protection plan " + testDdosProtectionPlanID + ", expected " + testOtherResourceGroupID + "/providers/Microsoft.Network/ddosProtectionPlans/ddos-other",
+		}},
+		{"DNS servers", nil, func(topology *HubSpokeTopology) {
+			topology.DNSServers = []string{"10.0.2.4", "10.0.2.5"}
+		}, []string{"spoke vnet-spoke: DNS servers are [10.0.2.4], expected [10.0.2.4, 10.0.2.5]"}},
+		{"spoke not using the gateway", func(t *testing.T, s *FakeARMServer) {
+			properties := testSpokeProperties()
+			properties["virtualNetworkPeerings"] = []interface{}{testEmbeddedPeering(testSpokeVnetID, "spoke-to-hub", testHubVnetID, nil)}
+			s.AddResource(t, testSpokeVnetID, map[string]interface{}{"properties": properties})
+		}, nil, []string{"spoke vnet-spoke: peering spoke-to-hub doesn't use the gateway of the hub"}},
+		{"spoke with gateway transit", func(t *testing.T, s *FakeARMServer) {
+			properties := testSpokeProperties()
+			properties["virtualNetworkPeerings"] = []interface{}{testEmbeddedPeering(testSpokeVnetID, "spoke-to-hub", testHubVnetID,
+				map[string]interface{}{"useRemoteGateways": true, "allowGatewayTransit": true})}
+			s.AddResource(t, testSpokeVnetID, map[string]interface{}{"properties": properties})
+		}, nil, []string{"spoke vnet-spoke: peering spoke-to-hub of the spoke allows gateway transit"}},
+		{"spoke not peered", func(t *testing.T, s *FakeARMServer) {
+			properties := testSpokeProperties()
+			delete(properties, "virtualNetworkPeerings")
+			s.AddResource(t, testSpokeVnetID, map[string]interface{}{"properties": properties})
+		}, nil, []string{"spoke vnet-spoke: not peered with the hub"}},
+		{"firewall address", nil, func(topology *HubSpokeTopology) {
+			topology.Firewall.PrivateIPAddress = "10.0.1.5"
+		}, []string{
+			"firewall afw-hub has private IP addresses [10.0.1.4], not 10.0.1.5",
+			"spoke vnet-spoke: subnet snet-app sends the default route to VirtualAppliance 10.0.1.4 (route default for 0.0.0.0/0), not to the firewall 10.0.1.5",
+		}},
+		{"missing gateway and spoke", nil, func(topology *HubSpokeTopology) {
+			topology.Gateway.Name = "vgw-missing"
+			topology.Spokes = append(topology.Spokes, TopologyVirtualNetwork{ResourceGroup: "rg-other", VirtualNetwork: "vnet-missing"})
+		}, []string{
+			"gateway vgw-missing doesn't exist",
+			"spoke vnet-missing doesn't exist",
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := setupTopology(t)
+			if c.setup != nil {
+				c.setup(t, s)
+			}
+			topology := loadTestTopology(t, "topology.yaml")
+			if c.topology != nil {
+				c.topology(&topology)
+			}
+			issues, err := CheckHubSpokeTopologyE(topology)
+			require.NoError(t, err)
+			assert.Equal(t, c.issues, issues)
+		})
+	}
+}
+
+func TestCheckDefaultRoute(t *testing.T) {
+	routeTable := func(routes ...network.Route) *network.RouteTable {
+		return &network.RouteTable{Name: to.StringPtr("rt"), RouteTablePropertiesFormat: &network.RouteTablePropertiesFormat{Routes: &routes}}
+	}
+	route := func(prefix string, nextHopType network.RouteNextHopType, nextHopIP string) network.Route {
+		properties := &network.RoutePropertiesFormat{AddressPrefix: to.StringPtr(prefix), NextHopType: nextHopType}
+		if nextHopIP != "" {
+			properties.NextHopIPAddress = to.StringPtr(nextHopIP)
+		}
+		return network.Route{Name: to.StringPtr(prefix), RoutePropertiesFormat: properties}
+	}
+
+	assert.Equal(t, "", checkDefaultRoute(routeTable(route("0.0.0.0/0", network.RouteNextHopTypeVirtualAppliance, "10.0.1.4")), "10.0.1.4"))
+	// the IPv6 default route and the more specific routes don't count
+	assert.Equal(t, "has no default route 0.0.0.0/0 in route table rt", checkDefaultRoute(routeTable(
+		route("::/0", network.RouteNextHopTypeVirtualAppliance, "fd00::4"),
+		route("0.0.0.0/1", network.RouteNextHopTypeVirtualAppliance, "10.0.1.4"),
+	), "10.0.1.4"))
+	assert.Equal(t, "sends the default route to None (route 0.0.0.0/0 for 0.0.0.0/0), not to the firewall 10.0.1.4",
+		checkDefaultRoute(routeTable(route("0.0.0.0/0", network.RouteNextHopTypeNone, "")), "10.0.1.4"))
+	assert.Equal(t, "has no route table", checkDefaultRoute(nil, "10.0.1.4"))
+}
+
+func TestCheckDdosProtection(t *testing.T) {
+	virtualNetwork := network.VirtualNetwork{
+		Name: to.StringPtr("vnet"),
+		VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+			EnableDdosProtection: to.BoolPtr(true),
+			DdosProtectionPlan:   &network.SubResource{ID: to.StringPtr(testDdosProtectionPlanID + "/")},
+		},
+	}
+	assert.Empty(t, checkDdosProtection(&virtualNetwork, testDdosProtectionPlanID))
+
+	virtualNetwork.DdosProtectionPlan = nil
+	assert.Equal(t, []string{"virtual network vnet uses DDoS protection plan none, expected " + testDdosProtectionPlanID},
+		checkDdosProtection(&virtualNetwork, testDdosProtectionPlanID))
+
+	virtualNetwork.EnableDdosProtection = nil
+	assert.Equal(t, []string{"DDoS protection of virtual network vnet is disabled"}, checkDdosProtection(&virtualNetwork, testDdosProtectionPlanID))
+}
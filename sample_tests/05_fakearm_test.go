@@ -0,0 +1,40 @@
+// +build 05 fakearm
+
+package gart
+
+import (
+	"testing"
+
+	"gart/helper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test05FakeARMServer checks a virtual network without Azure, against resources seeded in a fake ARM server
+func Test05FakeARMServer(t *testing.T) {
+	t.Setenv(helper.SubscriptionIDEnvName, "00000000-0000-0000-0000-000000000000")
+	server := helper.SetupFakeARMServer(t)
+	vnetID := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg-test/providers/Microsoft.Network/virtualNetworks/vnet-test"
+	server.AddResource(t, vnetID, `{
+		"location": "westeurope",
+		"properties": {
+			"addressSpace": {"addressPrefixes": ["10.0.0.0/16"]},
+			"subnets": [{
+				"id": "`+vnetID+`/subnets/snet-app",
+				"name": "snet-app",
+				"properties": {"addressPrefix": "10.0.1.0/24"}
+			}]
+		}
+	}`)
+
+	vnet, err := helper.GetVirtualNetworkE("rg-test", "vnet-test")
+	require.NoError(t, err)
+	assert.Equal(t, "westeurope", *vnet.Location)
+
+	subnet, err := helper.GetSubnetE("rg-test", "vnet-test", "snet-app")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.1.0/24", *subnet.AddressPrefix)
+
+	_, err = helper.GetVirtualNetworkE("rg-test", "vnet-missing")
+	assert.Error(t, err)
+}